@@ -0,0 +1,343 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// androidNameRe matches the valid names of Android string resources.
+var androidNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+/*
+Android parses the content of Android string resources files (res/values-<lang>/strings.xml)
+and provides all the Translation functions needed.
+And it's safe for concurrent use by multiple goroutines by using the sync package for locking.
+
+Resource names are the msgids of the entries, as in catalogs keyed by identifiers.
+Strings are read with the Android escaping rules, and their positional arguments, like "%1$s",
+are written as the "%[1]s" of the fmt package. Other elements, like string arrays, are ignored.
+
+Quantities of plurals are mapped to the plural forms of the Plural-Forms formula,
+which files don't include: use SetHeaders before parsing for other languages than the Western ones.
+
+Example:
+
+	import (
+		"fmt"
+		"net/textproto"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		// Create android object
+		a := new(gotext.Android)
+		a.SetHeaders(textproto.MIMEHeader{
+			"Language":     {"pl"},
+			"Plural-Forms": {"nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);"},
+		})
+
+		// Parse strings.xml file
+		a.ParseFile("/path/to/res/values-pl/strings.xml")
+
+		// Get Translation
+		fmt.Println(a.GetN("files_count", "files_count", 3, 3))
+	}
+*/
+type Android struct {
+	catalog
+}
+
+// NewAndroidTranslator creates a new Android object with the Translator interface
+func NewAndroidTranslator() Translator {
+	return new(Android)
+}
+
+// SetHeaders sets the headers of the catalog, as found in the header entry of PO files.
+// Their Plural-Forms is used to map the quantities of the plurals parsed afterwards.
+func (a *Android) SetHeaders(h textproto.MIMEHeader) {
+	a.Lock()
+	defer a.Unlock()
+
+	a.setHeaders(h)
+}
+
+// ParseFile tries to read the file by its provided path (f) and parse its content as Android string resources.
+// Gzip compressed files, like strings.xml.gz, are decompressed.
+func (a *Android) ParseFile(f string) {
+	a.ParseFileE(f)
+}
+
+// ParseFileE works like ParseFile, but returns an error when the file can't be read or parsed.
+// A missing file or a directory path returns an error wrapping ErrNotFound.
+func (a *Android) ParseFileE(f string) error {
+	return a.parseFile(f, a.ParseE)
+}
+
+// Parse loads the translations specified in the provided Android string resources content (buf).
+func (a *Android) Parse(buf []byte) {
+	a.ParseE(buf)
+}
+
+// ParseE works like Parse, but returns a *ParseError describing the first problem found.
+// Nothing is loaded when buf isn't valid XML. Resources without name, and plural items
+// with an unknown quantity, are reported with an error wrapping ErrInvalidCatalog,
+// and the other ones are still loaded.
+func (a *Android) ParseE(buf []byte) error {
+	// Lock while parsing, to map the quantities with the current Plural-Forms
+	a.Lock()
+	defer a.Unlock()
+
+	trs, err := a.parseAndroid(buf)
+	for _, tr := range trs {
+		a.add(tr)
+	}
+
+	return err
+}
+
+// parseAndroid reads the strings and plurals of Android string resources.
+// It returns the first schema error along with the valid resources,
+// or only an error when data isn't valid XML.
+// It must be called with the lock held.
+func (a *Android) parseAndroid(data []byte) ([]*Translation, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	// fail reports err at the current position
+	fail := func(err error) *ParseError {
+		line, col := dec.InputPos()
+		return &ParseError{Line: line, Column: col, Err: err}
+	}
+
+	// Root element
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, fail(fmt.Errorf("%w: missing resources element", ErrInvalidCatalog))
+		}
+		if err != nil {
+			return nil, xmlError(err)
+		}
+		if root, ok := tok.(xml.StartElement); ok {
+			if root.Name.Local != "resources" {
+				return nil, fail(fmt.Errorf("%w: root element %q isn't resources", ErrInvalidCatalog, root.Name.Local))
+			}
+			break
+		}
+	}
+
+	categories := cldrCategories(a.nplurals, a.pluralForm)
+
+	trs := []*Translation{}
+	var first error
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, xmlError(err)
+		}
+
+		var se xml.StartElement
+		switch t := tok.(type) {
+		case xml.StartElement:
+			se = t
+		case xml.EndElement:
+			return trs, first
+		default:
+			continue
+		}
+
+		// Report the position of the resource
+		perr := fail(nil)
+		name := xmlAttr(se, "name")
+
+		switch se.Name.Local {
+		case "string":
+			text, err := androidText(dec)
+			if err != nil {
+				return nil, xmlError(err)
+			}
+			if name == "" {
+				perr.Err = fmt.Errorf("%w: string without name", ErrInvalidCatalog)
+				break
+			}
+
+			tr := NewTranslation()
+			tr.ID = name
+			tr.Trs[0] = text
+			trs = append(trs, tr)
+
+		case "plurals":
+			items, err := androidItems(dec)
+			if err != nil {
+				return nil, xmlError(err)
+			}
+			if name == "" {
+				perr.Err = fmt.Errorf("%w: plurals without name", ErrInvalidCatalog)
+				break
+			}
+
+			tr := NewTranslation()
+			tr.ID = name
+			tr.PluralID = name
+			perr.Err = setCLDRForms(tr, items, categories, a.pluralForm, a.Language)
+			trs = append(trs, tr)
+
+		default:
+			if err := dec.Skip(); err != nil {
+				return nil, xmlError(err)
+			}
+		}
+
+		if perr.Err != nil && first == nil {
+			first = perr
+		}
+	}
+}
+
+// xmlAttr returns the value of the attribute name of se.
+func xmlAttr(se xml.StartElement, name string) string {
+	for _, attr := range se.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+// androidItems reads the items of the plurals element the decoder is in.
+func androidItems(dec *xml.Decoder) ([]cldrItem, error) {
+	var items []cldrItem
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "item" {
+				if err := dec.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			text, err := androidText(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, cldrItem{category: xmlAttr(t, "quantity"), text: text})
+
+		case xml.EndElement:
+			return items, nil
+		}
+	}
+}
+
+// androidText reads the text of the element the decoder is in, along with the text of its
+// child elements, like <b> or <xliff:g>, and unescapes it.
+func androidText(dec *xml.Decoder) (string, error) {
+	var sb strings.Builder
+	for depth := 1; depth > 0; {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+
+		switch t := tok.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	return androidUnescape(sb.String()), nil
+}
+
+// androidUnescape returns the string of an Android resource text: whitespace runs out of double quotes
+// are collapsed, and trimmed, escape sequences are replaced, and positional arguments are written as in fmt.
+func androidUnescape(s string) string {
+	var sb strings.Builder
+	quoted, space := false, false
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '"':
+			quoted = !quoted
+			continue
+
+		case r == '\\' && i+1 < len(runes):
+			i++
+			switch r = runes[i]; r {
+			case 'n':
+				r = '\n'
+			case 't':
+				r = '\t'
+			case 'u':
+				if i+4 < len(runes) {
+					if v, err := strconv.ParseUint(string(runes[i+1:i+5]), 16, 32); err == nil {
+						r = rune(v)
+						i += 4
+					}
+				}
+			}
+
+		case !quoted && unicode.IsSpace(r):
+			space = true
+			continue
+		}
+
+		if space {
+			if sb.Len() > 0 {
+				sb.WriteByte(' ')
+			}
+			space = false
+		}
+		sb.WriteRune(r)
+	}
+
+	return javaToGoFormat(sb.String())
+}
+
+// javaToGoFormat replaces the positional arguments of Java format strings, like "%1$s", with the "%[1]s" of fmt.
+func javaToGoFormat(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		sb.WriteByte(s[i])
+		if s[i] != '%' || i+1 == len(s) {
+			continue
+		}
+		if s[i+1] == '%' {
+			sb.WriteByte('%')
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		if j > i+1 && j < len(s) && s[j] == '$' {
+			sb.WriteString("[" + s[i+1:j] + "]")
+			i = j
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"net/textproto"
+	"testing"
+)
+
+const polishPluralForms = "nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);"
+
+const androidStr = `<?xml version="1.0" encoding="utf-8"?>
+<resources xmlns:xliff="urn:oasis:names:tc:xliff:document:1.2">
+    <string name="app_name">Moja aplikacja</string>
+    <string name="welcome">Witaj, <xliff:g id="user">%1$s</xliff:g>! Masz %2$d wiadomości.</string>
+    <string name="escaped">Don\'t \"quote\" \@me\nplease A</string>
+    <string name="spaces">
+        Collapsed
+        whitespace   here
+    </string>
+    <string name="quoted">"  Keep   spaces  "</string>
+    <string name="markup">Some <b>bold</b> text &amp; more</string>
+    <string-array name="planets">
+        <item>Merkury</item>
+    </string-array>
+    <plurals name="files">
+        <item quantity="one">%d plik</item>
+        <item quantity="few">%d pliki</item>
+        <item quantity="many">%d plików</item>
+        <item quantity="other">%d pliku</item>
+    </plurals>
+    <plurals name="songs">
+        <item quantity="one">%d piosenka</item>
+        <item quantity="other">%d piosenek</item>
+    </plurals>
+</resources>
+`
+
+func TestAndroid(t *testing.T) {
+	a := new(Android)
+	a.SetHeaders(textproto.MIMEHeader{"Language": {"pl"}, "Plural-Forms": {polishPluralForms}})
+	if err := a.ParseE([]byte(androidStr)); err != nil {
+		t.Fatal(err)
+	}
+
+	get, getN := a.Get, a.GetN
+	for _, c := range []struct{ got, expected string }{
+		{get("app_name"), "Moja aplikacja"},
+		{get("welcome", "Ania", 3), "Witaj, Ania! Masz 3 wiadomości."},
+		{get("escaped"), "Don't \"quote\" @me\nplease A"},
+		{get("spaces"), "Collapsed whitespace here"},
+		{get("quoted"), "  Keep   spaces  "},
+		{get("markup"), "Some bold text & more"},
+		{get("planets"), "planets"},
+		{getN("files", "files", 1, 1), "1 plik"},
+		{getN("files", "files", 3, 3), "3 pliki"},
+		{getN("files", "files", 5, 5), "5 plików"},
+		{getN("songs", "songs", 1, 1), "1 piosenka"},
+		{getN("songs", "songs", 22, 22), "22 piosenek"},
+		{getN("songs", "songs", 12, 12), "12 piosenek"},
+		{getN("songs", "songs", 5, 5), "5 piosenek"},
+	} {
+		if c.got != c.expected {
+			t.Errorf("Expected '%s' but got '%s'", c.expected, c.got)
+		}
+	}
+}
+
+func TestAndroidErrors(t *testing.T) {
+	for name, c := range map[string]struct {
+		xml  string
+		line int
+	}{
+		"syntax":   {"<resources>\n<string name=\"a\">A</resources>", 2},
+		"root":     {"<?xml version=\"1.0\"?>\n<xliff/>", 2},
+		"empty":    {"", 1},
+		"name":     {"<resources>\n<string name=\"a\">A</string>\n<string>B</string>\n</resources>", 3},
+		"quantity": {"<resources>\n<plurals name=\"p\">\n<item quantity=\"lots\">X</item>\n</plurals>\n</resources>", 2},
+	} {
+		err := new(Android).ParseE([]byte(c.xml))
+
+		var pe *ParseError
+		if !errors.As(err, &pe) || !errors.Is(err, ErrInvalidCatalog) {
+			t.Errorf("%s: expected ErrInvalidCatalog but got '%v'", name, err)
+			continue
+		}
+		if pe.Line != c.line {
+			t.Errorf("%s: expected error on line %d but got '%v'", name, c.line, err)
+		}
+	}
+
+	// Valid resources are still loaded
+	a := new(Android)
+	a.Parse([]byte("<resources><string>None</string><string name=\"hello\">Hallo</string></resources>"))
+	if s := a.Get("hello"); s != "Hallo" {
+		t.Errorf("Expected 'Hallo' but got '%s'", s)
+	}
+}
+
+func TestAndroidFormat(t *testing.T) {
+	for java, goFmt := range map[string]string{
+		"%1$s and %2$d":   "%[1]s and %[2]d",
+		"100%% of %1$s":   "100%% of %[1]s",
+		"%d files":        "%d files",
+		"%%1$s":           "%%1$s",
+		"trailing %":      "trailing %",
+		"%$s and %12$.2f": "%$s and %[12].2f",
+	} {
+		if s := javaToGoFormat(java); s != goFmt {
+			t.Errorf("Expected '%s' but got '%s'", goFmt, s)
+		}
+		if s := goToJavaFormat(goFmt); s != java {
+			t.Errorf("Expected '%s' but got '%s'", java, s)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// xmlTextReplacer escapes the XML special characters of texts.
+var xmlTextReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+/*
+AndroidEncoder writes catalogs as Android string resources files (res/values-<lang>/strings.xml),
+so the catalogs of Go code can be the source of the translations of Android apps.
+Any Translator can be encoded, as its entries are read through MarshalBinary.
+
+The msgids of the entries without context are the resource names by default, as in catalogs keyed
+by identifiers like "welcome_title". Set NameFunc to name the other entries, like the ones
+of catalogs keyed by their source text. Entries without valid name are reported with an error
+wrapping ErrUnnamed, once the other ones are written. Plural entries are written as plurals,
+with the quantity of every form found with the Plural-Forms formula of the catalog.
+
+Only the translated entries are written by default, as Android uses the default resources
+for the names a strings.xml file doesn't have. Set Untranslated to write the untranslated and fuzzy
+entries too, with their msgid and msgid_plural as text, like in the default strings.xml translators start from.
+
+Example:
+
+	import (
+		"os"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		po := new(gotext.Po)
+		po.ParseFile("/path/to/po/file/translations.po")
+
+		f, _ := os.Create("/path/to/res/values-de/strings.xml")
+		defer f.Close()
+
+		gotext.NewAndroidEncoder(f).Encode(po)
+	}
+*/
+type AndroidEncoder struct {
+	// Indent of the nested elements, four spaces by default.
+	Indent string
+
+	// NameFunc returns the resource name of the entry with the msgctxt ctx and the msgid id,
+	// or "" to leave it out. The msgid of the entries without context is used when it's nil.
+	NameFunc func(ctx, id string) string
+
+	// Untranslated writes the untranslated and fuzzy entries too, with their source text.
+	Untranslated bool
+
+	w io.Writer
+}
+
+// NewAndroidEncoder returns an AndroidEncoder writing to w.
+func NewAndroidEncoder(w io.Writer) *AndroidEncoder {
+	return &AndroidEncoder{
+		Indent: "    ",
+		w:      w,
+	}
+}
+
+// MarshalAndroid returns the catalog of t as the content of an Android string resources file.
+func MarshalAndroid(t Translator) ([]byte, error) {
+	var buff bytes.Buffer
+	err := NewAndroidEncoder(&buff).Encode(t)
+
+	return buff.Bytes(), err
+}
+
+// Encode writes the catalog of t as Android string resources, sorted by name.
+func (enc *AndroidEncoder) Encode(t Translator) error {
+	te, err := encodeTranslator(t)
+	if err != nil {
+		return err
+	}
+
+	// The catalog evaluates the Plural-Forms formula
+	c := new(catalog)
+	if te.Headers != nil {
+		c.setHeaders(te.Headers)
+	}
+	categories := cldrCategories(c.nplurals, c.pluralForm)
+
+	resources, unnamed := namedEntries(te, enc.NameFunc, androidNameRe, enc.Untranslated)
+
+	w := bufio.NewWriter(enc.w)
+	w.WriteString("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<resources>\n")
+	for _, name := range sortedKeys(resources) {
+		tr := resources[name]
+		if !tr.IsTranslated() || tr.IsFuzzy() {
+			tr = sourceEntry(tr, len(categories))
+		}
+		if tr.PluralID == "" {
+			w.WriteString(enc.Indent + "<string name=\"" + name + "\">" + androidEscape(tr.Trs[0]) + "</string>\n")
+			continue
+		}
+
+		w.WriteString(enc.Indent + "<plurals name=\"" + name + "\">\n")
+		for i, q := range categories {
+			if s, ok := tr.Trs[i]; ok && q != "" && s != "" {
+				w.WriteString(enc.Indent + enc.Indent + "<item quantity=\"" + q + "\">" + androidEscape(s) + "</item>\n")
+			}
+		}
+		w.WriteString(enc.Indent + "</plurals>\n")
+	}
+	w.WriteString("</resources>\n")
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return unnamedError(unnamed)
+}
+
+// namedEntries returns the translated entries of te, and the untranslated and fuzzy ones when untranslated is set,
+// by the name nameFunc returns for them, or by their msgid when it's nil and they have no context,
+// along with the entries without a name matching valid.
+// Entries are named in the order of their context and msgid, and the ones getting a name already used are left without.
+func namedEntries(te *TranslatorEncoding, nameFunc func(ctx, id string) string, valid *regexp.Regexp, untranslated bool) (map[string]*Translation, []string) {
+	entries := make(map[string]*Translation)
+	var unnamed []string
+	name := func(ctx, id string, tr *Translation) {
+		if id == "" || (!untranslated && (!tr.IsTranslated() || tr.IsFuzzy())) {
+			return
+		}
+
+		n := id
+		switch {
+		case nameFunc != nil:
+			if n = nameFunc(ctx, id); n == "" {
+				return
+			}
+		case ctx != "":
+			n = ""
+		}
+		if _, ok := entries[n]; ok || !valid.MatchString(n) {
+			unnamed = append(unnamed, entryString(ctx, id))
+			return
+		}
+		entries[n] = tr
+	}
+
+	for _, id := range sortedKeys(te.Translations) {
+		name("", id, te.Translations[id])
+	}
+	contexts := make([]string, 0, len(te.Contexts))
+	for ctx := range te.Contexts {
+		contexts = append(contexts, ctx)
+	}
+	sort.Strings(contexts)
+	for _, ctx := range contexts {
+		for _, id := range sortedKeys(te.Contexts[ctx]) {
+			name(ctx, id, te.Contexts[ctx][id])
+		}
+	}
+
+	return entries, unnamed
+}
+
+// sourceEntry returns a copy of tr translated with its source text: the msgid for the form 0,
+// and the msgid_plural for the other ones of the forms of plural entries.
+func sourceEntry(tr *Translation, forms int) *Translation {
+	src := *tr
+	src.Trs = map[int]string{0: tr.ID}
+	if tr.PluralID != "" {
+		for i := 1; i < forms; i++ {
+			src.Trs[i] = tr.PluralID
+		}
+	}
+
+	return &src
+}
+
+// entryString returns the msgctxt and msgid of an entry, quoted as in PO files.
+func entryString(ctx, id string) string {
+	if ctx == "" {
+		return "msgid " + strconv.Quote(id)
+	}
+
+	return "msgctxt " + strconv.Quote(ctx) + " msgid " + strconv.Quote(id)
+}
+
+// unnamedError returns an error wrapping ErrUnnamed listing the entries, or nil when there aren't any.
+func unnamedError(entries []string) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnnamed, strings.Join(entries, ", "))
+}
+
+// androidEscape returns s as the text of an Android resource: escaped, with the positional arguments
+// of Java format strings, and in double quotes when it has whitespace Android would collapse.
+func androidEscape(s string) string {
+	s = goToJavaFormat(s)
+
+	var sb strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\'':
+			sb.WriteString(`\'`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '@', '?':
+			// Resource references start with them
+			if i == 0 {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	out := sb.String()
+	if strings.HasPrefix(s, " ") || strings.HasSuffix(s, " ") || strings.Contains(s, "  ") {
+		out = `"` + out + `"`
+	}
+
+	return xmlTextReplacer.Replace(out)
+}
+
+// goToJavaFormat replaces the indexed arguments of fmt, like "%[1]s", with the "%1$s" of Java format strings.
+func goToJavaFormat(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		sb.WriteByte(s[i])
+		if s[i] != '%' || i+1 == len(s) {
+			continue
+		}
+		if s[i+1] == '%' {
+			sb.WriteByte('%')
+			i++
+			continue
+		}
+		if s[i+1] != '[' {
+			continue
+		}
+
+		j := i + 2
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		if j > i+2 && j < len(s) && s[j] == ']' {
+			sb.WriteString(s[i+2:j] + "$")
+			i = j
+		}
+	}
+
+	return sb.String()
+}
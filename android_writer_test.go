@@ -0,0 +1,162 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const androidPo = `msgid ""
+msgstr ""
+"Language: pl\n"
+"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+msgid "welcome"
+msgstr "Witaj, %[1]s! Masz %[2]d wiadomości."
+
+msgid "escaped"
+msgstr "Don't \"quote\" <b>me</b>\nplease \\ 100%%"
+
+msgid "reference"
+msgstr "@string/app_name"
+
+msgid "spaces"
+msgstr " two  spaces "
+
+msgid "files"
+msgid_plural "files"
+msgstr[0] "%d plik"
+msgstr[1] "%d pliki"
+msgstr[2] "%d plików"
+
+msgctxt "menu"
+msgid "open"
+msgstr "Otwórz"
+
+msgid "Not a resource name"
+msgstr "Pominięty"
+
+#, fuzzy
+msgid "fuzzy"
+msgstr "Niepewny"
+
+msgid "untranslated"
+msgstr ""
+`
+
+const androidExpected = `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <string name="escaped">Don\'t \"quote\" &lt;b&gt;me&lt;/b&gt;\nplease \\ 100%%</string>
+    <plurals name="files">
+        <item quantity="one">%d plik</item>
+        <item quantity="few">%d pliki</item>
+        <item quantity="other">%d plików</item>
+    </plurals>
+    <string name="reference">\@string/app_name</string>
+    <string name="spaces">" two  spaces "</string>
+    <string name="welcome">Witaj, %1$s! Masz %2$d wiadomości.</string>
+</resources>
+`
+
+func TestMarshalAndroid(t *testing.T) {
+	po := new(Po)
+	if err := po.ParseE([]byte(androidPo)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries without valid name are reported
+	out, err := MarshalAndroid(po)
+	if !errors.Is(err, ErrUnnamed) || !strings.Contains(err.Error(), `msgid "Not a resource name", msgctxt "menu" msgid "open"`) {
+		t.Errorf("Expected ErrUnnamed for the skipped entries but got '%v'", err)
+	}
+	if string(out) != androidExpected {
+		t.Errorf("Expected\n%s\nbut got\n%s", androidExpected, out)
+	}
+
+	// Back to a catalog
+	a := new(Android)
+	a.SetHeaders(po.Headers)
+	if err := a.ParseE(out); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"welcome", "escaped", "reference", "spaces"} {
+		if s, expected := a.GetTranslation(id).Get(), po.GetTranslation(id).Get(); s != expected {
+			t.Errorf("Expected '%s' but got '%s'", expected, s)
+		}
+	}
+	for n := 0; n < 30; n++ {
+		if s, expected := a.GetN("files", "files", n, n), po.GetN("files", "files", n, n); s != expected {
+			t.Errorf("Expected '%s' but got '%s'", expected, s)
+		}
+	}
+}
+
+func TestAndroidNameFunc(t *testing.T) {
+	po := new(Po)
+	if err := po.ParseE([]byte(androidPo)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buff bytes.Buffer
+	enc := NewAndroidEncoder(&buff)
+	enc.NameFunc = func(ctx, id string) string {
+		switch {
+		case ctx != "":
+			return ctx + "_" + id
+		case id == "Not a resource name":
+			return "skipped"
+		case id == "spaces":
+			return "welcome"
+		}
+		return id
+	}
+
+	// Names used twice are reported
+	err := enc.Encode(po)
+	if !errors.Is(err, ErrUnnamed) || !strings.HasSuffix(err.Error(), `: msgid "welcome"`) {
+		t.Errorf("Expected ErrUnnamed for the second welcome name but got '%v'", err)
+	}
+
+	s := buff.String()
+	for _, expected := range []string{
+		`<string name="menu_open">Otwórz</string>`,
+		`<string name="skipped">Pominięty</string>`,
+		`<string name="welcome">" two  spaces "</string>`,
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("Expected '%s' in\n%s", expected, s)
+		}
+	}
+}
+
+func TestAndroidUntranslated(t *testing.T) {
+	po := new(Po)
+	if err := po.ParseE([]byte(androidPo)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buff bytes.Buffer
+	enc := NewAndroidEncoder(&buff)
+	enc.Untranslated = true
+	if err := enc.Encode(po); !errors.Is(err, ErrUnnamed) {
+		t.Errorf("Expected ErrUnnamed but got '%v'", err)
+	}
+
+	// The other entries are written with their source text
+	s := buff.String()
+	for _, expected := range []string{
+		`<string name="fuzzy">fuzzy</string>`,
+		`<string name="untranslated">untranslated</string>`,
+		`<string name="welcome">Witaj, %1$s! Masz %2$d wiadomości.</string>`,
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("Expected '%s' in\n%s", expected, s)
+		}
+	}
+}
@@ -0,0 +1,683 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// appleContextSeparator separates the msgctxt from the msgid in the keys of Apple catalogs.
+const appleContextSeparator = "|"
+
+// Keys of .stringsdict plural rules
+const (
+	appleFormatKey     = "NSStringLocalizedFormatKey"
+	appleSpecTypeKey   = "NSStringFormatSpecTypeKey"
+	appleValueTypeKey  = "NSStringFormatValueTypeKey"
+	applePluralRule    = "NSStringPluralRuleType"
+	appleValueVariable = "value"
+)
+
+// appleVariableRe matches the variables of .stringsdict format keys, like "%#@files@".
+var appleVariableRe = regexp.MustCompile(`%(?:\d+\$)?#@([^@]*)@`)
+
+/*
+AppleStrings parses the content of iOS and macOS strings files, like Localizable.strings,
+and provides all the Translation functions needed.
+And it's safe for concurrent use by multiple goroutines by using the sync package for locking.
+
+Files are read as UTF-8, or as UTF-16 when they start with a byte order mark.
+The keys are the msgids, or the msgctxt and msgid separated by "|", like "menu|Open".
+Comments before an entry are read as its extracted comments, and the "%@" arguments
+and positional arguments like "%1$@" of keys and values are written as the "%s" and "%[1]s" of the fmt package.
+
+Example:
+
+	import (
+		"fmt"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		// Create strings object
+		s := gotext.NewAppleStringsTranslator()
+
+		// Parse .strings file
+		s.ParseFile("/path/to/de.lproj/Localizable.strings")
+
+		// Get Translation
+		fmt.Println(s.Get("Translate this"))
+	}
+*/
+type AppleStrings struct {
+	catalog
+}
+
+// NewAppleStringsTranslator creates a new AppleStrings object with the Translator interface
+func NewAppleStringsTranslator() Translator {
+	return new(AppleStrings)
+}
+
+// ParseFile tries to read the file by its provided path (f) and parse its content as a strings file.
+// Gzip compressed files, like Localizable.strings.gz, are decompressed.
+func (as *AppleStrings) ParseFile(f string) {
+	as.ParseFileE(f)
+}
+
+// ParseFileE works like ParseFile, but returns an error when the file can't be read or parsed.
+// A missing file or a directory path returns an error wrapping ErrNotFound.
+func (as *AppleStrings) ParseFileE(f string) error {
+	return as.parseFile(f, as.ParseE)
+}
+
+// Parse loads the translations specified in the provided strings file content (buf).
+func (as *AppleStrings) Parse(buf []byte) {
+	as.ParseE(buf)
+}
+
+// ParseE works like Parse, but returns a *ParseError wrapping ErrInvalidCatalog
+// when buf isn't a valid strings file, and nothing is loaded then.
+func (as *AppleStrings) ParseE(buf []byte) error {
+	trs, err := parseAppleStrings(decodeUTF16(buf))
+	if err != nil {
+		return err
+	}
+
+	// Lock while storing
+	as.Lock()
+	defer as.Unlock()
+
+	for _, tr := range trs {
+		as.add(tr)
+	}
+
+	return nil
+}
+
+// decodeUTF16 returns data as UTF-8, decoding it when it's UTF-16 with a byte order mark,
+// or when its first character is ASCII in UTF-16. An UTF-8 byte order mark is removed.
+func decodeUTF16(data []byte) []byte {
+	var bo binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}):
+		return data[3:]
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		bo, data = binary.LittleEndian, data[2:]
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		bo, data = binary.BigEndian, data[2:]
+	case len(data) >= 2 && data[0] == 0 && data[1] != 0:
+		bo = binary.BigEndian
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		bo = binary.LittleEndian
+	default:
+		return data
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = bo.Uint16(data[2*i:])
+	}
+
+	return []byte(string(utf16.Decode(units)))
+}
+
+// appleStringsParser reads the entries of strings files.
+type appleStringsParser struct {
+	data     []byte
+	off      int
+	comments []string
+}
+
+// fail returns a *ParseError at the offset off.
+func (p *appleStringsParser) fail(off int, format string, args ...interface{}) error {
+	line, col := sourcePosition(p.data, int64(off))
+	return &ParseError{Line: line, Column: col, Err: fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidCatalog}, args...)...)}
+}
+
+// skip skips the whitespace and comments, keeping the text of the comments.
+func (p *appleStringsParser) skip() error {
+	for p.off < len(p.data) {
+		switch {
+		case strings.IndexByte(" \t\r\n", p.data[p.off]) != -1:
+			p.off++
+
+		case bytes.HasPrefix(p.data[p.off:], []byte("/*")):
+			end := bytes.Index(p.data[p.off+2:], []byte("*/"))
+			if end == -1 {
+				return p.fail(p.off, "unterminated comment")
+			}
+			p.comments = append(p.comments, string(p.data[p.off+2:p.off+2+end]))
+			p.off += end + 4
+
+		case bytes.HasPrefix(p.data[p.off:], []byte("//")):
+			end := bytes.IndexByte(p.data[p.off:], '\n')
+			if end == -1 {
+				end = len(p.data) - p.off
+			}
+			p.comments = append(p.comments, string(p.data[p.off+2:p.off+end]))
+			p.off += end
+
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// isAppleUnquoted reports whether c can be part of an unquoted string.
+func isAppleUnquoted(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_$+/:.-", c) != -1
+}
+
+// str reads a quoted or unquoted string.
+func (p *appleStringsParser) str() (string, error) {
+	if p.off == len(p.data) {
+		return "", p.fail(p.off, "unexpected end of file")
+	}
+
+	start := p.off
+	if p.data[p.off] != '"' {
+		for p.off < len(p.data) && isAppleUnquoted(p.data[p.off]) {
+			p.off++
+		}
+		if p.off == start {
+			return "", p.fail(p.off, "unexpected character %q", p.data[p.off])
+		}
+		return string(p.data[start:p.off]), nil
+	}
+
+	var sb strings.Builder
+	for p.off++; p.off < len(p.data); p.off++ {
+		c := p.data[p.off]
+		switch {
+		case c == '"':
+			p.off++
+			return sb.String(), nil
+
+		case c == '\\' && p.off+1 < len(p.data):
+			p.off++
+			switch c = p.data[p.off]; c {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'a':
+				sb.WriteByte('\a')
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'v':
+				sb.WriteByte('\v')
+			case 'U', 'u':
+				if p.off+4 < len(p.data) {
+					if v, err := strconv.ParseUint(string(p.data[p.off+1:p.off+5]), 16, 32); err == nil {
+						sb.WriteRune(rune(v))
+						p.off += 4
+						continue
+					}
+				}
+				sb.WriteByte(c)
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				end := p.off + 1
+				for end < len(p.data) && end < p.off+3 && p.data[end] >= '0' && p.data[end] <= '7' {
+					end++
+				}
+				v, _ := strconv.ParseUint(string(p.data[p.off:end]), 8, 8)
+				sb.WriteByte(byte(v))
+				p.off = end - 1
+			default:
+				sb.WriteByte(c)
+			}
+
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return "", p.fail(start, "unterminated string")
+}
+
+// expect reads the character c, after the whitespace and comments.
+func (p *appleStringsParser) expect(c byte) error {
+	if err := p.skip(); err != nil {
+		return err
+	}
+	if p.off == len(p.data) || p.data[p.off] != c {
+		return p.fail(p.off, "expected %q", c)
+	}
+	p.off++
+
+	return nil
+}
+
+// parseAppleStrings reads the entries of the strings file data.
+func parseAppleStrings(data []byte) ([]*Translation, error) {
+	p := &appleStringsParser{data: data}
+
+	var trs []*Translation
+	for {
+		p.comments = nil
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.off == len(p.data) {
+			return trs, nil
+		}
+
+		key, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		comments := p.comments
+
+		// "key"; is a shortcut for "key" = "key";
+		value := key
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.off < len(p.data) && p.data[p.off] == '=' {
+			p.off++
+			if err := p.skip(); err != nil {
+				return nil, err
+			}
+			if value, err = p.str(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect(';'); err != nil {
+			return nil, err
+		}
+
+		tr := NewTranslation()
+		tr.Context, tr.ID = appleKey(key)
+		tr.Trs[0] = appleToGoFormat(value)
+		for _, c := range comments {
+			for _, line := range strings.Split(strings.TrimSpace(c), "\n") {
+				tr.ExtractedComments = append(tr.ExtractedComments, strings.TrimSpace(line))
+			}
+		}
+		trs = append(trs, tr)
+	}
+}
+
+// appleKey returns the msgctxt and msgid of the key of an Apple catalog entry.
+func appleKey(key string) (string, string) {
+	if i := strings.Index(key, appleContextSeparator); i != -1 {
+		return key[:i], appleToGoFormat(key[i+len(appleContextSeparator):])
+	}
+
+	return "", appleToGoFormat(key)
+}
+
+/*
+AppleStringsdict parses the content of iOS and macOS .stringsdict files, with the plural rules
+of the strings, and provides all the Translation functions needed.
+And it's safe for concurrent use by multiple goroutines by using the sync package for locking.
+
+Keys are mapped to entries as AppleStrings does, and their plural entries get the text of the format key
+for every plural category of its first variable, with the other variables replaced by their "other" text.
+The categories are mapped to the plural forms of the Plural-Forms formula,
+which files don't include: use SetHeaders before parsing for other languages than the Western ones.
+
+Example:
+
+	import (
+		"fmt"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		// Create stringsdict object
+		s := new(gotext.AppleStringsdict)
+
+		// Parse .stringsdict file
+		s.ParseFile("/path/to/de.lproj/Localizable.stringsdict")
+
+		// Get Translation
+		fmt.Println(s.GetN("%d files", "%d files", 3, 3))
+	}
+*/
+type AppleStringsdict struct {
+	catalog
+}
+
+// NewAppleStringsdictTranslator creates a new AppleStringsdict object with the Translator interface
+func NewAppleStringsdictTranslator() Translator {
+	return new(AppleStringsdict)
+}
+
+// SetHeaders sets the headers of the catalog, as found in the header entry of PO files.
+// Their Plural-Forms is used to map the categories of the plural rules parsed afterwards.
+func (sd *AppleStringsdict) SetHeaders(h textproto.MIMEHeader) {
+	sd.Lock()
+	defer sd.Unlock()
+
+	sd.setHeaders(h)
+}
+
+// ParseFile tries to read the file by its provided path (f) and parse its content as a .stringsdict file.
+// Gzip compressed files, like Localizable.stringsdict.gz, are decompressed.
+func (sd *AppleStringsdict) ParseFile(f string) {
+	sd.ParseFileE(f)
+}
+
+// ParseFileE works like ParseFile, but returns an error when the file can't be read or parsed.
+// A missing file or a directory path returns an error wrapping ErrNotFound.
+func (sd *AppleStringsdict) ParseFileE(f string) error {
+	return sd.parseFile(f, sd.ParseE)
+}
+
+// Parse loads the translations specified in the provided .stringsdict content (buf).
+func (sd *AppleStringsdict) Parse(buf []byte) {
+	sd.ParseE(buf)
+}
+
+// ParseE works like Parse, but returns a *ParseError describing the first problem found.
+// Nothing is loaded when buf isn't a valid property list. Entries without plural rules,
+// or with unknown plural categories, are reported with an error wrapping ErrInvalidCatalog,
+// and the other ones are still loaded.
+func (sd *AppleStringsdict) ParseE(buf []byte) error {
+	// Lock while parsing, to map the categories with the current Plural-Forms
+	sd.Lock()
+	defer sd.Unlock()
+
+	trs, err := sd.parseStringsdict(buf)
+	for _, tr := range trs {
+		sd.add(tr)
+	}
+
+	return err
+}
+
+// parseStringsdict reads the plural entries of a .stringsdict file.
+// It returns the first schema error along with the valid entries,
+// or only an error when data isn't a valid property list.
+// It must be called with the lock held.
+func (sd *AppleStringsdict) parseStringsdict(data []byte) ([]*Translation, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	// fail reports err at the current position
+	fail := func(err error) *ParseError {
+		line, col := dec.InputPos()
+		return &ParseError{Line: line, Column: col, Err: err}
+	}
+
+	// The plist element holds the dictionary of the entries
+	for depth := 0; depth < 2; {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, fail(fmt.Errorf("%w: missing plist dictionary", ErrInvalidCatalog))
+		}
+		if err != nil {
+			return nil, xmlError(err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			if expected := []string{"plist", "dict"}[depth]; se.Name.Local != expected {
+				return nil, fail(fmt.Errorf("%w: expected %s element instead of %s", ErrInvalidCatalog, expected, se.Name.Local))
+			}
+			depth++
+		}
+	}
+
+	categories := cldrCategories(sd.nplurals, sd.pluralForm)
+
+	trs := []*Translation{}
+	var first error
+	for {
+		key, err := plistKey(dec)
+		if err != nil {
+			return nil, xmlError(err)
+		}
+		if key == nil {
+			return trs, first
+		}
+
+		perr := fail(nil)
+		v, err := plistValue(dec)
+		if err != nil {
+			return nil, xmlError(err)
+		}
+
+		tr := NewTranslation()
+		tr.Context, tr.ID = appleKey(*key)
+		tr.PluralID = tr.ID
+
+		var items []cldrItem
+		if items, perr.Err = appleItems(*key, v); perr.Err == nil {
+			perr.Err = setCLDRForms(tr, items, categories, sd.pluralForm, sd.Language)
+			trs = append(trs, tr)
+		}
+		if perr.Err != nil && first == nil {
+			first = perr
+		}
+	}
+}
+
+// appleItems returns the texts of the plural categories of the rules of the key.
+func appleItems(key string, v interface{}) ([]cldrItem, error) {
+	rules, _ := v.(map[string]interface{})
+	format, _ := rules[appleFormatKey].(string)
+	vars := appleVariableRe.FindAllStringSubmatch(format, -1)
+	if len(vars) == 0 {
+		return nil, fmt.Errorf("%w: %q has no format key with a variable", ErrInvalidCatalog, key)
+	}
+
+	// The texts of the variables
+	texts := make([]map[string]interface{}, len(vars))
+	for i, m := range vars {
+		texts[i], _ = rules[m[1]].(map[string]interface{})
+		if texts[i] == nil || texts[i][appleSpecTypeKey] != applePluralRule {
+			return nil, fmt.Errorf("%w: %q has no plural rule for the variable %q", ErrInvalidCatalog, key, m[1])
+		}
+	}
+
+	// text returns the format key with the text of the category c for the first variable
+	text := func(c string) string {
+		i := 0
+		return appleVariableRe.ReplaceAllStringFunc(format, func(string) string {
+			rule := texts[i]
+			if i > 0 {
+				c = "other"
+			}
+			i++
+			s, _ := rule[c].(string)
+			return s
+		})
+	}
+
+	var items []cldrItem
+	for _, c := range []string{"zero", "one", "two", "few", "many", "other"} {
+		if _, ok := texts[0][c]; ok {
+			items = append(items, cldrItem{category: c, text: appleToGoFormat(text(c))})
+		}
+	}
+	for c := range texts[0] {
+		if c != appleSpecTypeKey && c != appleValueTypeKey && cldrSample(c) == nil {
+			return nil, fmt.Errorf("%w: %q has an unknown plural category %q", ErrInvalidCatalog, key, c)
+		}
+	}
+
+	return items, nil
+}
+
+// plistKey reads the next key of the dictionary the decoder is in, or nil at its end.
+func plistKey(dec *xml.Decoder) (*string, error) {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			v, err := plistValue(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			if t.Name.Local == "key" {
+				s, _ := v.(string)
+				return &s, nil
+			}
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+// plistValue reads the value of the element se, or of the next element if se isn't given.
+// Dictionaries are returned as maps, arrays as slices, and the other values as their text.
+func plistValue(dec *xml.Decoder, se ...xml.StartElement) (interface{}, error) {
+	if len(se) == 0 {
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			if t, ok := tok.(xml.StartElement); ok {
+				se = append(se, t)
+				break
+			}
+			if _, ok := tok.(xml.EndElement); ok {
+				return nil, nil
+			}
+		}
+	}
+
+	switch se[0].Name.Local {
+	case "dict":
+		dict := make(map[string]interface{})
+		for {
+			key, err := plistKey(dec)
+			if err != nil || key == nil {
+				return dict, err
+			}
+			if dict[*key], err = plistValue(dec); err != nil {
+				return nil, err
+			}
+		}
+
+	case "array":
+		var list []interface{}
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				v, err := plistValue(dec, t)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			case xml.EndElement:
+				return list, nil
+			}
+		}
+
+	case "true", "false":
+		return se[0].Name.Local, dec.Skip()
+	}
+
+	var sb strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.StartElement:
+			if err := dec.Skip(); err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			return sb.String(), nil
+		}
+	}
+}
+
+// appleToGoFormat replaces the arguments of Apple format strings with the ones of the fmt package:
+// positional arguments like "%1$@" are written like "%[1]s", objects ("%@") as strings, and the length modifiers are removed.
+func appleToGoFormat(s string) string {
+	return mapFormatVerbs(javaToGoFormat(s), func(spec, length string, verb byte) string {
+		switch verb {
+		case '@':
+			verb = 's'
+		case 'u', 'i':
+			verb = 'd'
+		}
+		return "%" + spec + string(verb)
+	})
+}
+
+// goToAppleFormat replaces the arguments of fmt with the ones of Apple format strings:
+// indexed arguments like "%[1]s" are written like "%1$@", and strings as objects.
+func goToAppleFormat(s string) string {
+	return mapFormatVerbs(goToJavaFormat(s), func(spec, length string, verb byte) string {
+		if verb == 's' {
+			verb = '@'
+		}
+		return "%" + spec + length + string(verb)
+	})
+}
+
+// mapFormatVerbs replaces the directives of the printf format s by the result of f,
+// which gets their flags, width, precision and argument index, their length modifier, and their verb.
+// "%%" is left as it is.
+func mapFormatVerbs(s string, f func(spec, length string, verb byte) string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			sb.WriteByte(s[i])
+			continue
+		}
+
+		j := i + 1
+		for j < len(s) && strings.IndexByte("0123456789$#+- .*[]'", s[j]) != -1 {
+			j++
+		}
+		k := j
+		for k < len(s) && strings.IndexByte("hlqLztj", s[k]) != -1 {
+			k++
+		}
+		if k == len(s) || s[k] == '%' {
+			sb.WriteString(s[i:k])
+			if k < len(s) && k == i+1 {
+				sb.WriteByte('%')
+				k++
+			}
+			i = k - 1
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[k:])
+		if size > 1 || r == utf8.RuneError {
+			sb.WriteString(s[i:k])
+			i = k - 1
+			continue
+		}
+		sb.WriteString(f(s[i+1:j], s[j:k], s[k]))
+		i = k
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"net/textproto"
+	"testing"
+	"unicode/utf16"
+)
+
+const appleStringsStr = `/* Greeting on the start page */
+"Hello" = "Hallo";
+
+// Menu entry
+"menu|Open" = "Öffnen";
+
+"welcome" = "Willkommen, %1$@! Du hast %2$ld Nachrichten.";
+"escaped" = "Ein \"Zitat\"\n\tund \\ \U00e4\101";
+unquoted_key = unquoted.value;
+"Same";
+"100%" = "100 %%";
+`
+
+func TestAppleStrings(t *testing.T) {
+	utf16le := []byte{0xff, 0xfe}
+	for _, u := range utf16.Encode([]rune(appleStringsStr)) {
+		utf16le = append(utf16le, byte(u), byte(u>>8))
+	}
+
+	for name, data := range map[string][]byte{
+		"UTF-8":     []byte(appleStringsStr),
+		"UTF-8 BOM": append([]byte{0xef, 0xbb, 0xbf}, appleStringsStr...),
+		"UTF-16LE":  utf16le,
+	} {
+		s := new(AppleStrings)
+		if err := s.ParseE(data); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		get, getC := s.Get, s.GetC
+		for _, c := range []struct{ got, expected string }{
+			{get("Hello"), "Hallo"},
+			{getC("Open", "menu"), "Öffnen"},
+			{get("welcome", "Ana", 3), "Willkommen, Ana! Du hast 3 Nachrichten."},
+			{get("escaped"), "Ein \"Zitat\"\n\tund \\ äA"},
+			{get("unquoted_key"), "unquoted.value"},
+			{get("Same"), "Same"},
+			{get("100%"), "100 %%"},
+		} {
+			if c.got != c.expected {
+				t.Errorf("%s: expected '%s' but got '%s'", name, c.expected, c.got)
+			}
+		}
+
+		if tr := s.GetTranslation("Hello"); len(tr.ExtractedComments) != 1 || tr.ExtractedComments[0] != "Greeting on the start page" {
+			t.Errorf("%s: unexpected comments %q", name, tr.ExtractedComments)
+		}
+	}
+}
+
+func TestAppleStringsErrors(t *testing.T) {
+	for name, c := range map[string]struct {
+		strings      string
+		line, column int
+	}{
+		"semicolon":  {"\"a\" = \"b\";\n\"c\" = \"d\"\n", 3, 1},
+		"unquoted":   {"\"a\" = \"b\";\n= \"d\";", 2, 1},
+		"string":     {"\"a\" = \"b;\n", 1, 7},
+		"comment":    {"\"a\" = \"b\";\n/* comment", 2, 1},
+		"equal sign": {"\"a\" \"b\";", 1, 5},
+	} {
+		s := new(AppleStrings)
+		s.Parse([]byte(appleStringsStr))
+
+		err := s.ParseE([]byte(c.strings))
+
+		var pe *ParseError
+		if !errors.As(err, &pe) || !errors.Is(err, ErrInvalidCatalog) {
+			t.Errorf("%s: expected ErrInvalidCatalog but got '%v'", name, err)
+			continue
+		}
+		if pe.Line != c.line || pe.Column != c.column {
+			t.Errorf("%s: expected error at %d:%d but got '%v'", name, c.line, c.column, err)
+		}
+
+		// Nothing is loaded
+		if get := s.Get; get("a") != "a" {
+			t.Errorf("%s: expected nothing loaded but got '%s'", name, get("a"))
+		}
+	}
+}
+
+const appleStringsdictStr = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>%d files</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@files@</string>
+		<key>files</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>d</string>
+			<key>one</key>
+			<string>%d plik</string>
+			<key>few</key>
+			<string>%d pliki</string>
+			<key>many</key>
+			<string>%d plików</string>
+			<key>other</key>
+			<string>%d pliku</string>
+		</dict>
+	</dict>
+	<key>inbox|%d messages in %d folders</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@messages@ w %#@folders@</string>
+		<key>messages</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>ld</string>
+			<key>one</key>
+			<string>%ld wiadomość</string>
+			<key>other</key>
+			<string>%ld wiadomości</string>
+		</dict>
+		<key>folders</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>ld</string>
+			<key>one</key>
+			<string>%ld folderze</string>
+			<key>other</key>
+			<string>%ld folderach</string>
+		</dict>
+	</dict>
+	<key>broken</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>no variables</string>
+	</dict>
+	<key>flags</key>
+	<array>
+		<true/>
+		<integer>1</integer>
+	</array>
+</dict>
+</plist>
+`
+
+func TestAppleStringsdict(t *testing.T) {
+	sd := new(AppleStringsdict)
+	sd.SetHeaders(textproto.MIMEHeader{"Language": {"pl"}, "Plural-Forms": {polishPluralForms}})
+
+	// The broken entry is reported, and the other ones loaded
+	err := sd.ParseE([]byte(appleStringsdictStr))
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrInvalidCatalog) || pe.Line != 52 {
+		t.Errorf("Expected ErrInvalidCatalog on line 52 but got '%v'", err)
+	}
+
+	getN, getNC := sd.GetN, sd.GetNC
+	for _, c := range []struct{ got, expected string }{
+		{getN("%d files", "%d files", 1, 1), "1 plik"},
+		{getN("%d files", "%d files", 3, 3), "3 pliki"},
+		{getN("%d files", "%d files", 5, 5), "5 plików"},
+		{getNC("%d messages in %d folders", "", 1, "inbox", 1, 4), "1 wiadomość w 4 folderach"},
+		{getNC("%d messages in %d folders", "", 3, "inbox", 3, 4), "3 wiadomości w 4 folderach"},
+	} {
+		if c.got != c.expected {
+			t.Errorf("Expected '%s' but got '%s'", c.expected, c.got)
+		}
+	}
+	if tr := sd.GetTranslation("broken"); tr != nil {
+		t.Errorf("Expected no broken entry but got %+v", tr)
+	}
+
+	// Syntax errors load nothing
+	for name, data := range map[string]string{
+		"syntax": "<plist><dict><key>a</key></plist>",
+		"root":   "<dict></dict>",
+		"empty":  "",
+	} {
+		if err := new(AppleStringsdict).ParseE([]byte(data)); !errors.Is(err, ErrInvalidCatalog) {
+			t.Errorf("%s: expected ErrInvalidCatalog but got '%v'", name, err)
+		}
+	}
+}
+
+func TestAppleFormat(t *testing.T) {
+	for apple, goFmt := range map[string]string{
+		"%@ and %1$@":     "%s and %[1]s",
+		"%ld of %lu":      "%d of %d",
+		"%.2f%% %5.1lf":   "%.2f%% %5.1f",
+		"%i items":        "%d items",
+		"trailing %":      "trailing %",
+		"%2$@ ist %1$lld": "%[2]s ist %[1]d",
+	} {
+		if s := appleToGoFormat(apple); s != goFmt {
+			t.Errorf("Expected '%s' but got '%s'", goFmt, s)
+		}
+	}
+
+	for goFmt, apple := range map[string]string{
+		"%s and %[1]s": "%@ and %1$@",
+		"%d of %v":     "%d of %v",
+		"100%% %s":     "100%% %@",
+	} {
+		if s := goToAppleFormat(goFmt); s != apple {
+			t.Errorf("Expected '%s' but got '%s'", apple, s)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+)
+
+// appleStringsReplacer escapes the quoted strings of strings files.
+var appleStringsReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+
+// appleEntry is an entry of the encoded catalog, with its key.
+type appleEntry struct {
+	key string
+	tr  *Translation
+}
+
+// appleEntries returns the translated and not fuzzy entries of te, singular or plural ones, sorted by key.
+func appleEntries(te *TranslatorEncoding, plural bool) []appleEntry {
+	var entries []appleEntry
+	add := func(ctx string, tr *Translation) {
+		if tr.ID == "" || (tr.PluralID != "") != plural || !tr.IsTranslated() || tr.IsFuzzy() {
+			return
+		}
+
+		// Keys without context, but with the separator, start with it
+		key := goToAppleFormat(tr.ID)
+		if ctx != "" || strings.Contains(key, appleContextSeparator) {
+			key = ctx + appleContextSeparator + key
+		}
+		entries = append(entries, appleEntry{key: key, tr: tr})
+	}
+	for _, tr := range te.Translations {
+		add("", tr)
+	}
+	for ctx, trs := range te.Contexts {
+		for _, tr := range trs {
+			add(ctx, tr)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	return entries
+}
+
+/*
+AppleStringsEncoder writes catalogs as UTF-8 strings files, like Localizable.strings, for iOS and macOS apps.
+Any Translator can be encoded, as its entries are read through MarshalBinary.
+
+Entries are written with the keys AppleStrings reads, and their comments, sorted by key.
+Plural entries are left out, to be written by AppleStringsdictEncoder.
+Untranslated and fuzzy entries are left out too: keys are the msgids, so the apps
+show the source text for the keys a strings file doesn't have.
+
+Example:
+
+	import (
+		"os"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		po := new(gotext.Po)
+		po.ParseFile("/path/to/po/file/translations.po")
+
+		f, _ := os.Create("/path/to/de.lproj/Localizable.strings")
+		defer f.Close()
+
+		gotext.NewAppleStringsEncoder(f).Encode(po)
+	}
+*/
+type AppleStringsEncoder struct {
+	w io.Writer
+}
+
+// NewAppleStringsEncoder returns an AppleStringsEncoder writing to w.
+func NewAppleStringsEncoder(w io.Writer) *AppleStringsEncoder {
+	return &AppleStringsEncoder{w: w}
+}
+
+// MarshalAppleStrings returns the catalog of t as the content of a strings file.
+func MarshalAppleStrings(t Translator) ([]byte, error) {
+	var buff bytes.Buffer
+	err := NewAppleStringsEncoder(&buff).Encode(t)
+
+	return buff.Bytes(), err
+}
+
+// Encode writes the singular entries of the catalog of t as a strings file.
+func (enc *AppleStringsEncoder) Encode(t Translator) error {
+	te, err := encodeTranslator(t)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(enc.w)
+	for i, e := range appleEntries(te, false) {
+		if i > 0 {
+			w.WriteString("\n")
+		}
+
+		comments := append(append([]string{}, e.tr.ExtractedComments...), e.tr.TranslatorComments...)
+		if len(comments) > 0 {
+			w.WriteString("/* " + strings.ReplaceAll(strings.Join(comments, "\n"), "*/", "* /") + " */\n")
+		}
+		w.WriteString(`"` + appleStringsReplacer.Replace(e.key) + `" = "` + appleStringsReplacer.Replace(goToAppleFormat(e.tr.Trs[0])) + "\";\n")
+	}
+
+	return w.Flush()
+}
+
+/*
+AppleStringsdictEncoder writes the plural entries of catalogs as .stringsdict files for iOS and macOS apps.
+Any Translator can be encoded, as its entries are read through MarshalBinary.
+
+Every entry gets a plural rule with a "value" variable, whose categories are found
+with the Plural-Forms formula of the catalog, and the type of the first argument of the "other" text.
+Only translated entries are written, as the apps use the strings file, or the msgid key, for the other ones.
+
+Example:
+
+	import (
+		"os"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		po := new(gotext.Po)
+		po.ParseFile("/path/to/po/file/translations.po")
+
+		f, _ := os.Create("/path/to/de.lproj/Localizable.stringsdict")
+		defer f.Close()
+
+		gotext.NewAppleStringsdictEncoder(f).Encode(po)
+	}
+*/
+type AppleStringsdictEncoder struct {
+	// Indent of the nested elements, a tab by default.
+	Indent string
+
+	w io.Writer
+}
+
+// NewAppleStringsdictEncoder returns an AppleStringsdictEncoder writing to w.
+func NewAppleStringsdictEncoder(w io.Writer) *AppleStringsdictEncoder {
+	return &AppleStringsdictEncoder{
+		Indent: "\t",
+		w:      w,
+	}
+}
+
+// MarshalAppleStringsdict returns the plural entries of the catalog of t as the content of a .stringsdict file.
+func MarshalAppleStringsdict(t Translator) ([]byte, error) {
+	var buff bytes.Buffer
+	err := NewAppleStringsdictEncoder(&buff).Encode(t)
+
+	return buff.Bytes(), err
+}
+
+// Encode writes the plural entries of the catalog of t as a .stringsdict file.
+func (enc *AppleStringsdictEncoder) Encode(t Translator) error {
+	te, err := encodeTranslator(t)
+	if err != nil {
+		return err
+	}
+
+	// The catalog evaluates the Plural-Forms formula
+	c := new(catalog)
+	if te.Headers != nil {
+		c.setHeaders(te.Headers)
+	}
+	categories := cldrCategories(c.nplurals, c.pluralForm)
+	other := indexOf(categories, "other")
+	if other == -1 {
+		other = len(categories) - 1
+	}
+
+	w := bufio.NewWriter(enc.w)
+	line := func(depth int, s string) {
+		w.WriteString(strings.Repeat(enc.Indent, depth) + s + "\n")
+	}
+	pair := func(depth int, key, value string) {
+		line(depth, "<key>"+xmlTextReplacer.Replace(key)+"</key>")
+		line(depth, "<string>"+xmlTextReplacer.Replace(value)+"</string>")
+	}
+
+	w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	w.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	w.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+	for _, e := range appleEntries(te, true) {
+		texts := make([]string, len(categories))
+		for i := range categories {
+			texts[i] = goToAppleFormat(e.tr.Trs[i])
+		}
+
+		line(1, "<key>"+xmlTextReplacer.Replace(e.key)+"</key>")
+		line(1, "<dict>")
+		pair(2, appleFormatKey, "%#@"+appleValueVariable+"@")
+		line(2, "<key>"+appleValueVariable+"</key>")
+		line(2, "<dict>")
+		pair(3, appleSpecTypeKey, applePluralRule)
+		pair(3, appleValueTypeKey, appleValueType(texts[other]))
+		for i, category := range categories {
+			if category != "" && texts[i] != "" {
+				pair(3, category, texts[i])
+			}
+		}
+		line(2, "</dict>")
+		line(1, "</dict>")
+	}
+	w.WriteString("</dict>\n</plist>\n")
+
+	return w.Flush()
+}
+
+// appleValueType returns the length modifier and verb of the first argument of the Apple format string s, "d" by default.
+func appleValueType(s string) string {
+	valueType := ""
+	mapFormatVerbs(s, func(spec, length string, verb byte) string {
+		if valueType == "" {
+			valueType = length + string(verb)
+		}
+		return ""
+	})
+	if valueType == "" {
+		return "d"
+	}
+
+	return valueType
+}
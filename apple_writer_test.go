@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"testing"
+)
+
+const applePo = `msgid ""
+msgstr ""
+"Language: pl\n"
+"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+# Greeting on the start page
+msgid "Hello"
+msgstr "Cześć"
+
+msgctxt "menu"
+msgid "Open"
+msgstr "Otwórz"
+
+msgid "Welcome, %[1]s!"
+msgstr "Witaj, %[1]s!\n\t\"Miłego dnia\" \\o/"
+
+msgid "a|b"
+msgstr "a lub b"
+
+#, fuzzy
+msgid "Fuzzy"
+msgstr "Niepewny"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d plik"
+msgstr[1] "%d pliki"
+msgstr[2] "%d plików"
+
+msgctxt "inbox"
+msgid "%s has %d messages"
+msgid_plural "%s has %d messages"
+msgstr[0] "%[2]s ma %[1]d wiadomość"
+msgstr[1] "%[2]s ma %[1]d wiadomości"
+msgstr[2] "%[2]s ma %[1]d wiadomości"
+`
+
+const appleStringsExpected = `/* Greeting on the start page */
+"Hello" = "Cześć";
+
+"Welcome, %1$@!" = "Witaj, %1$@!\n\t\"Miłego dnia\" \\o/";
+
+"menu|Open" = "Otwórz";
+
+"|a|b" = "a lub b";
+`
+
+const appleStringsdictExpected = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>%d file</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@value@</string>
+		<key>value</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>d</string>
+			<key>one</key>
+			<string>%d plik</string>
+			<key>few</key>
+			<string>%d pliki</string>
+			<key>other</key>
+			<string>%d plików</string>
+		</dict>
+	</dict>
+	<key>inbox|%@ has %d messages</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@value@</string>
+		<key>value</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>@</string>
+			<key>one</key>
+			<string>%2$@ ma %1$d wiadomość</string>
+			<key>few</key>
+			<string>%2$@ ma %1$d wiadomości</string>
+			<key>other</key>
+			<string>%2$@ ma %1$d wiadomości</string>
+		</dict>
+	</dict>
+</dict>
+</plist>
+`
+
+func TestMarshalApple(t *testing.T) {
+	po := new(Po)
+	if err := po.ParseE([]byte(applePo)); err != nil {
+		t.Fatal(err)
+	}
+
+	strs, err := MarshalAppleStrings(po)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(strs) != appleStringsExpected {
+		t.Errorf("Expected\n%s\nbut got\n%s", appleStringsExpected, strs)
+	}
+
+	dict, err := MarshalAppleStringsdict(po)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(dict) != appleStringsdictExpected {
+		t.Errorf("Expected\n%s\nbut got\n%s", appleStringsdictExpected, dict)
+	}
+
+	// Back to catalogs
+	s := new(AppleStrings)
+	if err := s.ParseE(strs); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []struct{ id, ctx string }{{"Hello", ""}, {"Open", "menu"}, {"Welcome, %[1]s!", ""}, {"a|b", ""}} {
+		tr, expected := s.GetTranslationC(k.id, k.ctx), po.GetTranslationC(k.id, k.ctx)
+		if k.ctx == "" {
+			expected = po.GetTranslation(k.id)
+		}
+		if tr == nil || tr.Get() != expected.Get() {
+			t.Errorf("Expected '%s' but got %+v", expected.Get(), tr)
+		}
+	}
+
+	sd := new(AppleStringsdict)
+	sd.SetHeaders(po.Headers)
+	if err := sd.ParseE(dict); err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < 30; n++ {
+		if s, expected := sd.GetN("%d file", "", n, n), po.GetN("%d file", "%d files", n, n); s != expected {
+			t.Errorf("Expected '%s' but got '%s'", expected, s)
+		}
+		if s, expected := sd.GetNC("%s has %d messages", "", n, "inbox", n, "Ola"), po.GetNC("%s has %d messages", "", n, "inbox", n, "Ola"); s != expected {
+			t.Errorf("Expected '%s' but got '%s'", expected, s)
+		}
+	}
+}
@@ -0,0 +1,529 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Prefixes of the ARB keys holding the global attributes, like "@@locale", and the headers of the catalog,
+// like "@@x-Plural-Forms", and of the keys holding the attributes of the messages.
+const (
+	arbGlobalPrefix    = "@@"
+	arbHeaderPrefix    = "@@x-"
+	arbAttributePrefix = "@"
+	arbLocaleKey       = "@@locale"
+)
+
+var (
+	// arbKeyRe matches the valid keys of ARB resources, which Flutter generates the methods of.
+	arbKeyRe = regexp.MustCompile(`^[a-z_][A-Za-z0-9_]*$`)
+
+	// arbPlaceholderRe matches the placeholder names the Sprintf function of this package can format.
+	arbPlaceholderRe = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+)
+
+// arbVerbs are the fmt verbs of the placeholder types, "v" being used for the other ones.
+var arbVerbs = map[string]byte{"int": 'd', "double": 'g', "String": 's'}
+
+/*
+Arb parses the content of Application Resource Bundle (ARB) files, as used by Flutter apps,
+and provides all the Translation functions needed.
+And it's safe for concurrent use by multiple goroutines by using the sync package for locking.
+
+Resource keys are the msgids of the entries, and the descriptions of their "@key" attributes
+their extracted comments. Messages made of an ICU plural, like "{count, plural, one{1 file} other{{count} files}}",
+are plural entries, with the text around the plural added to every form.
+
+Placeholders, like "{name}", are written as the named arguments of the Sprintf function of this package,
+like "%(name)s", with the verb of their type: "d" for int, "g" for double, "s" for String and "v" for the other ones.
+The "#" of the plural cases is the argument of the plural variable, an int unless declared otherwise.
+ICU quoting is resolved, and other arguments, like selects, are kept as text.
+
+Plural categories are mapped to the plural forms of the Plural-Forms formula, read from the "@@x-Plural-Forms"
+attribute ArbEncoder writes. Other files only give their "@@locale", which sets the Language header when it's missing,
+and the Plural-Forms msginit writes for the language when none has been set with SetHeaders.
+Exact matches, like "=1", are used for the category of their number when it has no text,
+and other global attributes than "@@locale" are ignored. Categories and exact matches without plural form,
+and plurals without Plural-Forms, are reported with an error wrapping ErrInvalidCatalog, once the file is loaded.
+
+Example:
+
+	import (
+		"fmt"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		// Create arb object
+		a := gotext.NewArbTranslator()
+
+		// Parse .arb file
+		a.ParseFile("/path/to/l10n/app_de.arb")
+
+		// Get Translation
+		fmt.Println(gotext.Sprintf(a.GetN("filesCount", "filesCount", 3), map[string]interface{}{"count": 3}))
+	}
+*/
+type Arb struct {
+	catalog
+}
+
+// arbResource is a resource of an ARB file, with the offset of its value.
+type arbResource struct {
+	key   string
+	value json.RawMessage
+	off   int64
+}
+
+// arbAttributes is the schema of the attributes of the messages of ARB files.
+type arbAttributes struct {
+	Description  string                    `json:"description,omitempty"`
+	Placeholders map[string]arbPlaceholder `json:"placeholders,omitempty"`
+}
+
+// arbPlaceholder is the schema of the attributes of placeholders, of which only the type is used.
+type arbPlaceholder struct {
+	Type string `json:"type,omitempty"`
+}
+
+// NewArbTranslator creates a new Arb object with the Translator interface
+func NewArbTranslator() Translator {
+	return new(Arb)
+}
+
+// SetHeaders sets the headers of the catalog, as found in the header entry of PO files.
+// Their Plural-Forms is used to map the categories of the plurals parsed afterwards,
+// unless the files have their own.
+func (a *Arb) SetHeaders(h textproto.MIMEHeader) {
+	a.Lock()
+	defer a.Unlock()
+
+	a.setHeaders(h)
+}
+
+// ParseFile tries to read the file by its provided path (f) and parse its content as an ARB file.
+// Gzip compressed files, like app_de.arb.gz, are decompressed.
+func (a *Arb) ParseFile(f string) {
+	a.ParseFileE(f)
+}
+
+// ParseFileE works like ParseFile, but returns an error when the file can't be read or parsed.
+// A missing file or a directory path returns an error wrapping ErrNotFound.
+func (a *Arb) ParseFileE(f string) error {
+	return a.parseFile(f, a.ParseE)
+}
+
+// Parse loads the translations specified in the provided ARB content (buf).
+func (a *Arb) Parse(buf []byte) {
+	a.ParseE(buf)
+}
+
+// ParseE works like Parse, but returns a *ParseError describing the first problem found.
+// Nothing is loaded when buf isn't a JSON object. Resources that aren't strings, malformed plurals
+// and unknown plural categories are reported with an error wrapping ErrInvalidCatalog,
+// and the other resources are still loaded.
+func (a *Arb) ParseE(buf []byte) error {
+	resources, err := parseArbResources(buf)
+	if err != nil {
+		return err
+	}
+
+	// Lock while parsing, to map the categories with the current Plural-Forms
+	a.Lock()
+	defer a.Unlock()
+
+	var first error
+	fail := func(r arbResource, err error) {
+		if first == nil {
+			line, col := sourcePosition(buf, r.off)
+			first = &ParseError{Line: line, Column: col, Err: err}
+		}
+	}
+
+	// Headers come first, for the Plural-Forms of the plurals
+	var headers textproto.MIMEHeader
+	locale := ""
+	attributes := make(map[string]arbResource)
+	for _, r := range resources {
+		switch {
+		case r.key == arbLocaleKey || strings.HasPrefix(r.key, arbHeaderPrefix):
+			var s string
+			if err := json.Unmarshal(r.value, &s); err != nil {
+				fail(r, fmt.Errorf("%w: %s isn't a string", ErrInvalidCatalog, r.key))
+				continue
+			}
+			if r.key == arbLocaleKey {
+				locale = s
+				continue
+			}
+			if headers == nil {
+				headers = textproto.MIMEHeader{}
+			}
+			headers.Add(strings.TrimPrefix(r.key, arbHeaderPrefix), s)
+		case strings.HasPrefix(r.key, arbGlobalPrefix):
+		case strings.HasPrefix(r.key, arbAttributePrefix):
+			attributes[strings.TrimPrefix(r.key, arbAttributePrefix)] = r
+		}
+	}
+
+	// The locale is the language of the catalog, giving the Plural-Forms when none is set
+	if headers != nil || locale != "" {
+		if headers == nil {
+			headers = textproto.MIMEHeader{}
+			for k, v := range a.Headers {
+				headers[k] = v
+			}
+		}
+		if headers.Get("Language") == "" && locale != "" {
+			headers.Set("Language", locale)
+		}
+		if headers.Get("Plural-Forms") == "" {
+			if pf := pluralFormsOf(headers.Get("Language")); pf != "" {
+				headers.Set("Plural-Forms", pf)
+			}
+		}
+		a.setHeaders(headers)
+	}
+
+	categories := cldrCategories(a.nplurals, a.pluralForm)
+	for _, r := range resources {
+		if strings.HasPrefix(r.key, arbAttributePrefix) {
+			continue
+		}
+
+		var s string
+		if err := json.Unmarshal(r.value, &s); err != nil {
+			fail(r, fmt.Errorf("%w: resource %q isn't a string", ErrInvalidCatalog, r.key))
+			continue
+		}
+
+		tr := NewTranslation()
+		tr.ID = r.key
+
+		types := make(map[string]string)
+		if attr, ok := attributes[r.key]; ok {
+			var attrs arbAttributes
+			if err := json.Unmarshal(attr.value, &attrs); err != nil {
+				fail(attr, fmt.Errorf("%w: attributes of %q aren't an object", ErrInvalidCatalog, r.key))
+			} else {
+				if attrs.Description != "" {
+					tr.ExtractedComments = strings.Split(attrs.Description, "\n")
+				}
+				for name, p := range attrs.Placeholders {
+					types[name] = p.Type
+				}
+			}
+		}
+
+		items, unmapped, err := arbPlural(s, categories, types)
+		if err != nil {
+			fail(r, fmt.Errorf("%w in resource %q", err, r.key))
+			continue
+		}
+		if items != nil {
+			tr.PluralID = r.key
+			if a.PluralForms == "" {
+				fail(r, fmt.Errorf("%w: plural %q without Plural-Forms for the language %q", ErrInvalidCatalog, r.key, a.Language))
+			}
+			if err := setCLDRForms(tr, items, categories, a.pluralForm, a.Language); err != nil {
+				fail(r, err)
+			}
+			if len(unmapped) > 0 {
+				fail(r, fmt.Errorf("%w: plural %q has no form for the cases %s", ErrInvalidCatalog, r.key, strings.Join(unmapped, ", ")))
+			}
+		} else {
+			tr.Trs[0] = arbText(s, "", types)
+		}
+		a.add(tr)
+	}
+
+	return first
+}
+
+// parseArbResources reads the resources of an ARB file, in order.
+func parseArbResources(data []byte) ([]arbResource, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	// syntaxError reports a JSON decoding error at its position
+	syntaxError := func(err error) *ParseError {
+		off := dec.InputOffset()
+		var se *json.SyntaxError
+		if errors.As(err, &se) {
+			off = se.Offset
+			if off < int64(len(data)) {
+				off--
+			}
+		}
+		line, col := sourcePosition(data, off)
+		return &ParseError{Line: line, Column: col, Err: fmt.Errorf("%w: %v", ErrInvalidCatalog, err)}
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, syntaxError(err)
+	}
+	if tok != json.Delim('{') {
+		return nil, &ParseError{Line: 1, Column: 1, Err: fmt.Errorf("%w: expected a JSON object", ErrInvalidCatalog)}
+	}
+
+	resources := []arbResource{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, syntaxError(err)
+		}
+		key, _ := tok.(string)
+
+		// The value comes after the separators
+		off := dec.InputOffset()
+		for off < int64(len(data)) && bytes.IndexByte([]byte(" \t\r\n:"), data[off]) != -1 {
+			off++
+		}
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, syntaxError(err)
+		}
+		resources = append(resources, arbResource{key: key, value: value, off: off})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, syntaxError(err)
+	}
+
+	return resources, nil
+}
+
+// arbPlural returns the texts of the categories of the first ICU plural of the message s,
+// with the text around it, converted by arbText with the placeholder types, or nil if s has none.
+// Exact matches, like "=1", are returned with the category of their number when it's one of the categories
+// and the plural has no text for it, and the other ones are returned as unmapped.
+func arbPlural(s string, categories []string, types map[string]string) ([]cldrItem, []string, error) {
+	for start := 0; start < len(s); {
+		i := arbIndexBrace(s, start)
+		if i == -1 {
+			return nil, nil, nil
+		}
+
+		end := arbClosingBrace(s, i)
+		if end == -1 {
+			return nil, nil, fmt.Errorf("%w: unbalanced braces", ErrInvalidCatalog)
+		}
+
+		parts := strings.SplitN(s[i+1:end], ",", 3)
+		if len(parts) != 3 || strings.TrimSpace(parts[1]) != "plural" {
+			start = end + 1
+			continue
+		}
+
+		cases, err := arbCases(parts[2])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// The plural variable is an int unless declared otherwise
+		variable := strings.TrimSpace(parts[0])
+		if types[variable] == "" {
+			types[variable] = "int"
+		}
+		text := func(c cldrItem) string {
+			return arbText(s[:i], "", types) + arbText(c.text, variable, types) + arbText(s[end+1:], "", types)
+		}
+
+		var items []cldrItem
+		var unmapped []string
+		for _, c := range cases {
+			if !strings.HasPrefix(c.category, "=") {
+				items = append(items, cldrItem{category: c.category, text: text(c)})
+			}
+		}
+		for _, c := range cases {
+			if !strings.HasPrefix(c.category, "=") {
+				continue
+			}
+			category := map[string]string{"=0": "zero", "=1": "one", "=2": "two"}[c.category]
+			if category == "" || indexOf(categories, category) == -1 || arbHasCategory(cases, category) {
+				unmapped = append(unmapped, c.category)
+				continue
+			}
+			items = append(items, cldrItem{category: category, text: text(c)})
+		}
+
+		return items, unmapped, nil
+	}
+
+	return nil, nil, nil
+}
+
+// arbCases returns the selectors and texts of the cases of an ICU plural.
+func arbCases(s string) ([]cldrItem, error) {
+	var cases []cldrItem
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			break
+		}
+
+		i := strings.IndexByte(s, '{')
+		if i == -1 {
+			return nil, fmt.Errorf("%w: plural case %q without text", ErrInvalidCatalog, strings.TrimSpace(s))
+		}
+		selector := strings.TrimSpace(s[:i])
+		end := arbClosingBrace(s, i)
+		if end == -1 {
+			return nil, fmt.Errorf("%w: unbalanced braces", ErrInvalidCatalog)
+		}
+
+		// The offset of the plural is ignored
+		if fields := strings.Fields(selector); len(fields) > 1 && strings.HasPrefix(fields[0], "offset:") {
+			selector = fields[len(fields)-1]
+		}
+		if selector == "" || strings.ContainsAny(selector, " \t\n}") {
+			return nil, fmt.Errorf("%w: malformed plural case %q", ErrInvalidCatalog, selector)
+		}
+
+		cases = append(cases, cldrItem{category: selector, text: s[i+1 : end]})
+		s = s[end+1:]
+	}
+
+	if !arbHasCategory(cases, "other") {
+		return nil, fmt.Errorf("%w: plural without other case", ErrInvalidCatalog)
+	}
+
+	return cases, nil
+}
+
+// arbText converts the ICU message text s, without plural, to a format of the Sprintf function of this package.
+// Placeholders are written with the verb of their type, and the "#" of the cases of the plural
+// of the variable plural as the placeholder of the variable, when it isn't empty.
+// Quoted text is unquoted, and the "%" of the text escaped.
+func arbText(s, plural string, types map[string]string) string {
+	// placeholder returns the argument of the placeholder name
+	placeholder := func(name string) string {
+		verb, ok := arbVerbs[types[name]]
+		if !ok {
+			verb = 'v'
+		}
+		return "%(" + name + ")" + string(verb)
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			if text, end := arbLiteral(s, i); end != -1 {
+				sb.WriteString(strings.ReplaceAll(text, "%", "%%"))
+				i = end - 1
+				continue
+			}
+		case c == '%':
+			sb.WriteString("%%")
+			continue
+		case c == '#' && plural != "":
+			sb.WriteString(placeholder(plural))
+			continue
+		case c == '{':
+			end := arbClosingBrace(s, i)
+			if end == -1 {
+				break
+			}
+			if name := strings.TrimSpace(s[i+1 : end]); arbPlaceholderRe.MatchString(name) {
+				sb.WriteString(placeholder(name))
+			} else {
+				sb.WriteString(strings.ReplaceAll(s[i:end+1], "%", "%%"))
+			}
+			i = end
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+
+	return sb.String()
+}
+
+// arbLiteral returns the text of the ICU escape starting with the apostrophe at the index i of s, and its end:
+// a doubled apostrophe is an apostrophe, and an apostrophe before a special character quotes the text until the next one.
+// The end is -1 when the apostrophe at i is only an apostrophe.
+func arbLiteral(s string, i int) (string, int) {
+	if i+1 < len(s) && s[i+1] == '\'' {
+		return "'", i + 2
+	}
+	if i+1 == len(s) || strings.IndexByte("{}#|", s[i+1]) == -1 {
+		return "", -1
+	}
+
+	var sb strings.Builder
+	for j := i + 1; j < len(s); j++ {
+		switch {
+		case s[j] != '\'':
+			sb.WriteByte(s[j])
+		case j+1 < len(s) && s[j+1] == '\'':
+			sb.WriteByte('\'')
+			j++
+		default:
+			return sb.String(), j + 1
+		}
+	}
+
+	return sb.String(), len(s)
+}
+
+// arbIndexBrace returns the index of the first opening brace of s from the index start which isn't quoted, or -1.
+func arbIndexBrace(s string, start int) int {
+	for j := start; j < len(s); j++ {
+		switch s[j] {
+		case '\'':
+			if _, end := arbLiteral(s, j); end != -1 {
+				j = end - 1
+			}
+		case '{':
+			return j
+		}
+	}
+
+	return -1
+}
+
+// arbClosingBrace returns the index of the brace closing the one at the index i of s, or -1.
+// Quoted braces are skipped.
+func arbClosingBrace(s string, i int) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\'':
+			if _, end := arbLiteral(s, j); end != -1 {
+				j = end - 1
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+
+	return -1
+}
+
+// arbHasCategory reports whether the cases have one for the category c.
+func arbHasCategory(cases []cldrItem, c string) bool {
+	for _, item := range cases {
+		if item.category == c {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"net/textproto"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const arbStr = `{
+  "@@locale": "de",
+  "@@last_modified": "2023-01-01T00:00:00Z",
+  "helloWorld": "Hallo {name}",
+  "@helloWorld": {
+    "description": "Greeting\non the start page",
+    "placeholders": {"name": {"type": "String"}}
+  },
+  "filesCount": "Es gibt {count, plural, =1{eine Datei} other{{count} Dateien}}.",
+  "@filesCount": {
+    "placeholders": {"count": {"type": "int"}}
+  },
+  "greetings": "{gender, select, male{Herr} other{Hallo}}",
+  "quoted": "Don''t use '{braces}' for 100% of {what}",
+  "items": "{n, plural, one{# Eintrag} other{# Einträge '#'}}",
+  "empty": ""
+}`
+
+func TestArb(t *testing.T) {
+	a := new(Arb)
+	if err := a.ParseE([]byte(arbStr)); err != nil {
+		t.Fatal(err)
+	}
+
+	get, getN := a.Get, a.GetN
+	for _, c := range []struct{ got, expected string }{
+		{get("helloWorld"), "Hallo %(name)s"},
+		{getN("filesCount", "filesCount", 1), "Es gibt eine Datei."},
+		{getN("filesCount", "filesCount", 0), "Es gibt %(count)d Dateien."},
+		{getN("filesCount", "filesCount", 5), "Es gibt %(count)d Dateien."},
+		{get("greetings"), "{gender, select, male{Herr} other{Hallo}}"},
+		{get("quoted"), "Don't use {braces} for 100%% of %(what)v"},
+		{getN("items", "items", 1), "%(n)d Eintrag"},
+		{getN("items", "items", 2), "%(n)d Einträge #"},
+		{Sprintf(get("helloWorld"), map[string]interface{}{"name": "Anna"}), "Hallo Anna"},
+		{get("missing"), "missing"},
+	} {
+		if c.got != c.expected {
+			t.Errorf("Expected '%s' but got '%s'", c.expected, c.got)
+		}
+	}
+
+	if a.Language != "de" {
+		t.Errorf("Expected language 'de' but got '%s'", a.Language)
+	}
+	if tr := a.GetTranslation("helloWorld"); len(tr.ExtractedComments) != 2 || tr.ExtractedComments[1] != "on the start page" {
+		t.Errorf("Unexpected comments %q", tr.ExtractedComments)
+	}
+	if tr := a.GetTranslation("filesCount"); tr.PluralID != "filesCount" || len(tr.Trs) != 2 {
+		t.Errorf("Expected plural entry but got %+v", tr)
+	}
+	if tr := a.GetTranslation("@@last_modified"); tr != nil {
+		t.Errorf("Expected no entry for global attributes but got %+v", tr)
+	}
+}
+
+func TestArbPluralForms(t *testing.T) {
+	arb := `{
+  "@@locale": "pl",
+  "filesCount": "{count, plural, one{{count} plik} few{{count} pliki} many{{count} plików} other{{count} pliku}}"
+}`
+
+	// The Plural-Forms comes from the locale, or from the headers set before, completed with the locale
+	for name, headers := range map[string]textproto.MIMEHeader{
+		"locale":        nil,
+		"headers":       {"Language": {"pl"}, "Plural-Forms": {polishPluralForms}},
+		"plural-forms":  {"Plural-Forms": {polishPluralForms}},
+		"other headers": {"Project-Id-Version": {"app"}},
+	} {
+		a := new(Arb)
+		if headers != nil {
+			a.SetHeaders(headers)
+		}
+		if err := a.ParseE([]byte(arb)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		getN := a.GetN
+		for n, expected := range map[int]string{0: "%(count)d plików", 1: "%(count)d plik", 3: "%(count)d pliki", 5: "%(count)d plików", 22: "%(count)d pliki"} {
+			if s := getN("filesCount", "filesCount", n); s != expected {
+				t.Errorf("%s: expected '%s' for %d but got '%s'", name, expected, n, s)
+			}
+		}
+
+		if a.Language != "pl" || a.PluralForms != pluralFormsOf("pl") {
+			t.Errorf("%s: unexpected headers %v", name, a.Headers)
+		}
+	}
+
+	// Categories and exact matches without form are reported, and the other ones kept
+	a := new(Arb)
+	err := a.ParseE([]byte(`{
+  "@@locale": "de",
+  "filesCount": "{count, plural, =0{Keine Dateien} =1{Eine Datei} few{{count} Dateien} other{{count} Dateien}}"
+}`))
+	if !errors.Is(err, ErrInvalidCatalog) || !strings.Contains(err.Error(), `no form left for the category "few"`) {
+		t.Errorf("Expected ErrInvalidCatalog for few but got '%v'", err)
+	}
+	if s := a.GetN("filesCount", "filesCount", 1); s != "Eine Datei" {
+		t.Errorf("Expected 'Eine Datei' but got '%s'", s)
+	}
+
+	for msg, expected := range map[string]string{
+		`{count, plural, =0{Keine Dateien} one{Eine Datei} other{{count} Dateien}}`: "no form for the cases =0",
+		`{count, plural, =1{Eine Datei} one{Eine Datei} other{{count} Dateien}}`:    "no form for the cases =1",
+	} {
+		err := new(Arb).ParseE([]byte(`{"@@locale": "de", "files": "` + msg + `"}`))
+		if !errors.Is(err, ErrInvalidCatalog) || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected ErrInvalidCatalog with %q for %q but got '%v'", expected, msg, err)
+		}
+	}
+
+	// Languages without known Plural-Forms are reported
+	if err := new(Arb).ParseE([]byte(`{"@@locale": "xx", "files": "{count, plural, one{Datei} other{Dateien}}"}`)); !errors.Is(err, ErrInvalidCatalog) {
+		t.Errorf("Expected ErrInvalidCatalog for an unknown language but got '%v'", err)
+	}
+}
+
+func TestArbErrors(t *testing.T) {
+	arb := `{
+  "ok": "Gut",
+  "number": 42,
+  "unbalanced": "{count, plural, one{Datei} other{Dateien}",
+  "noOther": "{count, plural, one{Datei}}",
+  "unknown": "{count, plural, one{Datei} lots{Dateien} other{Dateien}}"
+}`
+
+	a := new(Arb)
+	err := a.ParseE([]byte(arb))
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrInvalidCatalog) || pe.Line != 3 || pe.Column != 13 {
+		t.Fatalf("Expected ErrInvalidCatalog at 3:13 but got '%v'", err)
+	}
+
+	get := a.Get
+	for id, expected := range map[string]string{
+		"ok":         "Gut",
+		"number":     "number",
+		"unbalanced": "unbalanced",
+		"noOther":    "noOther",
+		"unknown":    "Datei",
+	} {
+		if s := get(id); s != expected {
+			t.Errorf("Expected '%s' but got '%s'", expected, s)
+		}
+	}
+
+	for _, msg := range []string{
+		"{count, plural, one{Datei} other{Dateien}",
+		"{count, plural, one{Datei}}",
+		"{count, plural, one{Datei} lots{Dateien} other{Dateien}}",
+	} {
+		if err := new(Arb).ParseE([]byte(`{"@@locale": "de", "files": "` + msg + `"}`)); !errors.Is(err, ErrInvalidCatalog) {
+			t.Errorf("Expected ErrInvalidCatalog for %q but got '%v'", msg, err)
+		}
+	}
+
+	// Nothing is loaded from invalid JSON
+	for _, arb := range []string{`{"ok": "Gut",`, `["ok"]`, ``} {
+		a := new(Arb)
+		if err := a.ParseE([]byte(arb)); !errors.Is(err, ErrInvalidCatalog) {
+			t.Errorf("Expected ErrInvalidCatalog for %q but got '%v'", arb, err)
+		}
+		if get := a.Get; get("ok") != "ok" {
+			t.Errorf("Expected nothing loaded from %q", arb)
+		}
+	}
+}
+
+func TestArbLocale(t *testing.T) {
+	fsys := fstest.MapFS{
+		"de/LC_MESSAGES/app.arb": {Data: []byte(arbStr)},
+	}
+
+	l := NewLocaleFS(fsys, "de_DE")
+	if err := l.AddDomainE("app"); err != nil {
+		t.Fatal(err)
+	}
+	if s := l.GetND("app", "filesCount", "filesCount", 1); s != "Es gibt eine Datei." {
+		t.Errorf("Expected 'Es gibt eine Datei.' but got '%s'", s)
+	}
+}
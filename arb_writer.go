@@ -0,0 +1,281 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+ArbEncoder writes catalogs as Application Resource Bundle (ARB) files, like app_de.arb, for Flutter apps.
+Any Translator can be encoded, as its entries are read through MarshalBinary.
+
+The msgids of the entries without context are the resource keys by default, with their extracted comments
+as description. Set NameFunc to name the other entries. Entries without valid key, like "welcomeTitle",
+are reported with an error wrapping ErrUnnamed, once the other ones are written.
+Plural entries are written as ICU plurals of the PluralVariable placeholder,
+with the category of every form found with the Plural-Forms formula of the catalog.
+The headers are written as "@@x-" attributes, like "@@x-Plural-Forms", and the Language one as "@@locale",
+so Arb reads the plurals back with the same formula.
+
+Only the translated entries are written by default, so Flutter reports the other messages as untranslated
+and uses the ones of the template ARB file. Set Untranslated to write them too, with their msgid and msgid_plural
+as text, like in the template file translators start from.
+
+The named arguments of the Sprintf function of this package, like "%(name)s", are written as placeholders,
+like "{name}", and declared with the type of their verb. Other arguments are written as the "arg1", "arg2", ...
+placeholders of their position. "{", "}" and "'" are quoted as ICU requires, so Flutter projects
+must enable use-escaping to read them.
+
+Example:
+
+	import (
+		"os"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		po := new(gotext.Po)
+		po.ParseFile("/path/to/po/file/translations.po")
+
+		f, _ := os.Create("/path/to/l10n/app_de.arb")
+		defer f.Close()
+
+		gotext.NewArbEncoder(f).Encode(po)
+	}
+*/
+type ArbEncoder struct {
+	// PluralVariable is the name of the placeholder of the plurals, "count" by default.
+	PluralVariable string
+
+	// NameFunc returns the resource key of the entry with the msgctxt ctx and the msgid id,
+	// or "" to leave it out. The msgid of the entries without context is used when it's nil.
+	NameFunc func(ctx, id string) string
+
+	// Untranslated writes the untranslated and fuzzy entries too, with their source text.
+	Untranslated bool
+
+	// Indent of the nested values, two spaces by default.
+	Indent string
+
+	w io.Writer
+}
+
+// NewArbEncoder returns an ArbEncoder writing to w.
+func NewArbEncoder(w io.Writer) *ArbEncoder {
+	return &ArbEncoder{
+		PluralVariable: "count",
+		Indent:         "  ",
+		w:              w,
+	}
+}
+
+// MarshalArb returns the catalog of t as the content of an ARB file.
+func MarshalArb(t Translator) ([]byte, error) {
+	var buff bytes.Buffer
+	err := NewArbEncoder(&buff).Encode(t)
+
+	return buff.Bytes(), err
+}
+
+// Encode writes the catalog of t as an ARB file, with the resources sorted by key.
+func (enc *ArbEncoder) Encode(t Translator) error {
+	te, err := encodeTranslator(t)
+	if err != nil {
+		return err
+	}
+
+	// The catalog evaluates the Plural-Forms formula
+	c := new(catalog)
+	if te.Headers != nil {
+		c.setHeaders(te.Headers)
+	}
+	categories := cldrCategories(c.nplurals, c.pluralForm)
+
+	variable := enc.PluralVariable
+	if variable == "" {
+		variable = "count"
+	}
+
+	w := bufio.NewWriter(enc.w)
+	first := true
+	write := func(key string, value interface{}) {
+		if !first {
+			w.WriteString(",\n")
+		}
+		first = false
+		w.WriteString(enc.Indent + arbJSON(key, "") + ": " + arbJSON(value, enc.Indent))
+	}
+
+	w.WriteString("{\n")
+	if te.Language != "" {
+		write(arbLocaleKey, te.Language)
+	}
+	for _, line := range strings.Split(headerString(te.Headers, headerEntry(te)), "\n") {
+		kv := strings.SplitN(line, ": ", 2)
+		if len(kv) == 2 && textproto.CanonicalMIMEHeaderKey(kv[0]) != "Language" {
+			write(arbHeaderPrefix+kv[0], kv[1])
+		}
+	}
+
+	resources, unnamed := namedEntries(te, enc.NameFunc, arbKeyRe, enc.Untranslated)
+	for _, key := range sortedKeys(resources) {
+		tr := resources[key]
+		if !tr.IsTranslated() || tr.IsFuzzy() {
+			tr = sourceEntry(tr, len(categories))
+		}
+
+		var attrs arbAttributes
+		if len(tr.ExtractedComments) > 0 {
+			attrs.Description = strings.Join(tr.ExtractedComments, "\n")
+		}
+
+		types := make(map[string]string)
+		if tr.PluralID == "" {
+			write(key, goToArb(tr.Trs[0], false, types))
+		} else {
+			var sb strings.Builder
+			sb.WriteString("{" + variable + ", plural,")
+			for i, category := range categories {
+				if s := tr.Trs[i]; category != "" && s != "" {
+					sb.WriteString(" " + category + "{" + goToArb(s, true, types) + "}")
+				}
+			}
+			sb.WriteString("}")
+			write(key, sb.String())
+			if _, ok := types[variable]; !ok {
+				types[variable] = "int"
+			}
+		}
+		if len(types) > 0 {
+			attrs.Placeholders = make(map[string]arbPlaceholder)
+			for name, t := range types {
+				attrs.Placeholders[name] = arbPlaceholder{Type: t}
+			}
+		}
+
+		if attrs.Description != "" || attrs.Placeholders != nil {
+			write(arbAttributePrefix+key, attrs)
+		}
+	}
+	if !first {
+		w.WriteString("\n")
+	}
+	w.WriteString("}\n")
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return unnamedError(unnamed)
+}
+
+// goToArb converts the format s, as read by Sprintf or fmt, to the text of an ICU message,
+// adding the types of its placeholders to types. Named arguments like "%(name)s" are the placeholders of their name,
+// and other arguments the "arg1", "arg2", ... placeholders of their position.
+// Special characters are quoted, including "#" in the cases of plurals.
+func goToArb(s string, plural bool, types map[string]string) string {
+	var sb strings.Builder
+	quoted := false
+	arg := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		special := c == '{' || c == '}' || (c == '#' && plural)
+		switch {
+		case special && !quoted:
+			sb.WriteByte('\'')
+			quoted = true
+		case !special && c != '\'' && quoted:
+			sb.WriteByte('\'')
+			quoted = false
+		}
+
+		switch {
+		case c == '\'':
+			sb.WriteString("''")
+		case c == '%' && i+1 < len(s) && s[i+1] == '%':
+			sb.WriteByte('%')
+			i++
+		case c == '%':
+			name, verb, end := goArgument(s, i, &arg)
+			if end == -1 {
+				sb.WriteByte(c)
+				continue
+			}
+			if _, ok := types[name]; !ok {
+				types[name] = arbTypes[verb]
+			}
+			sb.WriteString("{" + name + "}")
+			i = end - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	if quoted {
+		sb.WriteByte('\'')
+	}
+
+	return sb.String()
+}
+
+// arbTypes are the placeholder types of the fmt verbs, other verbs having no type.
+var arbTypes = map[byte]string{'d': "int", 'e': "double", 'f': "double", 'g': "double", 's': "String"}
+
+// goArgument returns the placeholder name and the verb of the argument starting with the "%" at the index i of s,
+// and its end, or -1 when there's no verb. Arguments without name are named after their position,
+// which follows the position of the previous one, arg.
+func goArgument(s string, i int, arg *int) (string, byte, int) {
+	j := i + 1
+	name := ""
+	if j < len(s) && s[j] == '(' {
+		k := strings.IndexByte(s[j:], ')')
+		if k == -1 || !arbPlaceholderRe.MatchString(s[j+1:j+k]) {
+			return "", 0, -1
+		}
+		name = s[j+1 : j+k]
+		j += k + 1
+	}
+
+	start := j
+	for j < len(s) && strings.IndexByte("0123456789+-# .*[]", s[j]) != -1 {
+		j++
+	}
+	if j == len(s) || !unicode.IsLetter(rune(s[j])) {
+		return "", 0, -1
+	}
+
+	if name == "" {
+		*arg++
+		if k := strings.LastIndexByte(s[start:j], '['); k != -1 {
+			index := s[start+k+1 : j]
+			if e := strings.IndexByte(index, ']'); e != -1 {
+				if n, err := strconv.Atoi(index[:e]); err == nil {
+					*arg = n
+				}
+			}
+		}
+		name = "arg" + strconv.Itoa(*arg)
+	}
+
+	return name, s[j], j + 1
+}
+
+// arbJSON returns v as JSON, without HTML escaping, with the nested values indented after prefix.
+func arbJSON(v interface{}, prefix string) string {
+	var buff bytes.Buffer
+	je := json.NewEncoder(&buff)
+	je.SetEscapeHTML(false)
+	je.SetIndent(prefix, prefix)
+	je.Encode(v)
+
+	return strings.TrimSuffix(buff.String(), "\n")
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const arbPo = `msgid ""
+msgstr ""
+"Language: pl\n"
+"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+#. Greeting <b>
+msgid "hello"
+msgstr "Cześć %(name)s"
+
+msgid "filesCount"
+msgid_plural "filesCount"
+msgstr[0] "%(count)d plik"
+msgstr[1] "%(count)d pliki"
+msgstr[2] "%(count)d plików"
+
+msgid "quoted"
+msgstr "Nie '{używaj}' # 100%% %s i %[1]s"
+
+msgid "Not a key"
+msgstr "Pominięty"
+
+msgctxt "menu"
+msgid "open"
+msgstr "Otwórz"
+
+#, fuzzy
+msgid "save"
+msgstr "Zapisz"
+`
+
+const arbExpected = `{
+  "@@locale": "pl",
+  "@@x-Plural-Forms": "nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+  "filesCount": "{count, plural, one{{count} plik} few{{count} pliki} other{{count} plików}}",
+  "@filesCount": {
+    "placeholders": {
+      "count": {
+        "type": "int"
+      }
+    }
+  },
+  "hello": "Cześć {name}",
+  "@hello": {
+    "description": "Greeting <b>",
+    "placeholders": {
+      "name": {
+        "type": "String"
+      }
+    }
+  },
+  "quoted": "Nie '''{'używaj'}''' # 100% {arg1} i {arg1}",
+  "@quoted": {
+    "placeholders": {
+      "arg1": {
+        "type": "String"
+      }
+    }
+  }
+}
+`
+
+func TestMarshalArb(t *testing.T) {
+	po := new(Po)
+	if err := po.ParseE([]byte(arbPo)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries without valid key are reported
+	out, err := MarshalArb(po)
+	if !errors.Is(err, ErrUnnamed) || !strings.HasSuffix(err.Error(), `: msgid "Not a key", msgctxt "menu" msgid "open"`) {
+		t.Errorf("Expected ErrUnnamed for the skipped entries but got '%v'", err)
+	}
+	if string(out) != arbExpected {
+		t.Errorf("Expected\n%s\nbut got\n%s", arbExpected, out)
+	}
+
+	if out, _ := MarshalArb(new(Po)); string(out) != "{\n}\n" {
+		t.Errorf("Expected an empty object but got %q", out)
+	}
+}
+
+func TestArbRoundTrip(t *testing.T) {
+	po := new(Po)
+	if err := po.ParseE([]byte(arbPo)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buff bytes.Buffer
+	enc := NewArbEncoder(&buff)
+	enc.PluralVariable = "n"
+	enc.NameFunc = func(ctx, id string) string {
+		return ctx + id
+	}
+	if err := enc.Encode(po); !errors.Is(err, ErrUnnamed) {
+		t.Fatalf("Expected ErrUnnamed but got '%v'", err)
+	}
+
+	// The Plural-Forms is read from the file
+	a := new(Arb)
+	if err := a.ParseE(buff.Bytes()); err != nil {
+		t.Fatalf("%v\n%s", err, buff.String())
+	}
+	if a.PluralForms != po.PluralForms {
+		t.Errorf("Expected Plural-Forms '%s' but got '%s'", po.PluralForms, a.PluralForms)
+	}
+
+	getN, poGetN := a.GetN, po.GetN
+	for _, n := range []int{1, 3, 5, 22, 112} {
+		if s, expected := getN("filesCount", "filesCount", n), poGetN("filesCount", "filesCount", n); s != expected {
+			t.Errorf("Expected '%s' for %d but got '%s'", expected, n, s)
+		}
+	}
+	if tr := a.GetTranslation("hello"); tr == nil || tr.Get() != "Cześć %(name)s" || len(tr.ExtractedComments) != 1 {
+		t.Errorf("Unexpected entry %+v", tr)
+	}
+
+	// Positional arguments are named, and the quoted text is read back
+	for id, expected := range map[string]string{
+		"quoted":   "Nie '{używaj}' # 100%% %(arg1)s i %(arg1)s",
+		"menuopen": "Otwórz",
+	} {
+		if s := a.Get(id); s != expected {
+			t.Errorf("Expected '%s' but got '%s'", expected, s)
+		}
+	}
+}
+
+func TestArbUntranslated(t *testing.T) {
+	po := new(Po)
+	if err := po.ParseE([]byte(`msgid ""
+msgstr ""
+"Language: de\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "Hello %(name)s"
+msgstr ""
+
+#, fuzzy
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d Datei"
+msgstr[1] "%d Dateien"
+`)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buff bytes.Buffer
+	enc := NewArbEncoder(&buff)
+	enc.Untranslated = true
+	enc.NameFunc = func(ctx, id string) string {
+		return map[string]string{"Hello %(name)s": "hello", "%d file": "filesCount"}[id]
+	}
+	if err := enc.Encode(po); err != nil {
+		t.Fatal(err)
+	}
+
+	// The entries are written with their source text
+	s := buff.String()
+	for _, expected := range []string{
+		`"hello": "Hello {name}"`,
+		`"filesCount": "{count, plural, one{{arg1} file} other{{arg1} files}}"`,
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("Expected '%s' in\n%s", expected, s)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DeineAgenturUG/gotext/plurals"
+)
+
+// catalog is the storage and lookup base of the Translator objects of formats other than PO and MO, like Json.
+// Their parsers fill it with setHeaders and add, and it provides the Translator methods.
+// And it's safe for concurrent use by multiple goroutines by using the sync package for locking.
+type catalog struct {
+	// Headers storage
+	Headers textproto.MIMEHeader
+
+	// Language header
+	Language string
+
+	// Plural-Forms header
+	PluralForms string
+
+	// Parsed Plural-Forms header values
+	nplurals    int
+	plural      string
+	pluralforms plurals.Expression
+
+	// Storage
+	translations map[string]*Translation
+	contexts     map[string]map[string]*Translation
+
+	// Use entries flagged as fuzzy on lookups
+	allowFuzzy bool
+
+	// Sync Mutex
+	sync.RWMutex
+}
+
+// parseFile reads the file f, decompressed when needed, and parses its content with parse.
+// A missing file or a directory path returns an error wrapping ErrNotFound.
+func (c *catalog) parseFile(f string, parse func([]byte) error) error {
+	// Check if file exists
+	info, err := os.Stat(f)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrNotFound, f)
+		}
+		return err
+	}
+
+	// Check that isn't a directory
+	if info.IsDir() {
+		return fmt.Errorf("%w: %s is a directory", ErrNotFound, f)
+	}
+
+	// Read file content, decompressed when needed
+	data, err := readFile(nil, f)
+	if err != nil {
+		return err
+	}
+
+	return withFile(parse(data), f)
+}
+
+// add stores tr, using its Context field.
+// It must be called with the write lock held.
+func (c *catalog) add(tr *Translation) {
+	// Init storage
+	if c.translations == nil {
+		c.translations = make(map[string]*Translation)
+		c.contexts = make(map[string]map[string]*Translation)
+	}
+
+	if tr.Context == "" {
+		c.translations[tr.ID] = tr
+		return
+	}
+
+	if _, ok := c.contexts[tr.Context]; !ok {
+		c.contexts[tr.Context] = make(map[string]*Translation)
+	}
+	c.contexts[tr.Context][tr.ID] = tr
+}
+
+// setHeaders replaces the headers, parses their Plural-Forms and stores them as the header entry,
+// so encoders like MoEncoder get them as from a PO file.
+// It must be called with the write lock held.
+func (c *catalog) setHeaders(h textproto.MIMEHeader) {
+	c.Headers = h
+	c.Language = h.Get("Language")
+	c.PluralForms = h.Get("Plural-Forms")
+	c.nplurals, c.plural, c.pluralforms = 0, "", nil
+
+	header := NewTranslation()
+	header.Trs[0] = headerString(h, "")
+	c.add(header)
+
+	// Parse Plural-Forms formula
+	for _, f := range strings.Split(c.PluralForms, ";") {
+		vs := strings.SplitN(f, "=", 2)
+		if len(vs) != 2 {
+			continue
+		}
+
+		switch strings.TrimSpace(vs[0]) {
+		case "nplurals":
+			c.nplurals, _ = strconv.Atoi(strings.TrimSpace(vs[1]))
+
+		case "plural":
+			c.plural = vs[1]
+
+			if expr, err := plurals.Compile(c.plural); err == nil {
+				c.pluralforms = expr
+			}
+		}
+	}
+}
+
+// pluralForm calculates the plural form index corresponding to n.
+// It must be called with the lock held.
+func (c *catalog) pluralForm(n int) int {
+	// Failure fallback
+	if c.pluralforms == nil {
+		/* Use Western plural rule.  */
+		if n == 1 {
+			return 0
+		}
+		return 1
+	}
+	return c.pluralforms.Eval(uint32(n))
+}
+
+// SetAllowFuzzy sets whether entries flagged as fuzzy are used on lookups.
+// Fuzzy entries are skipped by default, falling back to the msgid as with Po.
+func (c *catalog) SetAllowFuzzy(allow bool) {
+	c.Lock()
+	c.allowFuzzy = allow
+	c.Unlock()
+}
+
+// GetAllowFuzzy returns whether entries flagged as fuzzy are used on lookups.
+func (c *catalog) GetAllowFuzzy() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.allowFuzzy
+}
+
+// entry returns the Translation stored for str in ctx, or nil if there isn't one.
+// It must be called with the lock held.
+func (c *catalog) entry(str, ctx string) *Translation {
+	if ctx == "" {
+		return c.translations[str]
+	}
+
+	return c.contexts[ctx][str]
+}
+
+// lookup returns the Translation to use for str in ctx, or nil if there isn't one.
+// Fuzzy entries are skipped unless allowed, except for the header entry.
+// It must be called with the lock held.
+func (c *catalog) lookup(str, ctx string) *Translation {
+	tr := c.entry(str, ctx)
+	if tr == nil || c.allowFuzzy || tr.ID == "" || !tr.IsFuzzy() {
+		return tr
+	}
+
+	return nil
+}
+
+// Get retrieves the corresponding Translation for the given string.
+// Supports optional parameters (vars... interface{}) to be inserted on the formatted string using the fmt.Printf syntax.
+func (c *catalog) Get(str string, vars ...interface{}) string {
+	return c.GetC(str, "", vars...)
+}
+
+// GetN retrieves the (N)th plural form of Translation for the given string.
+// Supports optional parameters (vars... interface{}) to be inserted on the formatted string using the fmt.Printf syntax.
+func (c *catalog) GetN(str, plural string, n int, vars ...interface{}) string {
+	return c.GetNC(str, plural, n, "", vars...)
+}
+
+// GetC retrieves the corresponding Translation for a given string in the given context.
+// Supports optional parameters (vars... interface{}) to be inserted on the formatted string using the fmt.Printf syntax.
+func (c *catalog) GetC(str, ctx string, vars ...interface{}) string {
+	// Sync read
+	c.RLock()
+	defer c.RUnlock()
+
+	if tr := c.lookup(str, ctx); tr != nil {
+		return Printf(tr.Get(), vars...)
+	}
+
+	// Return the string we received by default
+	return Printf(str, vars...)
+}
+
+// GetNC retrieves the (N)th plural form of Translation for the given string in the given context.
+// Supports optional parameters (vars... interface{}) to be inserted on the formatted string using the fmt.Printf syntax.
+func (c *catalog) GetNC(str, plural string, n int, ctx string, vars ...interface{}) string {
+	// Sync read
+	c.RLock()
+	defer c.RUnlock()
+
+	if tr := c.lookup(str, ctx); tr != nil {
+		return Printf(tr.GetN(c.pluralForm(n)), vars...)
+	}
+
+	// Parse plural forms to distinguish between plural and singular
+	if c.pluralForm(n) == 0 {
+		return Printf(str, vars...)
+	}
+	return Printf(plural, vars...)
+}
+
+// GetTranslation returns the Translation object for the given string, or nil if there isn't one.
+// The returned object is shared with the catalog and must not be modified.
+func (c *catalog) GetTranslation(str string) *Translation {
+	return c.GetTranslationC(str, "")
+}
+
+// GetTranslationC returns the Translation object for the given string in the given context, or nil if there isn't one.
+// The returned object is shared with the catalog and must not be modified.
+func (c *catalog) GetTranslationC(str, ctx string) *Translation {
+	// Sync read
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.entry(str, ctx)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler interface
+func (c *catalog) MarshalBinary() ([]byte, error) {
+	// Sync read
+	c.RLock()
+	defer c.RUnlock()
+
+	obj := new(TranslatorEncoding)
+	obj.Headers = c.Headers
+	obj.Language = c.Language
+	obj.PluralForms = c.PluralForms
+	obj.Nplurals = c.nplurals
+	obj.Plural = c.plural
+	obj.Translations = c.translations
+	obj.Contexts = c.contexts
+
+	var buff bytes.Buffer
+	encoder := gob.NewEncoder(&buff)
+	err := encoder.Encode(obj)
+
+	return buff.Bytes(), err
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler interface
+func (c *catalog) UnmarshalBinary(data []byte) error {
+	buff := bytes.NewBuffer(data)
+	obj := new(TranslatorEncoding)
+
+	decoder := gob.NewDecoder(buff)
+	err := decoder.Decode(obj)
+	if err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.Headers = obj.Headers
+	c.Language = obj.Language
+	c.PluralForms = obj.PluralForms
+	c.nplurals = obj.Nplurals
+	c.plural = obj.Plural
+	c.translations = obj.Translations
+	c.contexts = obj.Contexts
+
+	c.pluralforms = nil
+	if expr, err := plurals.Compile(c.plural); err == nil {
+		c.pluralforms = expr
+	}
+
+	return nil
+}
+
+// sourcePosition returns the 1-based line and column of the byte offset off of data.
+func sourcePosition(data []byte, off int64) (int, int) {
+	if off > int64(len(data)) {
+		off = int64(len(data))
+	}
+	if off < 0 {
+		off = 0
+	}
+
+	before := data[:off]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := len(before) - bytes.LastIndexByte(before, '\n')
+
+	return line, col
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCharmapDecode(t *testing.T) {
+	for cs, tests := range map[string]map[string]string{
+		"ISO-8859-1":   {"Gr\xfc\xdfe": "Grüße", "plain": "plain"},
+		"latin9":       {"\xa4 5": "€ 5"},
+		"Windows-1252": {"\x80 \x93quoted\x94": "€ “quoted”"},
+		"KOI8-R":       {"\xf0\xd2\xc9\xd7\xc5\xd4": "Привет"},
+		"cp1251":       {"\xcf\xf0\xe8\xe2\xe5\xf2": "Привет"},
+		"UTF-8":        {"Grüße": "Grüße"},
+	} {
+		cm, err := getCharmap(cs)
+		if err != nil {
+			t.Fatalf("Unexpected error for '%s': %v", cs, err)
+		}
+		for in, out := range tests {
+			if got := cm.decode(in); got != out {
+				t.Errorf("Expected '%s' but got '%s' for charset '%s'", out, got, cs)
+			}
+		}
+	}
+
+	if _, err := getCharmap("EBCDIC"); !errors.Is(err, ErrUnsupportedCharset) {
+		t.Errorf("Expected ErrUnsupportedCharset but got '%v'", err)
+	}
+}
+
+func TestHeaderCharset(t *testing.T) {
+	header := "Language: de\nContent-Type: text/plain; charset=ISO-8859-1\n"
+	if cs := headerCharset(header); cs != "ISO-8859-1" {
+		t.Errorf("Expected 'ISO-8859-1' but got '%s'", cs)
+	}
+
+	header = setHeaderCharset(header, "UTF-8")
+	if !strings.Contains(header, "Content-Type: text/plain; charset=UTF-8\n") {
+		t.Errorf("Expected UTF-8 charset but got '%s'", header)
+	}
+}
+
+func TestPoCharset(t *testing.T) {
+	// Set PO content
+	str := "msgid \"\"\n" +
+		"msgstr \"\"\n" +
+		"\"Language: de\\n\"\n" +
+		"\"Last-Translator: J\xfcrgen\\n\"\n" +
+		"\"Content-Type: text/plain; charset=ISO-8859-1\\n\"\n" +
+		"\n" +
+		"msgctxt \"Gr\xfc\xdfe\"\n" +
+		"msgid \"Greeting\"\n" +
+		"msgstr \"Sch\xf6nen Tag\"\n" +
+		"\n" +
+		"msgid \"Gr\xfc\xdfe\"\n" +
+		"msgstr \"Gr\xfc\xdfe!\"\n"
+
+	po := new(Po)
+	if err := po.ParseE([]byte(str)); err != nil {
+		t.Fatal(err)
+	}
+	if tr := po.GetC("Greeting", "Grüße"); tr != "Schönen Tag" {
+		t.Errorf("Expected 'Schönen Tag' but got '%s'", tr)
+	}
+	if tr := po.Get("Grüße"); tr != "Grüße!" {
+		t.Errorf("Expected 'Grüße!' but got '%s'", tr)
+	}
+	if h := po.Headers.Get("Last-Translator"); h != "Jürgen" {
+		t.Errorf("Expected 'Jürgen' but got '%s'", h)
+	}
+	if h := po.Headers.Get("Content-Type"); h != "text/plain; charset=UTF-8" {
+		t.Errorf("Expected UTF-8 Content-Type but got '%s'", h)
+	}
+
+	// Unsupported charset
+	po = new(Po)
+	err := po.ParseE([]byte(strings.Replace(str, "ISO-8859-1", "EBCDIC", 1)))
+	if !errors.Is(err, ErrUnsupportedCharset) {
+		t.Errorf("Expected ErrUnsupportedCharset but got '%v'", err)
+	}
+	if tr := po.Get("Gr\xfc\xdfe"); tr != "Gr\xfc\xdfe!" {
+		t.Errorf("Expected unconverted translation but got '%s'", tr)
+	}
+
+	// Forced charset
+	po = new(Po)
+	po.SetCharset("KOI8-R")
+	if cs := po.GetCharset(); cs != "KOI8-R" {
+		t.Errorf("Expected 'KOI8-R' but got '%s'", cs)
+	}
+	err = po.ParseE([]byte("msgid \"Hello\"\nmsgstr \"\xf0\xd2\xc9\xd7\xc5\xd4\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr := po.Get("Hello"); tr != "Привет" {
+		t.Errorf("Expected 'Привет' but got '%s'", tr)
+	}
+}
+
+func TestMoCharset(t *testing.T) {
+	data := buildMo(map[string]string{
+		"":                "Language: de\nContent-Type: text/plain; charset=windows-1252\n",
+		"Price":           "Preis: 5 \x80",
+		"Ctx\x04Greeting": "Gr\xfc\xdfe",
+		"File\x00Files":   "Datei\x00Dateien \x84gro\xdf\x93",
+		"Quote \x93x\x94": "Zitat",
+	})
+
+	mo := new(Mo)
+	if err := mo.ParseE(data); err != nil {
+		t.Fatal(err)
+	}
+	if tr := mo.Get("Price"); tr != "Preis: 5 €" {
+		t.Errorf("Expected 'Preis: 5 €' but got '%s'", tr)
+	}
+	if tr := mo.GetC("Greeting", "Ctx"); tr != "Grüße" {
+		t.Errorf("Expected 'Grüße' but got '%s'", tr)
+	}
+	if tr := mo.GetN("File", "Files", 2); tr != "Dateien „groß“" {
+		t.Errorf("Expected 'Dateien „groß“' but got '%s'", tr)
+	}
+	if tr := mo.Get("Quote “x”"); tr != "Zitat" {
+		t.Errorf("Expected 'Zitat' but got '%s'", tr)
+	}
+
+	// Forced charset
+	mo = new(Mo)
+	mo.SetCharset("ISO-8859-1")
+	if err := mo.ParseE(data); err != nil {
+		t.Fatal(err)
+	}
+	if tr := mo.GetC("Greeting", "Ctx"); tr != "Grüße" {
+		t.Errorf("Expected 'Grüße' but got '%s'", tr)
+	}
+
+	// Unsupported charset
+	data = buildMo(map[string]string{
+		"":      "Content-Type: text/plain; charset=EBCDIC\n",
+		"Price": "Preis",
+	})
+	mo = new(Mo)
+	if err := mo.ParseE(data); !errors.Is(err, ErrUnsupportedCharset) {
+		t.Errorf("Expected ErrUnsupportedCharset but got '%v'", err)
+	}
+	if tr := mo.Get("Price"); tr != "Preis" {
+		t.Errorf("Expected 'Preis' but got '%s'", tr)
+	}
+}
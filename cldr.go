@@ -0,0 +1,213 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// cldrSamples lists the CLDR plural categories, used by Android and Apple plurals, but "other",
+// in the order they're matched to plural forms, with the numbers evaluated by the Plural-Forms formula to find their form.
+var cldrSamples = []struct {
+	category string
+	numbers  []int
+}{
+	{"one", []int{1}},
+	{"few", []int{3}},
+	{"two", []int{2}},
+	{"many", []int{11, 7}},
+	{"zero", []int{0}},
+}
+
+// cldrFractionCategories lists the languages with a category only used for fractions, which gettext has no form for:
+// "other" for the ones using "many" for most integers, and "many" for the other ones.
+var cldrFractionCategories = map[string]string{
+	"be": "other", "pl": "other", "ru": "other", "uk": "other",
+	"cs": "many", "lt": "many", "sk": "many",
+}
+
+// languagePluralForms lists the Plural-Forms msginit writes for the languages,
+// used for the catalogs only giving their language.
+var languagePluralForms = map[string]string{
+	"ja": "nplurals=1; plural=0;", "ko": "nplurals=1; plural=0;", "zh": "nplurals=1; plural=0;",
+	"vi": "nplurals=1; plural=0;", "th": "nplurals=1; plural=0;", "id": "nplurals=1; plural=0;",
+	"ms": "nplurals=1; plural=0;",
+
+	"en": "nplurals=2; plural=(n != 1);", "de": "nplurals=2; plural=(n != 1);", "nl": "nplurals=2; plural=(n != 1);",
+	"sv": "nplurals=2; plural=(n != 1);", "da": "nplurals=2; plural=(n != 1);", "nb": "nplurals=2; plural=(n != 1);",
+	"nn": "nplurals=2; plural=(n != 1);", "no": "nplurals=2; plural=(n != 1);", "fo": "nplurals=2; plural=(n != 1);",
+	"es": "nplurals=2; plural=(n != 1);", "pt": "nplurals=2; plural=(n != 1);", "it": "nplurals=2; plural=(n != 1);",
+	"ca": "nplurals=2; plural=(n != 1);", "bg": "nplurals=2; plural=(n != 1);", "el": "nplurals=2; plural=(n != 1);",
+	"fi": "nplurals=2; plural=(n != 1);", "et": "nplurals=2; plural=(n != 1);", "he": "nplurals=2; plural=(n != 1);",
+	"eo": "nplurals=2; plural=(n != 1);", "hu": "nplurals=2; plural=(n != 1);", "tr": "nplurals=2; plural=(n != 1);",
+
+	"fr": "nplurals=2; plural=(n > 1);", "pt_BR": "nplurals=2; plural=(n > 1);",
+
+	"lv": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2);",
+	"ga": "nplurals=3; plural=(n==1 ? 0 : n==2 ? 1 : 2);",
+	"ro": "nplurals=3; plural=(n==1 ? 0 : (n==0 || (n%100 > 0 && n%100 < 20)) ? 1 : 2);",
+	"lt": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"be": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"bs": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"hr": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"ru": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"sr": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"uk": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"cs": "nplurals=3; plural=(n==1) ? 0 : (n>=2 && n<=4) ? 1 : 2;",
+	"sk": "nplurals=3; plural=(n==1) ? 0 : (n>=2 && n<=4) ? 1 : 2;",
+	"pl": "nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+
+	"sl": "nplurals=4; plural=(n%100==1 ? 0 : n%100==2 ? 1 : n%100==3 || n%100==4 ? 2 : 3);",
+
+	"ar": "nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);",
+}
+
+// pluralFormsOf returns the Plural-Forms of the language lang, like "pt_BR" or "pl", or "" if it's unknown.
+func pluralFormsOf(lang string) string {
+	lang = strings.ReplaceAll(lang, "-", "_")
+	if pf, ok := languagePluralForms[lang]; ok {
+		return pf
+	}
+
+	return languagePluralForms[baseLanguage(lang)]
+}
+
+// baseLanguage returns the language code of the locale lang, like "pt" for "pt_BR" or "pt-BR".
+func baseLanguage(lang string) string {
+	return strings.SplitN(strings.SplitN(lang, "_", 2)[0], "-", 2)[0]
+}
+
+// cldrOtherSample is the number evaluated to find the form of the "other" category.
+const cldrOtherSample = 100
+
+// cldrItem is the text of a plural category.
+type cldrItem struct {
+	category string
+	text     string
+}
+
+// setCLDRForms sets the translations of tr from the texts of the plural categories.
+// Items of categories without their own form are used for the forms of their numbers, if any is left,
+// and forms without item get the "other" one, as Android and Apple platforms do. For the languages using "many"
+// for most numbers, it comes before "other".
+// Items left without form return an error, but the ones of the categories lang only uses for fractions.
+func setCLDRForms(tr *Translation, items []cldrItem, categories []string, form func(int) int, lang string) error {
+	fraction := cldrFractionCategories[baseLanguage(lang)]
+
+	var err error
+	var fallback []cldrItem
+	other := ""
+	for _, item := range items {
+		if item.category == "other" {
+			other = item.text
+			if fraction == "other" {
+				fallback = append(fallback, item)
+				continue
+			}
+		}
+
+		i := indexOf(categories, item.category)
+		switch {
+		case i != -1:
+			tr.Trs[i] = item.text
+		case cldrSample(item.category) == nil:
+			if err == nil {
+				err = fmt.Errorf("%w: plural %q has an unknown category %q", ErrInvalidCatalog, tr.ID, item.category)
+			}
+		default:
+			fallback = append(fallback, item)
+		}
+	}
+
+	// "other" comes last
+	sort.SliceStable(fallback, func(i, j int) bool {
+		return fallback[j].category == "other" && fallback[i].category != "other"
+	})
+	for _, item := range fallback {
+		i := form(cldrSample(item.category)[0])
+		if _, ok := tr.Trs[i]; !ok && i >= 0 && i < len(categories) {
+			tr.Trs[i] = item.text
+			continue
+		}
+		if item.category != fraction && err == nil {
+			err = fmt.Errorf("%w: plural %q has no form left for the category %q", ErrInvalidCatalog, tr.ID, item.category)
+		}
+	}
+
+	if other != "" {
+		for i := range categories {
+			if _, ok := tr.Trs[i]; !ok {
+				tr.Trs[i] = other
+			}
+		}
+	}
+
+	return err
+}
+
+// cldrSample returns the numbers evaluated to find the form of the category c, or nil if c is unknown.
+func cldrSample(c string) []int {
+	if c == "other" {
+		return []int{cldrOtherSample}
+	}
+	for _, s := range cldrSamples {
+		if s.category == c {
+			return s.numbers
+		}
+	}
+
+	return nil
+}
+
+// cldrCategories returns the CLDR category of every plural form.
+// The form of a large number is "other", which is used when a category is missing,
+// and the other forms get the first category with a number of the form.
+// Forms matching none of them are left empty.
+func cldrCategories(nplurals int, form func(int) int) []string {
+	if nplurals < 1 {
+		nplurals = 2
+	}
+
+	categories := make([]string, nplurals)
+	if i := form(cldrOtherSample); i >= 0 && i < nplurals {
+		categories[i] = "other"
+	}
+
+	for i := range categories {
+		if categories[i] != "" {
+			continue
+		}
+		for _, s := range cldrSamples {
+			if indexOf(categories, s.category) != -1 {
+				continue
+			}
+			for _, n := range s.numbers {
+				if form(n) == i {
+					categories[i] = s.category
+					break
+				}
+			}
+			if categories[i] != "" {
+				break
+			}
+		}
+	}
+
+	return categories
+}
+
+// indexOf returns the index of s in list, or -1 if it isn't there.
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DeineAgenturUG/gotext/plurals"
+)
+
+func TestCLDRCategories(t *testing.T) {
+	for _, c := range []struct {
+		nplurals int
+		plural   string
+		expected string
+	}{
+		{1, "0", "other"},
+		{2, "(n != 1)", "one other"},
+		{2, "(n > 1)", "one other"},
+		{3, "(n==1) ? 0 : (n>=2 && n<=4) ? 1 : 2", "one few other"},
+		{3, "(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2)", "one few other"},
+		{3, "(n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2)", "one other zero"},
+		{4, "(n%100==1 ? 0 : n%100==2 ? 1 : n%100==3 || n%100==4 ? 2 : 3)", "one two few other"},
+		{5, "(n==1 ? 0 : n==2 ? 1 : (n>2 && n<7) ? 2 :(n>6 && n<11) ? 3 : 4)", "one two few many other"},
+		{6, "(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5)", "zero one two few many other"},
+	} {
+		expr, err := plurals.Compile(c.plural)
+		if err != nil {
+			t.Fatal(err)
+		}
+		form := func(n int) int { return expr.Eval(uint32(n)) }
+
+		if q := strings.Join(cldrCategories(c.nplurals, form), " "); q != c.expected {
+			t.Errorf("%s: expected '%s' but got '%s'", c.plural, c.expected, q)
+		}
+	}
+}
+
+func TestPluralFormsOf(t *testing.T) {
+	// Every formula is valid
+	for lang, pf := range languagePluralForms {
+		if _, err := validatePluralForms(pf, true); err != nil {
+			t.Errorf("%s: %v", lang, err)
+		}
+	}
+
+	for lang, expected := range map[string]string{
+		"pl":    languagePluralForms["pl"],
+		"pt_BR": "nplurals=2; plural=(n > 1);",
+		"pt-BR": "nplurals=2; plural=(n > 1);",
+		"pt_PT": "nplurals=2; plural=(n != 1);",
+		"de-AT": "nplurals=2; plural=(n != 1);",
+		"xx":    "",
+		"":      "",
+	} {
+		if pf := pluralFormsOf(lang); pf != expected {
+			t.Errorf("%s: expected %q but got %q", lang, expected, pf)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+)
+
+// gzipMagic starts gzip compressed content.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// gzipFile closes both the gzip reader and its file.
+type gzipFile struct {
+	*gzip.Reader
+	f io.Closer
+}
+
+// Close closes the gzip reader and the file.
+func (gf *gzipFile) Close() error {
+	gf.Reader.Close()
+	return gf.f.Close()
+}
+
+// openFile opens the catalog file f of fsys for reading, or of the OS file system when fsys is nil.
+// Gzip compressed files, like default.po.gz, are recognized by their content and decompressed.
+func openFile(fsys fs.FS, f string) (io.ReadCloser, error) {
+	var fd io.ReadCloser
+	var err error
+	if fsys == nil {
+		fd, err = os.Open(f)
+	} else {
+		fd, err = fsys.Open(f)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(fd)
+	if magic, _ := br.Peek(len(gzipMagic)); !bytes.Equal(magic, gzipMagic) {
+		return struct {
+			io.Reader
+			io.Closer
+		}{br, fd}, nil
+	}
+
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		fd.Close()
+		return nil, err
+	}
+
+	return &gzipFile{Reader: zr, f: fd}, nil
+}
+
+// readFile reads the content of the catalog file f of fsys, decompressed when it's gzip compressed.
+func readFile(fsys fs.FS, f string) ([]byte, error) {
+	r, err := openFile(fsys, f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+// findFile returns f when it exists on fsys, or its gzip compressed version f+".gz".
+// It returns an empty string when none of them exists.
+func findFile(fsys fs.FS, f string) string {
+	for _, name := range []string{f, f + ".gz"} {
+		if fileExists(fsys, name) {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// fileExists reports whether f exists on fsys, or on the OS file system when fsys is nil.
+func fileExists(fsys fs.FS, f string) bool {
+	var err error
+	if fsys == nil {
+		_, err = os.Stat(f)
+	} else {
+		_, err = fs.Stat(fsys, f)
+	}
+
+	return err == nil
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// gzipFixture writes the gzip compressed content of the fixture src to dst.
+func gzipFixture(t *testing.T, src, dst string) {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(dst, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGzipParseFile(t *testing.T) {
+	dir := t.TempDir()
+	gzipFixture(t, "fixtures/de/default.po", filepath.Join(dir, "default.po.gz"))
+	gzipFixture(t, "fixtures/de/default.mo", filepath.Join(dir, "default.mo.gz"))
+
+	po, plainPo := new(Po), new(Po)
+	if err := po.ParseFileE(filepath.Join(dir, "default.po.gz")); err != nil {
+		t.Fatal(err)
+	}
+	plainPo.ParseFile("fixtures/de/default.po")
+
+	mo, plainMo := new(Mo), new(Mo)
+	if err := mo.ParseFileE(filepath.Join(dir, "default.mo.gz")); err != nil {
+		t.Fatal(err)
+	}
+	plainMo.ParseFile("fixtures/de/default.mo")
+
+	lazy := new(LazyMo)
+	if err := lazy.ParseFileE(filepath.Join(dir, "default.mo.gz")); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tr := range map[string][2]Translator{"Po": {po, plainPo}, "Mo": {mo, plainMo}, "LazyMo": {lazy, plainMo}} {
+		got, expected := tr[0].Get, tr[1].Get
+		if got("My text") != expected("My text") || got("My text") == "My text" {
+			t.Errorf("%s: expected '%s' but got '%s'", name, expected("My text"), got("My text"))
+		}
+	}
+
+	// Broken compressed content
+	data, _ := ioutil.ReadFile(filepath.Join(dir, "default.mo.gz"))
+	ioutil.WriteFile(filepath.Join(dir, "broken.mo.gz"), data[:len(data)/2], 0644)
+	if err := new(Mo).ParseFileE(filepath.Join(dir, "broken.mo.gz")); err == nil {
+		t.Error("Expected error on truncated gzip content")
+	}
+}
+
+func TestGzipAddDomain(t *testing.T) {
+	dir := t.TempDir()
+	gzipFixture(t, "fixtures/de/default.mo", filepath.Join(dir, "de", "LC_MESSAGES", "default.mo.gz"))
+	gzipFixture(t, "fixtures/de/default.po", filepath.Join(dir, "de", "LC_MESSAGES", "extras.po.gz"))
+	gzipFixture(t, "fixtures/de/default.mo", filepath.Join(dir, "de", "LC_MESSAGES", "extras.mo.gz"))
+
+	l := NewLocale(dir, "de_DE")
+	if err := l.AddDomainE("default"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := l.Domains.Load("default"); v == nil {
+		t.Fatal("Expected a default domain")
+	} else if _, ok := v.(*Mo); !ok {
+		t.Errorf("Expected a Mo domain but got %T", v)
+	}
+	if s := l.Get("My text"); s != "Translated text" {
+		t.Errorf("Expected 'Translated text' but got '%s'", s)
+	}
+
+	// Compressed .po files still come before .mo files
+	if err := l.AddDomainE("extras"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := l.Domains.Load("extras"); v == nil {
+		t.Fatal("Expected an extras domain")
+	} else if _, ok := v.(*Po); !ok {
+		t.Errorf("Expected a Po domain but got %T", v)
+	}
+
+	// Uncompressed files come first
+	plain := filepath.Join(dir, "de", "LC_MESSAGES", "default.mo")
+	if err := ioutil.WriteFile(plain, buildMo(map[string]string{"My text": "Plain"}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l.AddDomain("default")
+	if s := l.Get("My text"); s != "Plain" {
+		t.Errorf("Expected 'Plain' but got '%s'", s)
+	}
+
+	if err := l.AddDomainE("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got '%v'", err)
+	}
+}
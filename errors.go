@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a translation file or domain can't be found.
+	ErrNotFound = errors.New("gotext: translation file not found")
+
+	// ErrBadMagic is returned when a MO file doesn't start with a valid magic number.
+	ErrBadMagic = errors.New("gotext: invalid MO magic number")
+
+	// ErrUnsupportedRevision is returned when a MO file uses a revision this package can't read.
+	ErrUnsupportedRevision = errors.New("gotext: unsupported MO file revision")
+
+	// ErrCorruptMo is returned when the tables or strings of a MO file are out of its bounds,
+	// or when its strings overlap so much that decoding them would take memory out of proportion to its size.
+	ErrCorruptMo = errors.New("gotext: corrupted MO file")
+
+	// ErrInvalidCatalog is returned when a catalog file doesn't follow the schema of its format,
+	// like a JSON message without id.
+	ErrInvalidCatalog = errors.New("gotext: invalid catalog")
+
+	// ErrDuplicate is reported when a message is defined twice for the same context.
+	ErrDuplicate = errors.New("gotext: duplicate message definition")
+
+	// ErrInvalidHeader is reported by Validate when the header entry is missing or malformed.
+	ErrInvalidHeader = errors.New("gotext: invalid header entry")
+
+	// ErrInvalidPluralForms is reported by Validate when the Plural-Forms header is missing or can't be compiled.
+	ErrInvalidPluralForms = errors.New("gotext: invalid Plural-Forms header")
+
+	// ErrPluralCount is reported by Validate when an entry doesn't have nplurals translations,
+	// and by MoEncoder and JsonEncoder for entries with forms out of nplurals.
+	ErrPluralCount = errors.New("gotext: wrong number of plural forms")
+
+	// ErrPluralRange is reported by Validate when the plural expression returns an index out of nplurals.
+	ErrPluralRange = errors.New("gotext: plural expression out of range")
+
+	// ErrUnnamed is returned by the encoders of formats keyed by names, like AndroidEncoder,
+	// when entries have no valid name. The other entries are still written.
+	ErrUnnamed = errors.New("gotext: entries without valid name")
+
+	// ErrNewlineMismatch is reported by Validate when msgid and msgstr don't both begin or end with a newline.
+	ErrNewlineMismatch = errors.New("gotext: newline mismatch between msgid and msgstr")
+)
+
+// ParseError describes a problem found while parsing a translation source.
+// Line and Column are 1-based and are left at 0 when they don't apply, like for binary MO files.
+type ParseError struct {
+	// File is the path of the parsed file, if known.
+	File string
+
+	// Position of the problem in the source.
+	Line   int
+	Column int
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	msg := strings.TrimPrefix(e.Err.Error(), "gotext: ")
+
+	pos := e.File
+	if e.Line > 0 {
+		if pos != "" {
+			pos += ":"
+		}
+		pos += strconv.Itoa(e.Line)
+		if e.Column > 0 {
+			pos += ":" + strconv.Itoa(e.Column)
+		}
+	}
+
+	if pos == "" {
+		return "gotext: " + msg
+	}
+	return "gotext: " + pos + ": " + msg
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// withFile sets the file name on err when it's a *ParseError without one.
+func withFile(err error, f string) error {
+	var pe *ParseError
+	if errors.As(err, &pe) && pe.File == "" {
+		pe.File = f
+	}
+	return err
+}
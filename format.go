@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Priorities of the built-in formats, so PO files are preferred over MO files,
+// and both over the other formats, like JSON catalogs.
+const (
+	PoPriority      = 20
+	MoPriority      = 10
+	DefaultPriority = 0
+)
+
+// format is a catalog format registered with RegisterFormat.
+type format struct {
+	ext      string
+	factory  func() Translator
+	priority int
+}
+
+// formats holds the registered catalog formats, sorted by preference.
+var formats struct {
+	sync.RWMutex
+	list []format
+}
+
+func init() {
+	RegisterFormat("po", func() Translator { return new(Po) }, PoPriority)
+	RegisterFormat("mo", func() Translator { return new(Mo) }, MoPriority)
+	RegisterFormat("json", NewJsonTranslator, DefaultPriority)
+	RegisterFormat("xlf", NewXliffTranslator, DefaultPriority)
+	RegisterFormat("xliff", NewXliffTranslator, DefaultPriority)
+	RegisterFormat("properties", NewPropertiesTranslator, DefaultPriority)
+	RegisterFormat("ts", NewQtTranslator, DefaultPriority)
+	RegisterFormat("arb", NewArbTranslator, DefaultPriority)
+}
+
+/*
+RegisterFormat makes the catalog files with the extension ext loadable by Locale.AddDomain,
+which creates their Translator object with factory.
+
+AddDomain looks for the files of every registered format in each language directory,
+trying formats with a higher priority first. PO and MO files are registered with PoPriority and MoPriority.
+Registering an extension again replaces its factory and priority, so the preference between PO and MO
+files can be changed too. Formats with the same priority are tried in the order of their extensions.
+
+Translator objects implementing Loader report their loading errors to AddDomainE.
+
+Example:
+
+	// Prefer MO files over PO files
+	gotext.RegisterFormat("mo", func() gotext.Translator { return new(gotext.Mo) }, gotext.PoPriority+1)
+*/
+func RegisterFormat(ext string, factory func() Translator, priority int) {
+	ext = strings.TrimPrefix(ext, ".")
+
+	formats.Lock()
+	defer formats.Unlock()
+
+	list := formats.list[:0:0]
+	for _, f := range formats.list {
+		if f.ext != ext {
+			list = append(list, f)
+		}
+	}
+	list = append(list, format{ext: ext, factory: factory, priority: priority})
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].priority != list[j].priority {
+			return list[i].priority > list[j].priority
+		}
+		return list[i].ext < list[j].ext
+	})
+	formats.list = list
+}
+
+// registeredFormats returns the registered formats, sorted by preference.
+func registeredFormats() []format {
+	formats.RLock()
+	defer formats.RUnlock()
+
+	return formats.list
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+// parseOnly hides the Loader methods of its Translator.
+type parseOnly struct {
+	Translator
+}
+
+// restoreFormats registers the formats again as they are when the test ends.
+func restoreFormats(t *testing.T) {
+	saved := registeredFormats()
+	t.Cleanup(func() {
+		formats.Lock()
+		defer formats.Unlock()
+
+		formats.list = saved
+	})
+}
+
+func TestRegisterFormat(t *testing.T) {
+	restoreFormats(t)
+
+	// PO files are preferred by default
+	l := NewLocale("fixtures/", "de")
+	l.AddDomain("default")
+	if v, _ := l.Domains.Load("default"); v == nil {
+		t.Fatal("Expected a default domain")
+	} else if _, ok := v.(*Po); !ok {
+		t.Errorf("Expected a Po domain but got %T", v)
+	}
+
+	// Prefer MO files
+	RegisterFormat(".mo", func() Translator { return new(Mo) }, PoPriority+1)
+
+	l = NewLocale("fixtures/", "de")
+	l.AddDomain("default")
+	if v, _ := l.Domains.Load("default"); v == nil {
+		t.Fatal("Expected a default domain")
+	} else if _, ok := v.(*Mo); !ok {
+		t.Errorf("Expected a Mo domain but got %T", v)
+	}
+	if f := registeredFormats(); f[0].ext != "mo" || f[1].ext != "po" || f[2].ext == "mo" {
+		t.Errorf("Expected the mo format to be replaced but got %v", f)
+	}
+
+	// Custom formats
+	RegisterFormat("catalog", func() Translator { return parseOnly{new(Po)} }, 0)
+
+	fsys := fstest.MapFS{
+		"de/LC_MESSAGES/custom.catalog": {Data: []byte("msgid \"My text\"\nmsgstr \"Custom text\"\n")},
+		"de/LC_MESSAGES/custom.mo":      {Data: []byte("not a MO file")},
+		"de_DE/broken.catalog":          {Data: []byte("msgid \"My text\"\nmsgstr \"Broken\n")},
+	}
+
+	// A format with a higher priority in the same directory comes first
+	l = NewLocaleFS(fsys, "de_DE")
+	if err := l.AddDomainE("custom"); err == nil {
+		t.Error("Expected the broken MO file to be loaded")
+	}
+
+	RegisterFormat("catalog", func() Translator { return parseOnly{new(Po)} }, PoPriority+2)
+	if err := l.AddDomainE("custom"); err != nil {
+		t.Fatal(err)
+	}
+	if tr := l.GetD("custom", "My text"); tr != "Custom text" {
+		t.Errorf("Expected 'Custom text' but got '%s'", tr)
+	}
+
+	// Translator objects without Loader methods don't report errors
+	if err := l.AddDomainE("broken"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestRestoreFormats(t *testing.T) {
+	before := registeredFormats()
+	t.Run("register", func(t *testing.T) {
+		restoreFormats(t)
+		RegisterFormat("catalog", func() Translator { return new(Po) }, 0)
+		RegisterFormat("mo", func() Translator { return new(Mo) }, PoPriority+1)
+	})
+
+	if after := registeredFormats(); !reflect.DeepEqual(formatExts(after), formatExts(before)) {
+		t.Errorf("Expected the formats %v but got %v", formatExts(before), formatExts(after))
+	}
+}
+
+// formatExts returns the extensions of the formats f.
+func formatExts(f []format) []string {
+	exts := make([]string, len(f))
+	for i := range f {
+		exts[i] = f[i].ext
+	}
+
+	return exts
+}
@@ -1,3 +1,5 @@
 module github.com/DeineAgenturUG/gotext
 
+go 1.20
+
 // go: no requirements found in Gopkg.lock
@@ -24,6 +24,7 @@ package gotext
 
 import (
 	"encoding/gob"
+	"io/fs"
 	"sync"
 )
 
@@ -42,6 +43,9 @@ type config struct {
 	// Path to library directory where all locale directories and Translation files are.
 	library string
 
+	// File system of the library, used instead of the library path when set.
+	fsys fs.FS
+
 	// Storage for package level methods
 	storage sync.Map
 }
@@ -84,12 +88,51 @@ func GetInstance(loadDomains, loadLanguages []string, defaultDomain, defaultLang
 	})
 }
 
+// GetInstanceFS works like GetInstance, but reads the locale directories and Translation files from fsys,
+// like an embed.FS, instead of a library path.
+func GetInstanceFS(loadDomains, loadLanguages []string, defaultDomain, defaultLanguage string, fsys fs.FS) {
+	once.Do(func() {
+		globalConfig = &config{
+			loadDomains:   loadDomains,
+			domain:        defaultDomain,
+			loadLanguages: loadLanguages,
+			language:      defaultLanguage,
+			fsys:          fsys,
+			storage:       sync.Map{},
+		}
+		globalConfig.loadStorage(true)
+	})
+}
+
+// newLocale creates the Locale object for lang on the configured library.
+// It must be called with the lock held.
+func (c *config) newLocale(lang string) *Locale {
+	if c.fsys != nil {
+		return NewLocaleFS(c.fsys, lang)
+	}
+
+	return NewLocale(c.library, lang)
+}
+
+// setLibrary sets the library path or file system, and drops the Locale objects loaded from a different one.
+// It must be called with the write lock held.
+func (c *config) setLibrary(lib string, fsys fs.FS) {
+	if lib != c.library || fsys != nil || c.fsys != nil {
+		c.storage.Range(func(key, value interface{}) bool {
+			c.storage.Delete(key)
+			return true
+		})
+	}
+
+	c.library, c.fsys = lib, fsys
+}
+
 // loadStorage creates a new Locale object at package level based on the Global variables settings.
 // It's called automatically when trying to use Get or GetD methods.
 func (c *config) loadStorage(force bool) *config {
 	c.RLock()
 
-	if v, _ := c.storage.LoadOrStore(c.language, NewLocale(c.library, c.language)); v != nil {
+	if v, _ := c.storage.LoadOrStore(c.language, c.newLocale(c.language)); v != nil {
 		v2 := v.(*Locale)
 		v2.AddDomain(c.domain)
 		for _, domain := range c.loadDomains {
@@ -100,7 +143,7 @@ func (c *config) loadStorage(force bool) *config {
 	}
 
 	for _, language := range c.loadLanguages {
-		if v, _ := c.storage.LoadOrStore(language, NewLocale(c.library, language)); v != nil {
+		if v, _ := c.storage.LoadOrStore(language, c.newLocale(language)); v != nil {
 			v2 := v.(*Locale)
 			v2.AddDomain(c.domain)
 			for _, domain := range c.loadDomains {
@@ -169,7 +212,7 @@ func (c *config) GetLibrary() string {
 // It reloads the corresponding translation file.
 func (c *config) SetLibrary(lib string) *config {
 	c.Lock()
-	c.library = lib
+	c.setLibrary(lib, nil)
 	c.Unlock()
 
 	c.loadStorage(true)
@@ -183,7 +226,7 @@ func (c *config) SetLibrary(lib string) *config {
 func (c *config) Configure(lib, lang, dom string) *config {
 	c.Lock()
 
-	c.library = lib
+	c.setLibrary(lib, nil)
 	c.language = lang
 	c.domain = dom
 
@@ -348,7 +391,7 @@ func GetLibrary() string {
 // It reloads the corresponding Translation file.
 func SetLibrary(lib string) {
 	globalConfig.Lock()
-	globalConfig.library = lib
+	globalConfig.setLibrary(lib, nil)
 	globalConfig.Unlock()
 
 	globalConfig.loadStorage(true)
@@ -360,7 +403,22 @@ func SetLibrary(lib string) {
 // as using each setter will introduce a I/O overhead because the Translation file will be loaded after each set.
 func Configure(lib, lang, dom string) {
 	globalConfig.Lock()
-	globalConfig.library = lib
+	globalConfig.setLibrary(lib, nil)
+	globalConfig.language = SimplifiedLocale(lang)
+	globalConfig.domain = dom
+	globalConfig.loadDomains = append(globalConfig.loadDomains, dom)
+	globalConfig.loadDomains = UniqStrings(globalConfig.loadDomains)
+	globalConfig.Unlock()
+
+	globalConfig.loadStorage(true)
+}
+
+// ConfigureFS works like Configure, but reads the locale directories and Translation files from fsys,
+// like an embed.FS or a fstest.MapFS, instead of a library path.
+// The files are looked up from the root of fsys, using the same layout as a library directory.
+func ConfigureFS(fsys fs.FS, lang, dom string) {
+	globalConfig.Lock()
+	globalConfig.setLibrary("", fsys)
 	globalConfig.language = SimplifiedLocale(lang)
 	globalConfig.domain = dom
 	globalConfig.loadDomains = append(globalConfig.loadDomains, dom)
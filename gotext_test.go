@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"sync"
 	"testing"
+	"testing/fstest"
 )
 
 func loadInstance() {
@@ -290,6 +291,29 @@ func TestMoAndPoTranslator(t *testing.T) {
 	}
 }
 
+func TestConfigureFS(t *testing.T) {
+	loadInstance()
+
+	fsys := fstest.MapFS{
+		"en_US/LC_MESSAGES/default.po": {Data: []byte("msgid \"My text\"\nmsgstr \"Embedded text\"\n")},
+	}
+
+	ConfigureFS(fsys, "en_US", "default")
+	if tr := Get("My text"); tr != "Embedded text" {
+		t.Errorf("Expected 'Embedded text'. Got '%s'", tr)
+	}
+	if lib := GetLibrary(); lib != "" {
+		t.Errorf("Expected empty library. Got '%s'", lib)
+	}
+
+	// Back to a library path
+	fixPath, _ := filepath.Abs("./fixtures/")
+	Configure(fixPath, "en_US", "default")
+	if tr := Get("My text"); tr != "Translated text" {
+		t.Errorf("Expected 'Translated text'. Got '%s'", tr)
+	}
+}
+
 func TestDomains(t *testing.T) {
 	loadInstance()
 	// Set PO content
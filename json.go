@@ -0,0 +1,269 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+)
+
+/*
+Json parses the content of JSON catalogs and provides all the Translation functions needed.
+And it's safe for concurrent use by multiple goroutines by using the sync package for locking.
+
+A JSON catalog is an object with the headers of the catalog, as in the header entry of a PO file,
+and the list of its messages. Messages have an "id", an optional "context", and either a "translation",
+or a "plural" id with the "translations" of every plural form, indexed by the Plural-Forms expression.
+Translations needing review are marked "fuzzy", as with the fuzzy flag of PO files:
+
+	{
+		"headers": {
+			"Language": "de",
+			"Plural-Forms": "nplurals=2; plural=(n != 1);"
+		},
+		"messages": [
+			{"id": "Hello", "translation": "Hallo"},
+			{"context": "menu", "id": "Open", "translation": "Öffnen"},
+			{"id": "One file", "plural": "%d files", "translations": ["Eine Datei", "%d Dateien"]},
+			{"id": "Close", "translation": "Schließen", "fuzzy": true}
+		]
+	}
+
+Other fields are ignored. MarshalJson and JsonEncoder write any Translator with the same schema.
+
+Example:
+
+	import (
+		"fmt"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		// Create json object
+		j := gotext.NewJsonTranslator()
+
+		// Parse .json file
+		j.ParseFile("/path/to/json/file/translations.json")
+
+		// Get Translation
+		fmt.Println(j.Get("Translate this"))
+	}
+*/
+type Json struct {
+	catalog
+}
+
+// jsonCatalog is the schema of JSON catalogs.
+type jsonCatalog struct {
+	Headers  map[string]string `json:"headers,omitempty"`
+	Messages []jsonMessage     `json:"messages"`
+}
+
+// jsonMessage is the schema of the messages of JSON catalogs.
+type jsonMessage struct {
+	Context      string   `json:"context,omitempty"`
+	ID           string   `json:"id"`
+	Plural       string   `json:"plural,omitempty"`
+	Translation  string   `json:"translation,omitempty"`
+	Translations []string `json:"translations,omitempty"`
+	Fuzzy        bool     `json:"fuzzy,omitempty"`
+}
+
+// NewJsonTranslator creates a new Json object with the Translator interface
+func NewJsonTranslator() Translator {
+	return new(Json)
+}
+
+// ParseFile tries to read the file by its provided path (f) and parse its content as a JSON catalog.
+// Gzip compressed files, like translations.json.gz, are decompressed.
+func (j *Json) ParseFile(f string) {
+	j.ParseFileE(f)
+}
+
+// ParseFileE works like ParseFile, but returns an error when the file can't be read or parsed.
+// A missing file or a directory path returns an error wrapping ErrNotFound.
+func (j *Json) ParseFileE(f string) error {
+	return j.parseFile(f, j.ParseE)
+}
+
+// Parse loads the translations specified in the provided JSON content (buf).
+func (j *Json) Parse(buf []byte) {
+	j.ParseE(buf)
+}
+
+// ParseE works like Parse, but returns a *ParseError describing the first problem found.
+// Nothing is loaded when buf isn't valid JSON. Messages that don't follow the schema
+// are reported with an error wrapping ErrInvalidCatalog, and the other ones are still loaded.
+func (j *Json) ParseE(buf []byte) error {
+	headers, trs, err := parseJson(buf)
+	if headers == nil && trs == nil {
+		return err
+	}
+
+	// Lock while storing
+	j.Lock()
+	defer j.Unlock()
+
+	if headers != nil {
+		j.setHeaders(headers)
+	}
+	for _, tr := range trs {
+		j.add(tr)
+	}
+
+	return err
+}
+
+// parseJson reads the headers and messages of a JSON catalog.
+// It returns the first schema error along with the valid messages,
+// or only an error when data isn't a valid JSON catalog.
+func parseJson(data []byte) (textproto.MIMEHeader, []*Translation, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	// fail reports err at the offset off of data
+	fail := func(off int64, err error) *ParseError {
+		line, col := sourcePosition(data, off)
+		return &ParseError{Line: line, Column: col, Err: err}
+	}
+
+	// syntaxError reports a JSON decoding error at its position.
+	// Offsets of type errors are relative to the decoded value, starting at base.
+	syntaxError := func(err error, base int64) *ParseError {
+		off := dec.InputOffset()
+		var se *json.SyntaxError
+		var te *json.UnmarshalTypeError
+		if errors.As(err, &se) {
+			// The offset is after the invalid character, or at the end of truncated content
+			off = se.Offset
+			if off < int64(len(data)) {
+				off--
+			}
+		} else if errors.As(err, &te) {
+			off = base + te.Offset
+		}
+		return fail(off, fmt.Errorf("%w: %v", ErrInvalidCatalog, err))
+	}
+
+	// next returns the offset of the next value, after the separators
+	next := func() int64 {
+		off := dec.InputOffset()
+		for off < int64(len(data)) && bytes.IndexByte([]byte(" \t\r\n,:"), data[off]) != -1 {
+			off++
+		}
+		return off
+	}
+
+	// delim reads the delimiter d
+	delim := func(d json.Delim, what string) error {
+		off := next()
+		tok, err := dec.Token()
+		if err != nil {
+			return syntaxError(err, 0)
+		}
+		if tok != d {
+			return fail(off, fmt.Errorf("%w: expected %s", ErrInvalidCatalog, what))
+		}
+		return nil
+	}
+
+	if err := delim('{', "a JSON object"); err != nil {
+		return nil, nil, err
+	}
+
+	var headers textproto.MIMEHeader
+	var trs []*Translation
+	var first error
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, syntaxError(err, 0)
+		}
+
+		switch tok {
+		case "headers":
+			off := next()
+			var h map[string]string
+			if err := dec.Decode(&h); err != nil {
+				return nil, nil, syntaxError(err, off)
+			}
+			headers = make(textproto.MIMEHeader)
+			for k, v := range h {
+				headers.Set(k, v)
+			}
+
+		case "messages":
+			if err := delim('[', "an array of messages"); err != nil {
+				return nil, nil, err
+			}
+			for dec.More() {
+				off := next()
+
+				var m jsonMessage
+				if err := dec.Decode(&m); err != nil {
+					return nil, nil, syntaxError(err, off)
+				}
+
+				tr, err := m.translation()
+				if err != nil {
+					if first == nil {
+						first = fail(off, err)
+					}
+					continue
+				}
+				trs = append(trs, tr)
+			}
+			if err := delim(']', "the end of the messages"); err != nil {
+				return nil, nil, err
+			}
+
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, nil, syntaxError(err, 0)
+			}
+		}
+	}
+
+	if err := delim('}', "the end of the catalog"); err != nil {
+		return nil, nil, err
+	}
+
+	if trs == nil {
+		trs = []*Translation{}
+	}
+
+	return headers, trs, first
+}
+
+// translation builds the Translation of the message, checking it follows the schema.
+func (m *jsonMessage) translation() (*Translation, error) {
+	if m.ID == "" {
+		return nil, fmt.Errorf("%w: message without id", ErrInvalidCatalog)
+	}
+	if m.Plural == "" && len(m.Translations) > 0 {
+		return nil, fmt.Errorf("%w: message %q has translations but no plural id", ErrInvalidCatalog, m.ID)
+	}
+	if m.Plural != "" && m.Translation != "" {
+		return nil, fmt.Errorf("%w: plural message %q has a translation instead of translations", ErrInvalidCatalog, m.ID)
+	}
+
+	tr := NewTranslation()
+	tr.Context = m.Context
+	tr.ID = m.ID
+	tr.PluralID = m.Plural
+	tr.Trs[0] = m.Translation
+	for i, s := range m.Translations {
+		tr.Trs[i] = s
+	}
+	if m.Fuzzy {
+		tr.Flags = []string{"fuzzy"}
+	}
+
+	return tr, nil
+}
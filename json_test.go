@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+const jsonCatalogStr = `{
+	"headers": {
+		"Language": "de",
+		"Plural-Forms": "nplurals=2; plural=(n != 1);",
+		"X-Generator": "test"
+	},
+	"extra": {"ignored": [1, 2, 3]},
+	"messages": [
+		{"id": "Hello", "translation": "Hallo"},
+		{"context": "menu", "id": "Open", "translation": "Öffnen"},
+		{"id": "One file", "plural": "%d files", "translations": ["Eine Datei", "%d Dateien"]},
+		{"context": "menu", "id": "One item", "plural": "%d items", "translations": ["Ein Eintrag", "%d Einträge"]},
+		{"id": "Untranslated"}
+	]
+}`
+
+func TestJson(t *testing.T) {
+	j := new(Json)
+	if err := j.ParseE([]byte(jsonCatalogStr)); err != nil {
+		t.Fatal(err)
+	}
+
+	if j.Language != "de" || j.PluralForms != "nplurals=2; plural=(n != 1);" {
+		t.Errorf("Unexpected headers: '%s', '%s'", j.Language, j.PluralForms)
+	}
+	if h := j.Headers.Get("X-Generator"); h != "test" {
+		t.Errorf("Expected 'test' but got '%s'", h)
+	}
+
+	get, getN, getC, getNC := j.Get, j.GetN, j.GetC, j.GetNC
+	for _, c := range []struct{ got, expected string }{
+		{get("Hello"), "Hallo"},
+		{getC("Open", "menu"), "Öffnen"},
+		{getC("Open", "other"), "Open"},
+		{getN("One file", "%d files", 1), "Eine Datei"},
+		{getN("One file", "%d files", 3, 3), "3 Dateien"},
+		{getNC("One item", "%d items", 5, "menu", 5), "5 Einträge"},
+		{get("Untranslated"), "Untranslated"},
+		{getN("Missing", "Missings", 2), "Missings"},
+	} {
+		if c.got != c.expected {
+			t.Errorf("Expected '%s' but got '%s'", c.expected, c.got)
+		}
+	}
+
+	// Gob encoding
+	buff, err := j.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	j2 := new(Json)
+	if err := j2.UnmarshalBinary(buff); err != nil {
+		t.Fatal(err)
+	}
+	if s := j2.GetN("One file", "%d files", 3, 3); s != "3 Dateien" {
+		t.Errorf("Expected '3 Dateien' but got '%s'", s)
+	}
+}
+
+func TestJsonErrors(t *testing.T) {
+	for name, c := range map[string]struct {
+		json         string
+		line, column int
+	}{
+		"syntax":         {"{\n  \"messages\": [\n    {\"id\": \"Hello\",}\n  ]\n}", 3, 20},
+		"type":           {"{\n  \"messages\": [{\"id\": 42}]\n}", 2, 25},
+		"not an object":  {"[]", 1, 1},
+		"not an array":   {"{\"messages\": {}}", 1, 14},
+		"missing id":     {"{\"messages\": [\n  {\"id\": \"Hello\", \"translation\": \"Hallo\"},\n  {\"translation\": \"None\"}\n]}", 3, 3},
+		"plural":         {"{\"messages\": [\n{\"id\": \"One\", \"translations\": [\"Eins\"]}]}", 2, 1},
+		"no plural list": {"{\"messages\": [{\"id\": \"One\", \"plural\": \"Many\", \"translation\": \"Eins\"}]}", 1, 15},
+		"truncated":      {"{\"messages\": [", 1, 15},
+	} {
+		j := new(Json)
+		err := j.ParseE([]byte(c.json))
+
+		var pe *ParseError
+		if !errors.As(err, &pe) || !errors.Is(err, ErrInvalidCatalog) {
+			t.Errorf("%s: expected ErrInvalidCatalog but got '%v'", name, err)
+			continue
+		}
+		if pe.Line != c.line || pe.Column != c.column {
+			t.Errorf("%s: expected error at %d:%d but got '%v'", name, c.line, c.column, err)
+		}
+	}
+
+	// Valid messages are still loaded
+	j := new(Json)
+	j.Parse([]byte("{\"messages\": [{\"translation\": \"None\"}, {\"id\": \"Hello\", \"translation\": \"Hallo\"}]}"))
+	if s := j.Get("Hello"); s != "Hallo" {
+		t.Errorf("Expected 'Hallo' but got '%s'", s)
+	}
+}
+
+func TestJsonLocale(t *testing.T) {
+	fsys := fstest.MapFS{
+		"de/LC_MESSAGES/default.json": {Data: []byte(jsonCatalogStr)},
+	}
+
+	l := NewLocaleFS(fsys, "de_DE")
+	if err := l.AddDomainE("default"); err != nil {
+		t.Fatal(err)
+	}
+	if s := l.GetN("One file", "%d files", 2, 2); s != "2 Dateien" {
+		t.Errorf("Expected '2 Dateien' but got '%s'", s)
+	}
+
+	// Locale encoding
+	buff, err := l.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l2 := new(Locale)
+	if err := l2.UnmarshalBinary(buff); err != nil {
+		t.Fatal(err)
+	}
+	if s := l2.GetC("Open", "menu"); s != "Öffnen" {
+		t.Errorf("Expected 'Öffnen' but got '%s'", s)
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+/*
+JsonEncoder writes catalogs as JSON, with the schema Json objects parse.
+Any Translator can be encoded, as its entries are read through MarshalBinary,
+so a PO or MO catalog can be served to JavaScript code too.
+
+Example:
+
+	import (
+		"os"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		po := new(gotext.Po)
+		po.ParseFile("/path/to/po/file/translations.po")
+
+		f, _ := os.Create("/path/to/json/file/translations.json")
+		defer f.Close()
+
+		gotext.NewJsonEncoder(f).Encode(po)
+	}
+*/
+type JsonEncoder struct {
+	// Indent of the nested values, two spaces by default. Use "" for a compact output.
+	Indent string
+
+	w io.Writer
+}
+
+// NewJsonEncoder returns a JsonEncoder writing indented JSON to w.
+func NewJsonEncoder(w io.Writer) *JsonEncoder {
+	return &JsonEncoder{
+		Indent: "  ",
+		w:      w,
+	}
+}
+
+// MarshalJson returns the catalog of t as the content of an indented JSON catalog.
+func MarshalJson(t Translator) ([]byte, error) {
+	var buff bytes.Buffer
+	err := NewJsonEncoder(&buff).Encode(t)
+
+	return buff.Bytes(), err
+}
+
+// Encode writes the catalog of t as JSON.
+// Messages are sorted by context and id, and fuzzy ones are marked as such.
+// Untranslated entries are left out, as lookups return the msgid for them anyway.
+// Plural messages get the nplurals translations of the catalog, and the ones with other forms
+// return an error wrapping ErrPluralCount, without writing anything.
+// Other metadata, like comments, references and the other flags, isn't part of the schema and is lost.
+func (enc *JsonEncoder) Encode(t Translator) error {
+	te, err := encodeTranslator(t)
+	if err != nil {
+		return err
+	}
+	nplurals := formCount(te.Nplurals)
+
+	obj := jsonCatalog{
+		Headers:  jsonHeaders(te.Headers, headerEntry(te)),
+		Messages: []jsonMessage{},
+	}
+
+	add := func(ctx string, tr *Translation) {
+		if tr.ID == "" || !tr.IsTranslated() {
+			return
+		}
+
+		m := jsonMessage{Context: ctx, ID: tr.ID, Plural: tr.PluralID, Fuzzy: tr.IsFuzzy()}
+		if tr.PluralID == "" {
+			m.Translation = tr.Trs[0]
+		} else {
+			var extra []int
+			m.Translations, extra = tr.forms(nplurals)
+			if len(extra) > 0 && err == nil {
+				err = fmt.Errorf("%w: %s has msgstr[%d], but nplurals is %d", ErrPluralCount, entryString(ctx, tr.ID), extra[0], nplurals)
+			}
+		}
+		obj.Messages = append(obj.Messages, m)
+	}
+	for _, tr := range te.Translations {
+		add("", tr)
+	}
+	for ctx, trs := range te.Contexts {
+		for _, tr := range trs {
+			add(ctx, tr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	sort.Slice(obj.Messages, func(i, j int) bool {
+		a, b := obj.Messages[i], obj.Messages[j]
+		if a.Context != b.Context {
+			return a.Context < b.Context
+		}
+		return a.ID < b.ID
+	})
+
+	je := json.NewEncoder(enc.w)
+	je.SetEscapeHTML(false)
+	je.SetIndent("", enc.Indent)
+
+	return je.Encode(obj)
+}
+
+// jsonHeaders returns the first value of every header, with the spelling of the PO header entry raw,
+// or the GNU spelling of the known ones.
+func jsonHeaders(h textproto.MIMEHeader, raw string) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+
+	headers := make(map[string]string, len(h))
+	for _, line := range strings.Split(headerString(h, raw), "\n") {
+		kv := strings.SplitN(line, ": ", 2)
+		if _, ok := headers[kv[0]]; len(kv) == 2 && !ok {
+			headers[kv[0]] = kv[1]
+		}
+	}
+
+	return headers
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJson(t *testing.T) {
+	po := new(Po)
+	po.Parse([]byte(`msgid ""
+msgstr ""
+"Language: de\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+"POT-Creation-Date: 2024-01-01 00:00+0000\n"
+
+msgid "Hello <b>%s</b>"
+msgstr "Hallo <b>%s</b>"
+
+msgctxt "menu"
+msgid "Open"
+msgstr "Öffnen"
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "Eine Datei"
+msgstr[1] "%d Dateien"
+
+#, fuzzy
+msgid "Fuzzy"
+msgstr "Unsicher"
+
+msgid "Untranslated"
+msgstr ""
+`))
+
+	data, err := MarshalJson(po)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{
+  "headers": {
+    "Language": "de",
+    "POT-Creation-Date": "2024-01-01 00:00+0000",
+    "Plural-Forms": "nplurals=2; plural=(n != 1);"
+  },
+  "messages": [
+    {
+      "id": "Fuzzy",
+      "translation": "Unsicher",
+      "fuzzy": true
+    },
+    {
+      "id": "Hello <b>%s</b>",
+      "translation": "Hallo <b>%s</b>"
+    },
+    {
+      "id": "One file",
+      "plural": "%d files",
+      "translations": [
+        "Eine Datei",
+        "%d Dateien"
+      ]
+    },
+    {
+      "context": "menu",
+      "id": "Open",
+      "translation": "Öffnen"
+    }
+  ]
+}
+`
+	if string(data) != expected {
+		t.Errorf("Unexpected JSON:\n%s", data)
+	}
+
+	// The JSON catalog translates the same
+	j := new(Json)
+	if err := j.ParseE(data); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, 1, 2} {
+		if a, b := j.GetN("One file", "%d files", n), po.GetN("One file", "%d files", n); a != b {
+			t.Errorf("Expected '%s' but got '%s'", b, a)
+		}
+	}
+	if s := j.GetC("Open", "menu"); s != "Öffnen" {
+		t.Errorf("Expected 'Öffnen' but got '%s'", s)
+	}
+
+	// Fuzzy entries are kept, and skipped on lookups unless allowed
+	if tr := j.GetTranslation("Fuzzy"); tr == nil || !tr.IsFuzzy() || tr.Get() != "Unsicher" {
+		t.Errorf("Expected the fuzzy entry but got %+v", tr)
+	}
+	if s := j.Get("Fuzzy"); s != "Fuzzy" {
+		t.Errorf("Expected 'Fuzzy' but got '%s'", s)
+	}
+
+	// PO to JSON to PO keeps the fuzzy work
+	te, err := encodeTranslator(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := te.GetTranslator().(*Po).MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "#, fuzzy\nmsgid \"Fuzzy\"\nmsgstr \"Unsicher\"\n") {
+		t.Errorf("Expected the fuzzy entry in\n%s", out)
+	}
+
+	// And encodes back to the same JSON
+	again, err := MarshalJson(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Errorf("Unexpected JSON:\n%s", again)
+	}
+}
+
+func TestJsonEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewJsonEncoder(&buf)
+	enc.Indent = ""
+
+	if err := enc.Encode(new(Json)); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); s != "{\"messages\":[]}\n" {
+		t.Errorf("Unexpected JSON: %s", s)
+	}
+}
+
+func TestJsonEncoderForms(t *testing.T) {
+	j := new(Json)
+	if err := j.ParseE([]byte(`{
+  "headers": {"Plural-Forms": "nplurals=3; plural=(n==1 ? 0 : n==2 ? 1 : 2);"},
+  "messages": [{"id": "One file", "plural": "%d files", "translations": ["Eine Datei"]}]
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Missing forms are written empty
+	data, err := MarshalJson(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"Eine Datei",
+        "",
+        ""
+      ]`)) {
+		t.Errorf("Expected 3 translations in\n%s", data)
+	}
+
+	// Forms out of nplurals aren't written
+	j.GetTranslation("One file").Trs[20000000] = "Viele Dateien"
+	var buff bytes.Buffer
+	if err := NewJsonEncoder(&buff).Encode(j); !errors.Is(err, ErrPluralCount) {
+		t.Errorf("Expected ErrPluralCount but got '%v'", err)
+	}
+	if buff.Len() != 0 {
+		t.Errorf("Expected no output but got %q", buff.String())
+	}
+}
@@ -0,0 +1,641 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DeineAgenturUG/gotext/plurals"
+)
+
+/*
+LazyMo is a read-only Translator over the raw content of a MO file.
+Unlike Mo, it doesn't load the entries on maps: each lookup reads the MO string tables,
+using the hash table when the file has one, or a binary search otherwise.
+That makes loading almost free and keeps memory usage to the size of the file, or less with ParseReaderAt.
+Translations are the same Mo returns.
+And it's safe for concurrent use by multiple goroutines by using the sync package for locking.
+
+Example:
+
+	import (
+		"fmt"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		// Create lazy mo object
+		mo := gotext.NewLazyMoTranslator()
+
+		// Parse .mo file
+		mo.ParseFile("/path/to/po/file/translations.mo")
+
+		// Get Translation
+		fmt.Println(mo.Get("Translate this"))
+	}
+*/
+type LazyMo struct {
+	// Headers storage
+	Headers textproto.MIMEHeader
+
+	// Language header
+	Language string
+
+	// Plural-Forms header
+	PluralForms string
+
+	// Parsed Plural-Forms header values
+	nplurals    int
+	plural      string
+	pluralforms plurals.Expression
+
+	// Charset forced by SetCharset, instead of the one declared on the headers
+	charset string
+
+	// MO content
+	data       io.ReaderAt
+	size       int64
+	bo         binary.ByteOrder
+	count      uint32
+	idsOffset  uint32
+	strsOffset uint32
+	hashSize   uint32
+	hashOffset uint32
+	cs         *charmap
+
+	// Expanded system dependent strings of revision 1 files, indexed after the static ones
+	sysdep      []moEntry
+	sysdepIndex map[string]uint32
+
+	// Sync Mutex
+	sync.RWMutex
+}
+
+// NewLazyMoTranslator creates a new LazyMo object with the Translator interface
+func NewLazyMoTranslator() Translator {
+	return new(LazyMo)
+}
+
+// ParseFile tries to read the file by its provided path (f) and keeps its content to look up translations.
+// Gzip compressed files are decompressed in memory.
+func (mo *LazyMo) ParseFile(f string) {
+	mo.ParseFileE(f)
+}
+
+// ParseFileE works like ParseFile, but returns an error when the file can't be read or parsed.
+// A missing file or a directory path returns an error wrapping ErrNotFound.
+func (mo *LazyMo) ParseFileE(f string) error {
+	// Check if file exists
+	info, err := os.Stat(f)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrNotFound, f)
+		}
+		return err
+	}
+
+	// Check that isn't a directory
+	if info.IsDir() {
+		return fmt.Errorf("%w: %s is a directory", ErrNotFound, f)
+	}
+
+	// Read file content, decompressed when needed
+	data, err := readFile(nil, f)
+	if err != nil {
+		return err
+	}
+
+	return withFile(mo.ParseE(data), f)
+}
+
+// Parse keeps the MO content in buf, without copying it, to look up translations.
+// Unlike Mo, the content replaces any previously parsed one.
+func (mo *LazyMo) Parse(buf []byte) {
+	mo.ParseE(buf)
+}
+
+// ParseE works like Parse, but returns a *ParseError when the content isn't a valid MO file,
+// as Mo.ParseE does.
+func (mo *LazyMo) ParseE(buf []byte) error {
+	return mo.ParseReaderAtE(bytes.NewReader(buf), int64(len(buf)))
+}
+
+// ParseReaderAt looks up translations on the size bytes of MO content of r, like an open *os.File,
+// so the content doesn't need to be held in memory.
+// r must stay readable while the LazyMo object is used.
+func (mo *LazyMo) ParseReaderAt(r io.ReaderAt, size int64) {
+	mo.ParseReaderAtE(r, size)
+}
+
+// ParseReaderAtE works like ParseReaderAt, but returns a *ParseError when the content isn't a valid MO file.
+// The previous content and headers are dropped on errors too, leaving no translations.
+func (mo *LazyMo) ParseReaderAtE(r io.ReaderAt, size int64) error {
+	// Lock while parsing
+	mo.Lock()
+
+	if err := mo.parse(r, size); err != nil {
+		mo.reset()
+		mo.Unlock()
+		return &ParseError{Err: err}
+	}
+
+	// The header entry declares the charset of the entries
+	var csErr error
+	mo.cs = nil
+	if mo.charset != "" {
+		mo.cs, csErr = getCharmap(mo.charset)
+	} else if header, ok := mo.entry(""); ok {
+		mo.cs, csErr = getCharmap(headerCharset(header.Get()))
+	}
+
+	// Unlock to parse headers
+	mo.Unlock()
+
+	// Parse headers
+	mo.parseHeaders()
+
+	// Report unsupported charsets
+	if csErr != nil {
+		return &ParseError{Err: csErr}
+	}
+
+	return nil
+}
+
+// reset drops the parsed content and headers, but the charset forced by SetCharset.
+// It must be called with the write lock held.
+func (mo *LazyMo) reset() {
+	mo.Headers, mo.Language, mo.PluralForms = nil, "", ""
+	mo.nplurals, mo.plural, mo.pluralforms = 0, "", nil
+	mo.data, mo.size, mo.bo = nil, 0, nil
+	mo.count, mo.idsOffset, mo.strsOffset, mo.hashSize, mo.hashOffset = 0, 0, 0, 0, 0
+	mo.cs = nil
+	mo.sysdep, mo.sysdepIndex = nil, nil
+}
+
+// parse reads the MO header of r and checks its tables are in bounds.
+// It must be called with the write lock held.
+func (mo *LazyMo) parse(r io.ReaderAt, size int64) error {
+	buf := make([]byte, moSysdepHeaderSize)
+	n, _ := r.ReadAt(buf, 0)
+	if n < 4 || size < 4 {
+		return ErrBadMagic
+	}
+
+	var bo binary.ByteOrder
+	switch binary.LittleEndian.Uint32(buf) {
+	case MoMagicLittleEndian:
+		bo = binary.LittleEndian
+	case MoMagicBigEndian:
+		bo = binary.BigEndian
+	default:
+		return ErrBadMagic
+	}
+	if n < moHeaderSize || size < moHeaderSize {
+		return fmt.Errorf("%w (%w): header needs %d bytes, the file has %d", ErrCorruptMo, io.ErrUnexpectedEOF, moHeaderSize, size)
+	}
+
+	// Major revision 0, with minor revisions 0 and 1
+	rev := bo.Uint32(buf[4:])
+	if rev>>16 != 0 || rev&0xffff > 1 {
+		return ErrUnsupportedRevision
+	}
+	if rev == 1 && (n < moSysdepHeaderSize || size < moSysdepHeaderSize) {
+		return fmt.Errorf("%w (%w): revision 1 header needs %d bytes, the file has %d", ErrCorruptMo, io.ErrUnexpectedEOF, moSysdepHeaderSize, size)
+	}
+
+	mo.data, mo.size, mo.bo = r, size, bo
+	mo.sysdep, mo.sysdepIndex = nil, nil
+	mo.count = bo.Uint32(buf[8:])
+	mo.idsOffset = bo.Uint32(buf[12:])
+	mo.strsOffset = bo.Uint32(buf[16:])
+	mo.hashSize = bo.Uint32(buf[20:])
+	mo.hashOffset = bo.Uint32(buf[24:])
+
+	// Strings tables must be in bounds
+	tableSize := int64(mo.count) * 8
+	if int64(mo.idsOffset)+tableSize > size {
+		return fmt.Errorf("%w (%w): msgid table at offset %d with %d entries exceeds the file size %d", ErrCorruptMo, io.ErrUnexpectedEOF, mo.idsOffset, mo.count, size)
+	}
+	if int64(mo.strsOffset)+tableSize > size {
+		return fmt.Errorf("%w (%w): msgstr table at offset %d with %d entries exceeds the file size %d", ErrCorruptMo, io.ErrUnexpectedEOF, mo.strsOffset, mo.count, size)
+	}
+
+	// The hash table is optional, so it's ignored when it can't be used
+	if mo.hashSize < 3 || int64(mo.hashOffset)+int64(mo.hashSize)*4 > size {
+		mo.hashSize = 0
+	}
+
+	// System dependent strings are expanded once, as glibc does
+	if rev == 1 {
+		entries, err := readSysdepStrings(r, size, bo, buf, moMaxDecoded*size)
+		if err != nil {
+			return err
+		}
+		mo.sysdep = entries
+		mo.sysdepIndex = make(map[string]uint32, len(entries))
+		for i, e := range entries {
+			key := e.key
+			if j := strings.IndexByte(key, 0); j != -1 {
+				key = key[:j]
+			}
+			mo.sysdepIndex[key] = mo.count + uint32(i)
+		}
+	}
+
+	return nil
+}
+
+// readUint32 reads the number at offset off.
+func (mo *LazyMo) readUint32(off int64) (uint32, bool) {
+	var buf [4]byte
+	if n, _ := mo.data.ReadAt(buf[:], off); n < len(buf) {
+		return 0, false
+	}
+
+	return mo.bo.Uint32(buf[:]), true
+}
+
+// readString reads the string i of the table at offset table.
+func (mo *LazyMo) readString(table uint32, i uint32) (string, bool) {
+	var buf [8]byte
+	if n, _ := mo.data.ReadAt(buf[:], int64(table)+int64(i)*8); n < len(buf) {
+		return "", false
+	}
+
+	length, offset := int64(mo.bo.Uint32(buf[:])), int64(mo.bo.Uint32(buf[4:]))
+	if offset+length > mo.size {
+		return "", false
+	}
+
+	s := make([]byte, length)
+	if n, _ := mo.data.ReadAt(s, offset); n < len(s) {
+		return "", false
+	}
+
+	return string(s), true
+}
+
+// compareKey compares key to the msgid part of an original string, up to its plural id.
+func compareKey(key, orig string) int {
+	if i := strings.IndexByte(orig, 0); i != -1 {
+		orig = orig[:i]
+	}
+
+	return strings.Compare(key, orig)
+}
+
+// find returns the index of the entry for key, in the charset of the file.
+// System dependent strings are indexed after the static ones.
+func (mo *LazyMo) find(key string) (uint32, bool) {
+	if mo.data == nil {
+		return 0, false
+	}
+
+	if i, ok := mo.findStatic(key); ok {
+		return i, true
+	}
+	i, ok := mo.sysdepIndex[key]
+
+	return i, ok
+}
+
+// findStatic returns the index of the entry for key on the static string tables.
+func (mo *LazyMo) findStatic(key string) (uint32, bool) {
+
+	// Hash table lookup, as GNU gettext does
+	if mo.hashSize > 0 {
+		h := hashString(key)
+		idx := h % mo.hashSize
+		incr := 1 + h%(mo.hashSize-2)
+
+		for tries := uint32(0); tries < mo.hashSize; tries++ {
+			n, ok := mo.readUint32(int64(mo.hashOffset) + int64(idx)*4)
+			if !ok || n == 0 || n > mo.count {
+				return 0, false
+			}
+
+			if orig, ok := mo.readString(mo.idsOffset, n-1); ok && compareKey(key, orig) == 0 {
+				return n - 1, true
+			}
+
+			if idx >= mo.hashSize-incr {
+				idx -= mo.hashSize - incr
+			} else {
+				idx += incr
+			}
+		}
+
+		return 0, false
+	}
+
+	// Binary search over the sorted original strings
+	lo, hi := uint32(0), mo.count
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		orig, ok := mo.readString(mo.idsOffset, mid)
+		if !ok {
+			return 0, false
+		}
+
+		switch c := compareKey(key, orig); {
+		case c == 0:
+			return mid, true
+		case c < 0:
+			hi = mid
+		default:
+			lo = mid + 1
+		}
+	}
+
+	return 0, false
+}
+
+// translation builds the Translation of the entry i, converted to UTF-8.
+func (mo *LazyMo) translation(i uint32) (*Translation, bool) {
+	orig, trs, ok := mo.rawEntry(i)
+	if !ok {
+		return nil, false
+	}
+
+	tr := NewTranslation()
+	if j := strings.Index(orig, EotSeparator); j != -1 {
+		tr.Context, orig = mo.cs.decode(orig[:j]), orig[j+1:]
+	}
+	if j := strings.IndexByte(orig, 0); j != -1 {
+		tr.PluralID, orig = mo.cs.decode(orig[j+1:]), orig[:j]
+	}
+	tr.ID = mo.cs.decode(orig)
+
+	for j, s := range strings.Split(trs, NulSeparator) {
+		tr.Trs[j] = mo.cs.decode(s)
+	}
+
+	// The header entry declares UTF-8 once converted
+	if mo.cs != nil && tr.ID == "" && tr.Context == "" {
+		tr.Trs[0] = setHeaderCharset(tr.Trs[0], "UTF-8")
+	}
+
+	return tr, true
+}
+
+// rawEntry returns the original and translation strings of the entry i.
+func (mo *LazyMo) rawEntry(i uint32) (string, string, bool) {
+	if i >= mo.count {
+		if i-mo.count >= uint32(len(mo.sysdep)) {
+			return "", "", false
+		}
+		e := mo.sysdep[i-mo.count]
+		return e.key, e.value, true
+	}
+
+	orig, ok := mo.readString(mo.idsOffset, i)
+	if !ok {
+		return "", "", false
+	}
+	trs, ok := mo.readString(mo.strsOffset, i)
+
+	return orig, trs, ok
+}
+
+// entry looks up the Translation for key, which includes the context, if any.
+func (mo *LazyMo) entry(key string) (*Translation, bool) {
+	key, ok := mo.cs.encode(key)
+	if !ok {
+		return nil, false
+	}
+
+	i, ok := mo.find(key)
+	if !ok {
+		return nil, false
+	}
+
+	return mo.translation(i)
+}
+
+// parseHeaders retrieves data from the header entry
+func (mo *LazyMo) parseHeaders() {
+	// Make sure we end with 2 carriage returns.
+	raw := mo.Get("") + "\n\n"
+
+	// Read
+	reader := bufio.NewReader(strings.NewReader(raw))
+	tp := textproto.NewReader(reader)
+
+	var err error
+
+	// Sync Headers write.
+	mo.Lock()
+	defer mo.Unlock()
+
+	mo.nplurals, mo.plural, mo.pluralforms = 0, "", nil
+	mo.Headers, err = tp.ReadMIMEHeader()
+	if err != nil {
+		return
+	}
+
+	// Get/save needed headers
+	mo.Language = mo.Headers.Get("Language")
+	mo.PluralForms = mo.Headers.Get("Plural-Forms")
+
+	// Parse Plural-Forms formula
+	if mo.PluralForms == "" {
+		return
+	}
+
+	// Split plural form header value
+	pfs := strings.Split(mo.PluralForms, ";")
+
+	// Parse values
+	for _, i := range pfs {
+		vs := strings.SplitN(i, "=", 2)
+		if len(vs) != 2 {
+			continue
+		}
+
+		switch strings.TrimSpace(vs[0]) {
+		case "nplurals":
+			mo.nplurals, _ = strconv.Atoi(vs[1])
+
+		case "plural":
+			mo.plural = vs[1]
+
+			if expr, err := plurals.Compile(mo.plural); err == nil {
+				mo.pluralforms = expr
+			}
+
+		}
+	}
+}
+
+// pluralForm calculates the plural form index corresponding to n.
+// Returns 0 on error
+func (mo *LazyMo) pluralForm(n int) int {
+	// Failure fallback
+	if mo.pluralforms == nil {
+		/* Use the Germanic plural rule.  */
+		if n == 1 {
+			return 0
+		}
+		return 1
+
+	}
+	return mo.pluralforms.Eval(uint32(n))
+}
+
+// SetCharset forces the charset used to convert the entries to UTF-8,
+// instead of the one declared on the Content-Type header. It must be called before parsing.
+func (mo *LazyMo) SetCharset(cs string) {
+	mo.Lock()
+	mo.charset = cs
+	mo.Unlock()
+}
+
+// GetCharset returns the charset forced by SetCharset.
+func (mo *LazyMo) GetCharset() string {
+	mo.RLock()
+	defer mo.RUnlock()
+
+	return mo.charset
+}
+
+// Get retrieves the corresponding Translation for the given string.
+// Supports optional parameters (vars... interface{}) to be inserted on the formatted string using the fmt.Printf syntax.
+func (mo *LazyMo) Get(str string, vars ...interface{}) string {
+	// Sync read
+	mo.RLock()
+	defer mo.RUnlock()
+
+	if tr, ok := mo.entry(str); ok {
+		return Printf(tr.Get(), vars...)
+	}
+
+	// Return the same we received by default
+	return Printf(str, vars...)
+}
+
+// GetN retrieves the (N)th plural form of Translation for the given string.
+// Supports optional parameters (vars... interface{}) to be inserted on the formatted string using the fmt.Printf syntax.
+func (mo *LazyMo) GetN(str, plural string, n int, vars ...interface{}) string {
+	// Sync read
+	mo.RLock()
+	defer mo.RUnlock()
+
+	if tr, ok := mo.entry(str); ok {
+		return Printf(tr.GetN(mo.pluralForm(n)), vars...)
+	}
+
+	if n == 1 {
+		return Printf(str, vars...)
+	}
+	return Printf(plural, vars...)
+}
+
+// GetC retrieves the corresponding Translation for a given string in the given context.
+// Supports optional parameters (vars... interface{}) to be inserted on the formatted string using the fmt.Printf syntax.
+func (mo *LazyMo) GetC(str, ctx string, vars ...interface{}) string {
+	// Sync read
+	mo.RLock()
+	defer mo.RUnlock()
+
+	if tr, ok := mo.entry(ctx + EotSeparator + str); ok {
+		return Printf(tr.Get(), vars...)
+	}
+
+	// Return the string we received by default
+	return Printf(str, vars...)
+}
+
+// GetNC retrieves the (N)th plural form of Translation for the given string in the given context.
+// Supports optional parameters (vars... interface{}) to be inserted on the formatted string using the fmt.Printf syntax.
+func (mo *LazyMo) GetNC(str, plural string, n int, ctx string, vars ...interface{}) string {
+	// Sync read
+	mo.RLock()
+	defer mo.RUnlock()
+
+	if tr, ok := mo.entry(ctx + EotSeparator + str); ok {
+		return Printf(tr.GetN(mo.pluralForm(n)), vars...)
+	}
+
+	if n == 1 {
+		return Printf(str, vars...)
+	}
+	return Printf(plural, vars...)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler interface.
+// All the entries are read to build the TranslatorEncoding.
+func (mo *LazyMo) MarshalBinary() ([]byte, error) {
+	mo.RLock()
+	defer mo.RUnlock()
+
+	obj := new(TranslatorEncoding)
+	obj.Headers = mo.Headers
+	obj.Language = mo.Language
+	obj.PluralForms = mo.PluralForms
+	obj.Nplurals = mo.nplurals
+	obj.Plural = mo.plural
+	obj.Translations = make(map[string]*Translation)
+	obj.Contexts = make(map[string]map[string]*Translation)
+
+	for i := uint32(0); mo.data != nil && i < mo.count+uint32(len(mo.sysdep)); i++ {
+		tr, ok := mo.translation(i)
+		if !ok {
+			return nil, &ParseError{Err: io.ErrUnexpectedEOF}
+		}
+
+		// System dependent strings don't replace static ones, as on lookups
+		if tr.Context == "" {
+			if _, ok := obj.Translations[tr.ID]; !ok || i < mo.count {
+				obj.Translations[tr.ID] = tr
+			}
+			continue
+		}
+		if _, ok := obj.Contexts[tr.Context]; !ok {
+			obj.Contexts[tr.Context] = make(map[string]*Translation)
+		}
+		if _, ok := obj.Contexts[tr.Context][tr.ID]; !ok || i < mo.count {
+			obj.Contexts[tr.Context][tr.ID] = tr
+		}
+	}
+
+	var buff bytes.Buffer
+	encoder := gob.NewEncoder(&buff)
+	err := encoder.Encode(obj)
+
+	return buff.Bytes(), err
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler interface.
+// The entries are encoded back to a MO file in memory.
+func (mo *LazyMo) UnmarshalBinary(data []byte) error {
+	buff := bytes.NewBuffer(data)
+	obj := new(TranslatorEncoding)
+
+	decoder := gob.NewDecoder(buff)
+	err := decoder.Decode(obj)
+	if err != nil {
+		return err
+	}
+
+	out, err := MarshalMo(obj.GetTranslator())
+	if err != nil {
+		return err
+	}
+
+	return mo.ParseE(out)
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// checkLazyMo compares the translations of a LazyMo object with the ones of the Mo object loaded from the same data.
+func checkLazyMo(t *testing.T, name string, mo *Mo, lazy *LazyMo) {
+	// Ids aren't format strings here
+	get, lazyGet, getC, lazyGetC := mo.Get, lazy.Get, mo.GetC, lazy.GetC
+
+	for id, tr := range mo.translations {
+		for n := 0; n < 5; n++ {
+			if a, b := mo.GetN(id, tr.PluralID, n), lazy.GetN(id, tr.PluralID, n); a != b {
+				t.Errorf("%s: expected '%s' but got '%s' for %q with n = %d", name, a, b, id, n)
+			}
+		}
+		if a, b := get(id), lazyGet(id); a != b {
+			t.Errorf("%s: expected '%s' but got '%s' for %q", name, a, b, id)
+		}
+	}
+	for ctx, trs := range mo.contexts {
+		for id, tr := range trs {
+			for n := 0; n < 5; n++ {
+				if a, b := mo.GetNC(id, tr.PluralID, n, ctx), lazy.GetNC(id, tr.PluralID, n, ctx); a != b {
+					t.Errorf("%s: expected '%s' but got '%s' for %q in context %q", name, a, b, id, ctx)
+				}
+			}
+			if a, b := getC(id, ctx), lazyGetC(id, ctx); a != b {
+				t.Errorf("%s: expected '%s' but got '%s' for %q in context %q", name, a, b, id, ctx)
+			}
+		}
+	}
+
+	// Missing entries
+	if tr := lazy.GetN("Missing", "Missings", 2); tr != "Missings" {
+		t.Errorf("%s: expected 'Missings' but got '%s'", name, tr)
+	}
+	if tr := lazy.GetC("Missing", "Ctx"); tr != "Missing" {
+		t.Errorf("%s: expected 'Missing' but got '%s'", name, tr)
+	}
+	if lazy.Language != mo.Language || lazy.PluralForms != mo.PluralForms {
+		t.Errorf("%s: expected headers %v but got %v", name, mo.Headers, lazy.Headers)
+	}
+}
+
+func TestLazyMo(t *testing.T) {
+	files, err := filepath.Glob("fixtures/*/*.mo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range files {
+		mo := new(Mo)
+		if err := mo.ParseFileE(f); err != nil {
+			t.Fatal(err)
+		}
+
+		// As written by msgfmt
+		lazy := new(LazyMo)
+		if err := lazy.ParseFileE(f); err != nil {
+			t.Fatal(err)
+		}
+		checkLazyMo(t, f, mo, lazy)
+
+		// Binary search on big endian files without hash table
+		var buff bytes.Buffer
+		enc := NewMoEncoder(&buff)
+		enc.ByteOrder = binary.BigEndian
+		enc.HashTable = false
+		if err := enc.Encode(mo); err != nil {
+			t.Fatal(err)
+		}
+		lazy = new(LazyMo)
+		if err := lazy.ParseE(buff.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		if lazy.hashSize != 0 {
+			t.Errorf("Unexpected hash table size %d", lazy.hashSize)
+		}
+		checkLazyMo(t, f+" (binary search)", mo, lazy)
+
+		// Encoding
+		data, err := lazy.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		lazy = new(LazyMo)
+		if err := lazy.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		checkLazyMo(t, f+" (encoding)", mo, lazy)
+	}
+}
+
+func TestLazyMoReaderAt(t *testing.T) {
+	f, err := os.Open("fixtures/de/default.mo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lazy := new(LazyMo)
+	if err := lazy.ParseReaderAtE(f, info.Size()); err != nil {
+		t.Fatal(err)
+	}
+
+	mo := new(Mo)
+	mo.ParseFile("fixtures/de/default.mo")
+	checkLazyMo(t, f.Name(), mo, lazy)
+
+	// Invalid content
+	err = lazy.ParseE([]byte("not a MO file at all, but long enough"))
+	if !errors.Is(err, ErrBadMagic) {
+		t.Errorf("Expected ErrBadMagic but got '%v'", err)
+	}
+	if tr := lazy.Get("My text"); tr != "My text" {
+		t.Errorf("Expected 'My text' but got '%s'", tr)
+	}
+
+	// Nothing is left of the previous content
+	if lazy.Headers != nil || lazy.Language != "" || lazy.PluralForms != "" {
+		t.Errorf("Expected no headers but got %v, '%s' and '%s'", lazy.Headers, lazy.Language, lazy.PluralForms)
+	}
+	if tr := lazy.GetN("One with var: %s", "Several with vars: %s", 2, "test"); tr != "Several with vars: test" {
+		t.Errorf("Expected 'Several with vars: test' but got '%s'", tr)
+	}
+	if te, err := encodeTranslator(lazy); err != nil || len(te.Translations) != 0 || te.Nplurals != 0 {
+		t.Errorf("Expected no entries but got %v (%v)", te, err)
+	}
+}
+
+func TestLazyMoCharset(t *testing.T) {
+	data := buildMo(map[string]string{
+		"":                "Language: de\nContent-Type: text/plain; charset=ISO-8859-1\n",
+		"Gr\xfc\xdfe":     "Gr\xfc\xdfe!",
+		"Ctx\x04Sch\xf6n": "H\xfcbsch",
+	})
+
+	lazy := new(LazyMo)
+	if err := lazy.ParseE(data); err != nil {
+		t.Fatal(err)
+	}
+	if tr := lazy.Get("Grüße"); tr != "Grüße!" {
+		t.Errorf("Expected 'Grüße!' but got '%s'", tr)
+	}
+	if tr := lazy.GetC("Schön", "Ctx"); tr != "Hübsch" {
+		t.Errorf("Expected 'Hübsch' but got '%s'", tr)
+	}
+	if tr := lazy.Get("Привет"); tr != "Привет" {
+		t.Errorf("Expected 'Привет' but got '%s'", tr)
+	}
+	if h := lazy.Headers.Get("Content-Type"); h != "text/plain; charset=UTF-8" {
+		t.Errorf("Expected UTF-8 Content-Type but got '%s'", h)
+	}
+}
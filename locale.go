@@ -6,10 +6,14 @@
 package gotext
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/gob"
+	"fmt"
+	"io/fs"
 	"os"
 	"path"
+	"strings"
 	"sync"
 )
 
@@ -49,6 +53,9 @@ type Locale struct {
 	// Path to locale files.
 	path string
 
+	// File system of the locale files, used instead of path when set.
+	fsys fs.FS
+
 	// Language for this Locale
 	lang string
 
@@ -58,12 +65,16 @@ type Locale struct {
 	// First AddDomain is default Domain
 	defaultDomain string
 
+	// Use entries flagged as fuzzy on domains loaded by AddDomain
+	allowFuzzy bool
+
 	// Sync Mutex
 	sync.RWMutex
 }
 
 // NewLocale creates and initializes a new Locale object for a given language.
 // It receives a path for the i18n .po/.mo files directory (p) and a language code to use (l).
+// When p is a .zip file, the files are read from the archive, using the same layout as a directory.
 func NewLocale(p, l string) *Locale {
 	return &Locale{
 		path: p,
@@ -71,62 +82,102 @@ func NewLocale(p, l string) *Locale {
 	}
 }
 
-func (l *Locale) findExt(dom, ext, lang string) string {
-	filename := path.Join(l.path, lang, "LC_MESSAGES", dom+"."+ext)
-	if _, err := os.Stat(filename); err == nil {
-		return filename
+// NewLocaleFS creates and initializes a new Locale object for a given language (l),
+// reading the .po/.mo files from fsys, like an embed.FS, instead of a library directory.
+// The files are looked up from the root of fsys, using the same layout as a library directory.
+func NewLocaleFS(fsys fs.FS, l string) *Locale {
+	return &Locale{
+		fsys: fsys,
+		lang: SimplifiedLocale(l),
+	}
+}
+
+// findExt returns the path of the dom file with the extension ext for lang,
+// or of its gzip compressed version, like default.po.gz.
+// Paths are relative to the root of fsys when it isn't nil, or include the library path otherwise.
+func (l *Locale) findExt(fsys fs.FS, dom, ext, lang string) string {
+	root := l.path
+	if fsys != nil {
+		root = ""
 	}
 
-	filename = path.Join(l.path, l.lang, dom+"."+ext)
-	if _, err := os.Stat(filename); err == nil {
+	filename := findFile(fsys, path.Join(root, lang, "LC_MESSAGES", dom+"."+ext))
+	if filename != "" {
 		return filename
 	}
 
-	return ""
+	return findFile(fsys, path.Join(root, l.lang, dom+"."+ext))
+}
+
+// isBundle reports whether the library path of the Locale is a zip archive.
+func (l *Locale) isBundle() bool {
+	return strings.EqualFold(path.Ext(l.path), ".zip")
+}
+
+// openBundle opens the zip archive of the library path.
+// The archive is only read while loading domains, so it isn't kept open.
+func (l *Locale) openBundle() (*zip.ReadCloser, error) {
+	zr, err := zip.OpenReader(l.path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, l.path)
+	}
+
+	return zr, err
 }
 
-// AddDomain creates a new domain for a given locale object and initializes the Po object.
+// AddDomain creates a new domain for a given locale object and initializes its Translator object.
 // If the domain exists, it gets reloaded.
+// The files of the formats registered with RegisterFormat are looked up, PO files being preferred over MO files by default.
+// Gzip compressed files, like default.po.gz, are used when the uncompressed file isn't found in the same directory.
 func (l *Locale) AddDomain(dom string) {
-	var poObj Translator
+	l.AddDomainE(dom)
+}
+
+// AddDomainE works like AddDomain, but returns an error when the domain can't be loaded.
+// It returns an error wrapping ErrNotFound when no file of a registered format, compressed or not, exists for the domain.
+// When the file is found but fails to parse, the recovered translations are still added
+// and the parsing error is returned.
+func (l *Locale) AddDomainE(dom string) error {
+	// Files of zip bundles are read from the archive
+	fsys := l.fsys
+	if fsys == nil && l.isBundle() {
+		zr, err := l.openBundle()
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		fsys = zr
+	}
 
-	file := l.findExt(dom, "po", l.lang)
-	if file != "" {
-		poObj = new(Po)
-		// Parse file.
-		poObj.ParseFile(file)
-		goto nextAddDomain
-	} else {
-		file = l.findExt(dom, "mo", l.lang)
-		if file != "" {
-			poObj = new(Mo)
-			// Parse file.
-			poObj.ParseFile(file)
-			goto nextAddDomain
-		} else {
-			file := l.findExt(dom, "po", l.lang[:2])
-			if file != "" {
-				poObj = new(Po)
-				// Parse file.
-				poObj.ParseFile(file)
-				goto nextAddDomain
-			} else {
-				file = l.findExt(dom, "mo", l.lang[:2])
-				if file != "" {
-					poObj = new(Mo)
-					// Parse file.
-					poObj.ParseFile(file)
-					goto nextAddDomain
-				} else {
-					// fallback return if no file found with
-					return
-				}
+	// Look for the registered formats on the language, then on its simplified code
+	langs := []string{l.lang}
+	if len(l.lang) > 2 {
+		langs = append(langs, l.lang[:2])
+	}
+
+	var poObj Translator
+	var file string
+search:
+	for _, lang := range langs {
+		for _, f := range registeredFormats() {
+			if file = l.findExt(fsys, dom, f.ext, lang); file != "" {
+				poObj = f.factory()
+				break search
 			}
 		}
 	}
+	if poObj == nil {
+		return fmt.Errorf("%w: domain %q for language %q", ErrNotFound, dom, l.lang)
+	}
+
+	// Apply fuzzy setting before parsing
+	if ft, ok := poObj.(fuzzyTranslator); ok {
+		ft.SetAllowFuzzy(l.GetAllowFuzzy())
+	}
+
+	// Parse file.
+	err := parseFile(poObj, fsys, file, path.Join(l.path, file))
 
-	// Goto Mark: nextAddDomain
-nextAddDomain:
 	// Save new domain
 	l.Lock()
 	if l.defaultDomain == "" {
@@ -136,6 +187,46 @@ nextAddDomain:
 	l.Unlock()
 
 	l.Domains.Store(dom, poObj)
+
+	return err
+}
+
+// parseFile parses the file f of fsys, or of the OS file system when fsys is nil, with tr.
+// Errors are reported on the file name when tr implements Loader.
+func parseFile(tr Translator, fsys fs.FS, f, name string) error {
+	ld, ok := tr.(Loader)
+	if ok && fsys == nil {
+		return ld.ParseFileE(f)
+	}
+
+	// Other Translator objects get the decompressed content
+	data, err := readFile(fsys, f)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		tr.Parse(data)
+		return nil
+	}
+
+	return withFile(ld.ParseE(data), name)
+}
+
+// SetAllowFuzzy sets whether entries flagged as fuzzy are used by the domains loaded with AddDomain.
+// It only applies to domains loaded after the call, and it's useful for staging environments
+// where unreviewed translations should be shown.
+func (l *Locale) SetAllowFuzzy(allow bool) {
+	l.Lock()
+	l.allowFuzzy = allow
+	l.Unlock()
+}
+
+// GetAllowFuzzy returns whether entries flagged as fuzzy are used by the domains loaded with AddDomain.
+func (l *Locale) GetAllowFuzzy() bool {
+	l.RLock()
+	defer l.RUnlock()
+
+	return l.allowFuzzy
 }
 
 // AddTranslator takes a domain name and a Translator object to make it available in the Locale object.
@@ -236,6 +327,7 @@ type LocaleEncoding struct {
 	Lang          string
 	Domains       map[string][]byte
 	DefaultDomain string
+	AllowFuzzy    bool
 }
 
 // MarshalBinary implements encoding BinaryMarshaler interface
@@ -254,6 +346,7 @@ func (l *Locale) MarshalBinary() ([]byte, error) {
 
 	obj.Lang = l.lang
 	obj.Path = l.path
+	obj.AllowFuzzy = l.allowFuzzy
 
 	var buff bytes.Buffer
 	encoder := gob.NewEncoder(&buff)
@@ -276,6 +369,7 @@ func (l *Locale) UnmarshalBinary(data []byte) error {
 	l.defaultDomain = obj.DefaultDomain
 	l.lang = obj.Lang
 	l.path = obj.Path
+	l.allowFuzzy = obj.AllowFuzzy
 
 	// Decode Domains
 	for k, v := range obj.Domains {
@@ -287,7 +381,12 @@ func (l *Locale) UnmarshalBinary(data []byte) error {
 			return err
 		}
 
-		l.Domains.Store(k, tr.GetTranslator())
+		trObj := tr.GetTranslator()
+		if ft, ok := trObj.(fuzzyTranslator); ok {
+			ft.SetAllowFuzzy(l.allowFuzzy)
+		}
+
+		l.Domains.Store(k, trObj)
 	}
 
 	return nil
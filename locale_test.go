@@ -6,10 +6,17 @@
 package gotext
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"sync"
 	"testing"
+	"testing/fstest"
 )
 
 func TestLocale(t *testing.T) {
@@ -550,3 +557,203 @@ func TestLocaleBinaryEncoding(t *testing.T) {
 		t.Errorf("'%s' is different from '%s", l.GetN("One with var: %s", "Several with vars: %s", 3, "VALUE"), l2.GetN("One with var: %s", "Several with vars: %s", 3, "VALUE"))
 	}
 }
+
+func TestLocaleAddDomainE(t *testing.T) {
+	l := NewLocale("fixtures/", "en_US")
+
+	if err := l.AddDomainE("default"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tr := l.Get("My text"); tr != "Translated text" {
+		t.Errorf("Expected 'Translated text' but got '%s'", tr)
+	}
+
+	err := l.AddDomainE("missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got '%v'", err)
+	}
+	if _, ok := l.Domains.Load("missing"); ok {
+		t.Error("Missing domain shouldn't be stored")
+	}
+}
+
+func TestLocaleFuzzy(t *testing.T) {
+	// Set PO content
+	str := `
+msgid "Unreviewed"
+msgstr ""
+
+#, fuzzy
+msgid "Fuzzy"
+msgstr "Fuzzy translation"
+`
+	// Create Locales directory
+	dirname := path.Join("/tmp", "fuzzy")
+	err := os.MkdirAll(dirname, os.ModePerm)
+	if err != nil {
+		t.Fatalf("Can't create test directory: %s", err.Error())
+	}
+
+	// Write PO content to file
+	err = ioutil.WriteFile(path.Join(dirname, "default.po"), []byte(str), 0644)
+	if err != nil {
+		t.Fatalf("Can't write to test file: %s", err.Error())
+	}
+
+	l := NewLocale("/tmp", "fuzzy")
+	l.AddDomain("default")
+	if tr := l.Get("Fuzzy"); tr != "Fuzzy" {
+		t.Errorf("Expected 'Fuzzy' but got '%s'", tr)
+	}
+
+	l = NewLocale("/tmp", "fuzzy")
+	l.SetAllowFuzzy(true)
+	l.AddDomain("default")
+	if tr := l.Get("Fuzzy"); tr != "Fuzzy translation" {
+		t.Errorf("Expected 'Fuzzy translation' but got '%s'", tr)
+	}
+
+	// Setting survives encoding
+	buff, err := l.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l2 := new(Locale)
+	if err = l2.UnmarshalBinary(buff); err != nil {
+		t.Fatal(err)
+	}
+	if tr := l2.Get("Fuzzy"); tr != "Fuzzy translation" {
+		t.Errorf("Expected 'Fuzzy translation' but got '%s'", tr)
+	}
+
+	// Other catalog formats get the setting as well
+	fsys := fstest.MapFS{
+		"de/default.xlf": {Data: []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file source-language="en" target-language="de" datatype="plaintext" original="default">
+    <body>
+      <trans-unit id="1">
+        <source>Fuzzy</source>
+        <target state="needs-review-translation">Fuzzy translation</target>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`)},
+	}
+
+	l = NewLocaleFS(fsys, "de")
+	l.AddDomain("default")
+	if tr := l.Get("Fuzzy"); tr != "Fuzzy" {
+		t.Errorf("Expected 'Fuzzy' but got '%s'", tr)
+	}
+
+	l = NewLocaleFS(fsys, "de")
+	l.SetAllowFuzzy(true)
+	l.AddDomain("default")
+	if tr := l.Get("Fuzzy"); tr != "Fuzzy translation" {
+		t.Errorf("Expected 'Fuzzy translation' but got '%s'", tr)
+	}
+}
+
+func TestLocaleBundle(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(buildMo(map[string]string{"My text": "Compressed text"}))
+	zw.Close()
+
+	// Build the bundle
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range map[string][]byte{
+		"de/LC_MESSAGES/default.po":   []byte("msgid \"My text\"\nmsgstr \"Bundled text\"\n"),
+		"de/LC_MESSAGES/extras.mo.gz": gz.Bytes(),
+		"fr/default.mo":               buildMo(map[string]string{"My text": "Texte groupé"}),
+		"fr/broken.po":                []byte("msgid \"My text\"\nmsgstr \"Broken\n"),
+	} {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(content)
+	}
+	w.Close()
+
+	bundle := filepath.Join(t.TempDir(), "locales.zip")
+	if err := ioutil.WriteFile(bundle, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewLocale(bundle, "de_DE")
+	if err := l.AddDomainE("default"); err != nil {
+		t.Fatal(err)
+	}
+	if tr := l.Get("My text"); tr != "Bundled text" {
+		t.Errorf("Expected 'Bundled text' but got '%s'", tr)
+	}
+	if err := l.AddDomainE("extras"); err != nil {
+		t.Fatal(err)
+	}
+	if tr := l.GetD("extras", "My text"); tr != "Compressed text" {
+		t.Errorf("Expected 'Compressed text' but got '%s'", tr)
+	}
+	if err := l.AddDomainE("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got '%v'", err)
+	}
+
+	l = NewLocale(bundle, "fr")
+	l.AddDomain("default")
+	if tr := l.Get("My text"); tr != "Texte groupé" {
+		t.Errorf("Expected 'Texte groupé' but got '%s'", tr)
+	}
+
+	// Errors report the file inside the bundle
+	var pe *ParseError
+	if err := l.AddDomainE("broken"); !errors.As(err, &pe) || pe.File != path.Join(bundle, "fr/broken.po") {
+		t.Errorf("Expected a parse error on fr/broken.po but got '%v'", err)
+	}
+
+	// Missing bundle
+	l = NewLocale(filepath.Join(t.TempDir(), "missing.zip"), "de")
+	if err := l.AddDomainE("default"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got '%v'", err)
+	}
+
+	// Package configuration
+	Configure(bundle, "de_DE", "default")
+	if tr := Get("My text"); tr != "Bundled text" {
+		t.Errorf("Expected 'Bundled text' but got '%s'", tr)
+	}
+}
+
+func TestLocaleFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"de/LC_MESSAGES/default.po": {Data: []byte("msgid \"My text\"\nmsgstr \"Embedded text\"\n")},
+		"fr/default.mo":             {Data: buildMo(map[string]string{"My text": "Texte embarqué"})},
+	}
+
+	l := NewLocaleFS(fsys, "de_DE")
+	if err := l.AddDomainE("default"); err != nil {
+		t.Fatal(err)
+	}
+	if tr := l.Get("My text"); tr != "Embedded text" {
+		t.Errorf("Expected 'Embedded text' but got '%s'", tr)
+	}
+	if err := l.AddDomainE("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got '%v'", err)
+	}
+
+	l = NewLocaleFS(fsys, "fr")
+	l.AddDomain("default")
+	if tr := l.Get("My text"); tr != "Texte embarqué" {
+		t.Errorf("Expected 'Texte embarqué' but got '%s'", tr)
+	}
+
+	// Same lookup as the library directory
+	l, dir := NewLocaleFS(os.DirFS("fixtures"), "de_DE"), NewLocale("fixtures", "de_DE")
+	l.AddDomain("default")
+	dir.AddDomain("default")
+	if tr, expected := l.Get("My text"), dir.Get("My text"); tr != expected {
+		t.Errorf("Expected '%s' but got '%s'", expected, tr)
+	}
+}
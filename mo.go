@@ -10,6 +10,8 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/gob"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/textproto"
 	"os"
@@ -75,12 +77,20 @@ type Mo struct {
 	translations map[string]*Translation
 	contexts     map[string]map[string]*Translation
 
+	// Charset forced by SetCharset, instead of the one declared on the headers
+	charset string
+
+	// Duplicate keys found by the last parse, reported by Validate
+	duplicates []Diagnostic
+
 	// Sync Mutex
 	sync.RWMutex
 
 	// Parsing buffers
 	trBuffer  *Translation
 	ctxBuffer string
+	csBuffer  *charmap
+	csErr     error
 }
 
 // NewMoTranslator creates a new Mo object with the Translator interface
@@ -89,150 +99,259 @@ func NewMoTranslator() Translator {
 }
 
 // ParseFile tries to read the file by its provided path (f) and parse its content as a .po file.
+// Gzip compressed files, like translations.mo.gz, are decompressed.
 func (mo *Mo) ParseFile(f string) {
+	mo.ParseFileE(f)
+}
+
+// ParseFileE works like ParseFile, but returns an error when the file can't be read or parsed.
+// A missing file or a directory path returns an error wrapping ErrNotFound.
+func (mo *Mo) ParseFileE(f string) error {
 	// Check if file exists
 	info, err := os.Stat(f)
 	if err != nil {
-		return
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrNotFound, f)
+		}
+		return err
 	}
 
 	// Check that isn't a directory
 	if info.IsDir() {
-		return
+		return fmt.Errorf("%w: %s is a directory", ErrNotFound, f)
 	}
 
-	// Parse file content
-	data, err := ioutil.ReadFile(f)
+	// Read file content, decompressed when needed
+	data, err := readFile(nil, f)
 	if err != nil {
-		return
+		return err
 	}
 
-	mo.Parse(data)
+	return withFile(mo.ParseE(data), f)
 }
 
 // Parse loads the translations specified in the provided string (str)
 func (mo *Mo) Parse(buf []byte) {
+	mo.ParseE(buf)
+}
+
+// ParseE works like Parse, but returns a *ParseError when the content isn't a valid MO file.
+// The wrapped error is ErrBadMagic, ErrUnsupportedRevision, or ErrCorruptMo and io.ErrUnexpectedEOF
+// when a table or string is out of the content bounds. Errors leave the previously loaded catalog untouched.
+//
+// Entries are converted to UTF-8 from the charset declared on the Content-Type header,
+// or the one set with SetCharset, and the header is updated to declare UTF-8.
+// ErrUnsupportedCharset is wrapped when the charset isn't supported, and the entries are then loaded unconverted.
+func (mo *Mo) ParseE(buf []byte) error {
 	// Lock while parsing
 	mo.Lock()
 
-	// Init storage
-	if mo.translations == nil {
-		mo.translations = make(map[string]*Translation)
-		mo.contexts = make(map[string]map[string]*Translation)
+	if err := mo.parse(buf); err != nil {
+		mo.Unlock()
+		return &ParseError{Err: err}
 	}
 
-	r := bytes.NewReader(buf)
+	csErr := mo.csErr
 
-	var magicNumber uint32
-	if err := binary.Read(r, binary.LittleEndian, &magicNumber); err != nil {
-		return
-		// return fmt.Errorf("gettext: %v", err)
+	// Unlock to parse headers
+	mo.Unlock()
+
+	// Parse headers
+	mo.parseHeaders()
+
+	// Report unsupported charsets
+	if csErr != nil {
+		return &ParseError{Err: csErr}
+	}
+
+	return nil
+}
+
+// ParseReader loads the translations of the MO file read from r.
+// MO files need random access to their string tables, so the content is read whole before parsing.
+func (mo *Mo) ParseReader(r io.Reader) {
+	mo.ParseReaderE(r)
+}
+
+// ParseReaderE works like ParseReader, but returns the error found reading r,
+// or a *ParseError when the content isn't a valid MO file, as ParseE does.
+func (mo *Mo) ParseReaderE(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
 	}
+
+	return mo.ParseE(data)
+}
+
+// moMaxDecoded caps the bytes of the strings decoded from a MO file to this many times the file size.
+// Strings of valid files don't share their bytes, so only crafted files with overlapping strings reach it,
+// which would otherwise take memory quadratic to their size.
+const moMaxDecoded = 4
+
+// parse reads the MO content in buf into the translations storage.
+// Every offset and length is checked before reading, and the storage is only updated
+// when the whole content is valid, so errors leave the previous catalog untouched.
+// It must be called with the write lock held.
+func (mo *Mo) parse(buf []byte) error {
+	size := uint64(len(buf))
+	if size < 4 {
+		return ErrBadMagic
+	}
+
 	var bo binary.ByteOrder
-	switch magicNumber {
+	switch binary.LittleEndian.Uint32(buf) {
 	case MoMagicLittleEndian:
 		bo = binary.LittleEndian
 	case MoMagicBigEndian:
 		bo = binary.BigEndian
 	default:
-		return
-		// return fmt.Errorf("gettext: %v", "invalid magic number")
+		return ErrBadMagic
 	}
 
-	var header struct {
-		MajorVersion uint16
-		MinorVersion uint16
-		MsgIDCount   uint32
-		MsgIDOffset  uint32
-		MsgStrOffset uint32
-		HashSize     uint32
-		HashOffset   uint32
+	if size < moHeaderSize {
+		return fmt.Errorf("%w (%w): header needs %d bytes, the file has %d", ErrCorruptMo, io.ErrUnexpectedEOF, moHeaderSize, size)
 	}
-	if err := binary.Read(r, bo, &header); err != nil {
-		return
-		// return fmt.Errorf("gettext: %v", err)
+
+	// Major revision 0, with minor revisions 0 and 1
+	rev := bo.Uint32(buf[4:])
+	if rev>>16 != 0 || rev&0xffff > 1 {
+		return ErrUnsupportedRevision
 	}
-	if v := header.MajorVersion; v != 0 && v != 1 {
-		return
-		// return fmt.Errorf("gettext: %v", "invalid version number")
-	}
-	if v := header.MinorVersion; v != 0 && v != 1 {
-		return
-		// return fmt.Errorf("gettext: %v", "invalid version number")
+	if rev == 1 && size < moSysdepHeaderSize {
+		return fmt.Errorf("%w (%w): revision 1 header needs %d bytes, the file has %d", ErrCorruptMo, io.ErrUnexpectedEOF, moSysdepHeaderSize, size)
 	}
 
-	msgIDStart := make([]uint32, header.MsgIDCount)
-	msgIDLen := make([]uint32, header.MsgIDCount)
-	if _, err := r.Seek(int64(header.MsgIDOffset), 0); err != nil {
-		return
-		// return fmt.Errorf("gettext: %v", err)
+	count := uint64(bo.Uint32(buf[8:]))
+	idsOffset := uint64(bo.Uint32(buf[12:]))
+	strsOffset := uint64(bo.Uint32(buf[16:]))
+	hashSize := uint64(bo.Uint32(buf[20:]))
+	hashOffset := uint64(bo.Uint32(buf[24:]))
+
+	// Tables must fit in the file
+	if idsOffset+count*8 > size {
+		return fmt.Errorf("%w (%w): msgid table at offset %d with %d entries exceeds the file size %d", ErrCorruptMo, io.ErrUnexpectedEOF, idsOffset, count, size)
 	}
-	for i := 0; i < int(header.MsgIDCount); i++ {
-		if err := binary.Read(r, bo, &msgIDLen[i]); err != nil {
-			return
-			// return fmt.Errorf("gettext: %v", err)
+	if strsOffset+count*8 > size {
+		return fmt.Errorf("%w (%w): msgstr table at offset %d with %d entries exceeds the file size %d", ErrCorruptMo, io.ErrUnexpectedEOF, strsOffset, count, size)
+	}
+	if hashSize > 0 && hashOffset+hashSize*4 > size {
+		return fmt.Errorf("%w (%w): hash table at offset %d with %d entries exceeds the file size %d", ErrCorruptMo, io.ErrUnexpectedEOF, hashOffset, hashSize, size)
+	}
+
+	// readString returns the string i of the table at offset table.
+	decoded := uint64(0)
+	readString := func(table, i uint64, name string) ([]byte, error) {
+		length := uint64(bo.Uint32(buf[table+i*8:]))
+		offset := uint64(bo.Uint32(buf[table+i*8+4:]))
+		if offset+length > size {
+			return nil, fmt.Errorf("%w (%w): %s %d at offset %d with length %d exceeds the file size %d", ErrCorruptMo, io.ErrUnexpectedEOF, name, i, offset, length, size)
 		}
-		if err := binary.Read(r, bo, &msgIDStart[i]); err != nil {
-			return
-			// return fmt.Errorf("gettext: %v", err)
+		if decoded += length; decoded > moMaxDecoded*size {
+			return nil, fmt.Errorf("%w: strings exceed %d times the file size %d", ErrCorruptMo, moMaxDecoded, size)
 		}
+		return buf[offset : offset+length], nil
 	}
 
-	msgStrStart := make([]int32, header.MsgIDCount)
-	msgStrLen := make([]int32, header.MsgIDCount)
-	if _, err := r.Seek(int64(header.MsgStrOffset), 0); err != nil {
-		return
-		// return fmt.Errorf("gettext: %v", err)
+	mo.csBuffer, mo.csErr = nil, nil
+	if mo.charset != "" {
+		mo.csBuffer, mo.csErr = getCharmap(mo.charset)
 	}
-	for i := 0; i < int(header.MsgIDCount); i++ {
-		if err := binary.Read(r, bo, &msgStrLen[i]); err != nil {
-			return
-			// return fmt.Errorf("gettext: %v", err)
+
+	// Parse everything before touching the storage
+	translations := make(map[string]*Translation)
+	contexts := make(map[string]map[string]*Translation)
+	var duplicates []Diagnostic
+
+	// add stores the entry, replacing a duplicated one only when replace is set
+	add := func(msgID, msgStr []byte, replace bool) {
+		tr := mo.newTranslation(msgID, msgStr)
+
+		// Keys must be unique
+		if _, ok := contexts[tr.Context][tr.ID]; ok || tr.Context == "" && translations[tr.ID] != nil {
+			duplicates = append(duplicates, Diagnostic{
+				Context: tr.Context,
+				ID:      tr.ID,
+				Err:     fmt.Errorf("%w: msgid %q", ErrDuplicate, tr.ID),
+			})
+			if !replace {
+				return
+			}
 		}
-		if err := binary.Read(r, bo, &msgStrStart[i]); err != nil {
+
+		if tr.Context == "" {
+			translations[tr.ID] = tr
 			return
-			// return fmt.Errorf("gettext: %v", err)
 		}
+		if _, ok := contexts[tr.Context]; !ok {
+			contexts[tr.Context] = make(map[string]*Translation)
+		}
+		contexts[tr.Context][tr.ID] = tr
 	}
 
-	for i := 0; i < int(header.MsgIDCount); i++ {
-		if _, err := r.Seek(int64(msgIDStart[i]), 0); err != nil {
-			return
-			// return fmt.Errorf("gettext: %v", err)
+	for i := uint64(0); i < count; i++ {
+		msgID, err := readString(idsOffset, i, "msgid")
+		if err != nil {
+			return err
 		}
-		msgIDData := make([]byte, msgIDLen[i])
-		if _, err := r.Read(msgIDData); err != nil {
-			return
-			// return fmt.Errorf("gettext: %v", err)
+		msgStr, err := readString(strsOffset, i, "msgstr")
+		if err != nil {
+			return err
 		}
 
-		if _, err := r.Seek(int64(msgStrStart[i]), 0); err != nil {
-			return
-			// return fmt.Errorf("gettext: %v", err)
+		add(msgID, msgStr, true)
+	}
+
+	// Revision 1 system dependent strings, expanded for this system.
+	// Static strings come first on glibc lookups, so they're kept on duplicates.
+	if rev == 1 {
+		entries, err := readSysdepStrings(bytes.NewReader(buf), int64(size), bo, buf[:moSysdepHeaderSize], int64(moMaxDecoded*size-decoded))
+		if err != nil {
+			return err
 		}
-		msgStrData := make([]byte, msgStrLen[i])
-		if _, err := r.Read(msgStrData); err != nil {
-			return
-			// return fmt.Errorf("gettext: %v", err)
+		for _, e := range entries {
+			add([]byte(e.key), []byte(e.value), false)
 		}
+	}
 
-		if len(msgIDData) == 0 {
-			mo.addTranslation(msgIDData, msgStrData)
-		} else {
-			mo.addTranslation(msgIDData, msgStrData)
-		}
+	// Init storage
+	if mo.translations == nil {
+		mo.translations = make(map[string]*Translation)
+		mo.contexts = make(map[string]map[string]*Translation)
 	}
 
-	// Unlock to parse headers
-	mo.Unlock()
+	for id, tr := range translations {
+		mo.translations[id] = tr
+	}
+	for ctx, trs := range contexts {
+		if _, ok := mo.contexts[ctx]; !ok {
+			mo.contexts[ctx] = make(map[string]*Translation)
+		}
+		for id, tr := range trs {
+			mo.contexts[ctx][id] = tr
+		}
+	}
+	mo.duplicates = duplicates
 
-	// Parse headers
-	mo.parseHeaders()
-	return
-	// return nil
+	return nil
 }
 
-func (mo *Mo) addTranslation(msgid, msgstr []byte) {
+// newTranslation builds the Translation of a msgid/msgstr pair, converted to UTF-8.
+func (mo *Mo) newTranslation(msgid, msgstr []byte) *Translation {
+	// The header entry declares the charset of the following entries
+	if len(msgid) == 0 {
+		mo.setCharsetBuffer(string(msgstr))
+	}
+
+	// Convert to UTF-8
+	if mo.csBuffer != nil {
+		msgid = []byte(mo.csBuffer.decode(string(msgid)))
+		msgstr = []byte(mo.csBuffer.decode(string(msgstr)))
+		if len(msgid) == 0 {
+			msgstr = []byte(setHeaderCharset(string(msgstr), "UTF-8"))
+		}
+	}
+
 	translation := NewTranslation()
 	var msgctxt []byte
 	var msgidPlural []byte
@@ -248,9 +367,12 @@ func (mo *Mo) addTranslation(msgid, msgstr []byte) {
 	if len(dd) > 1 {
 		msgid = dd[0]
 		dd = dd[1:]
+	} else {
+		dd = nil
 	}
 
 	translation.ID = string(msgid)
+	translation.Context = string(msgctxt)
 
 	msgidPlural = bytes.Join(dd, []byte(NulSeparator))
 	if len(msgidPlural) > 0 {
@@ -264,15 +386,38 @@ func (mo *Mo) addTranslation(msgid, msgstr []byte) {
 		}
 	}
 
-	if len(msgctxt) > 0 {
-		// With context...
-		if _, ok := mo.contexts[string(msgctxt)]; !ok {
-			mo.contexts[string(msgctxt)] = make(map[string]*Translation)
-		}
-		mo.contexts[string(msgctxt)][translation.ID] = translation
-	} else {
-		mo.translations[translation.ID] = translation
+	return translation
+}
+
+// setCharsetBuffer sets the charset used to convert the parsed entries from the raw header entry,
+// unless a charset has been forced with SetCharset.
+func (mo *Mo) setCharsetBuffer(header string) {
+	if mo.charset != "" {
+		return
 	}
+
+	var err error
+	mo.csBuffer, err = getCharmap(headerCharset(header))
+	if err != nil && mo.csErr == nil {
+		mo.csErr = err
+	}
+}
+
+// SetCharset forces the charset used to convert the parsed content to UTF-8,
+// ignoring the one declared on the Content-Type header. Use "" to restore the default.
+// It applies to the content parsed after the call.
+func (mo *Mo) SetCharset(cs string) {
+	mo.Lock()
+	mo.charset = cs
+	mo.Unlock()
+}
+
+// GetCharset returns the charset forced with SetCharset.
+func (mo *Mo) GetCharset() string {
+	mo.RLock()
+	defer mo.RUnlock()
+
+	return mo.charset
 }
 
 // parseHeaders retrieves data from previously parsed headers
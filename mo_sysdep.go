@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// moSysdepHeaderSize is the size of the revision 1 MO header, which adds the system dependent strings tables.
+const moSysdepHeaderSize = 48
+
+// moSegmentsEnd marks the last segment of a system dependent string.
+const moSegmentsEnd = 0xffffffff
+
+/*
+sysdepSegmentValue returns the expansion of a system dependent segment name,
+like "lu" for "PRIu64", the name of the <PRIu64> format macro.
+Values are the ones glibc uses on LP64 systems, and unknown names return false.
+*/
+func sysdepSegmentValue(name string) (string, bool) {
+	// glibc specific printf flag
+	if name == "I" {
+		return "I", true
+	}
+
+	// ISO C99 <inttypes.h> format macros: PRI{d,i,o,u,x,X}{8,16,32,64,LEAST*,FAST*,MAX,PTR}
+	if len(name) < 5 || name[:3] != "PRI" {
+		return "", false
+	}
+	switch name[3] {
+	case 'd', 'i', 'o', 'u', 'x', 'X':
+	default:
+		return "", false
+	}
+
+	switch name[4:] {
+	case "8", "16", "32", "LEAST8", "LEAST16", "LEAST32", "FAST8":
+		return name[3:4], true
+	case "64", "LEAST64", "FAST16", "FAST32", "FAST64", "MAX", "PTR":
+		return "l" + name[3:4], true
+	}
+
+	return "", false
+}
+
+// moSysdepReader reads the system dependent strings tables of a revision 1 MO file.
+type moSysdepReader struct {
+	r    io.ReaderAt
+	size int64
+	bo   binary.ByteOrder
+
+	// Expanded segments, nil for unknown ones
+	segments []*string
+
+	// Bytes read by expand, up to limit
+	decoded, limit int64
+}
+
+// read reads n bytes at offset off, which must be in the content bounds.
+func (sr *moSysdepReader) read(off, n int64, name string) ([]byte, error) {
+	if off+n > sr.size {
+		return nil, fmt.Errorf("%w (%w): %s at offset %d with length %d exceeds the file size %d", ErrCorruptMo, io.ErrUnexpectedEOF, name, off, n, sr.size)
+	}
+
+	buf := make([]byte, n)
+	if _, err := sr.r.ReadAt(buf, off); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// uint32 reads the number at offset off.
+func (sr *moSysdepReader) uint32(off int64, name string) (int64, error) {
+	buf, err := sr.read(off, 4, name)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(sr.bo.Uint32(buf)), nil
+}
+
+// expand builds the string i of the sysdep table at offset table, replacing its segments by their value.
+// It returns false when the string uses an unknown segment.
+func (sr *moSysdepReader) expand(table, i int64, name string) (string, bool, error) {
+	off, err := sr.uint32(table+i*4, name)
+	if err != nil {
+		return "", false, err
+	}
+	static, err := sr.uint32(off, name)
+	if err != nil {
+		return "", false, err
+	}
+
+	valid := true
+	var s []byte
+	for p := off + 4; ; p += 8 {
+		pair, err := sr.read(p, 8, name+" segment")
+		if err != nil {
+			return "", false, err
+		}
+		segSize, ref := int64(sr.bo.Uint32(pair)), sr.bo.Uint32(pair[4:])
+
+		piece, err := sr.read(static, segSize, name)
+		if err != nil {
+			return "", false, err
+		}
+
+		// Strings sharing their segments could take any memory
+		if sr.decoded += 8 + segSize; sr.decoded > sr.limit {
+			return "", false, fmt.Errorf("%w: strings exceed %d times the file size %d", ErrCorruptMo, moMaxDecoded, sr.size)
+		}
+		s = append(s, piece...)
+		static += segSize
+
+		if ref == moSegmentsEnd {
+			break
+		}
+		if int64(ref) >= int64(len(sr.segments)) {
+			return "", false, fmt.Errorf("%w: %s %d refers to segment %d of %d", ErrCorruptMo, name, i, ref, len(sr.segments))
+		}
+		if sr.segments[ref] == nil {
+			valid = false
+			continue
+		}
+		s = append(s, *sr.segments[ref]...)
+	}
+
+	// The last static segment includes the terminating NUL
+	if len(s) > 0 && s[len(s)-1] == 0 {
+		s = s[:len(s)-1]
+	}
+
+	return string(s), valid, nil
+}
+
+// readSysdepStrings expands the system dependent strings of a revision 1 MO file
+// into msgid/msgstr pairs, as glibc does when loading the file.
+// header is the revision 1 header of the content, and strings using unknown segments are left out.
+// Reading more than limit bytes to expand them returns an error wrapping ErrCorruptMo.
+func readSysdepStrings(r io.ReaderAt, size int64, bo binary.ByteOrder, header []byte, limit int64) ([]moEntry, error) {
+	segCount := int64(bo.Uint32(header[28:]))
+	segOffset := int64(bo.Uint32(header[32:]))
+	count := int64(bo.Uint32(header[36:]))
+	idsOffset := int64(bo.Uint32(header[40:]))
+	strsOffset := int64(bo.Uint32(header[44:]))
+
+	// Tables must fit in the file
+	if segOffset+segCount*8 > size {
+		return nil, fmt.Errorf("%w (%w): sysdep segments table at offset %d with %d entries exceeds the file size %d", ErrCorruptMo, io.ErrUnexpectedEOF, segOffset, segCount, size)
+	}
+	if idsOffset+count*4 > size {
+		return nil, fmt.Errorf("%w (%w): sysdep msgid table at offset %d with %d entries exceeds the file size %d", ErrCorruptMo, io.ErrUnexpectedEOF, idsOffset, count, size)
+	}
+	if strsOffset+count*4 > size {
+		return nil, fmt.Errorf("%w (%w): sysdep msgstr table at offset %d with %d entries exceeds the file size %d", ErrCorruptMo, io.ErrUnexpectedEOF, strsOffset, count, size)
+	}
+
+	sr := &moSysdepReader{r: r, size: size, bo: bo, limit: limit}
+
+	// Segment names
+	table, err := sr.read(segOffset, segCount*8, "sysdep segments table")
+	if err != nil {
+		return nil, err
+	}
+	sr.segments = make([]*string, segCount)
+	for i := range sr.segments {
+		length, offset := int64(bo.Uint32(table[i*8:])), int64(bo.Uint32(table[i*8+4:]))
+		name, err := sr.read(offset, length, "sysdep segment")
+		if err != nil {
+			return nil, err
+		}
+
+		// msgfmt counts the terminating NUL in the length of the names
+		name = bytes.TrimSuffix(name, []byte{0})
+		if v, ok := sysdepSegmentValue(string(name)); ok {
+			sr.segments[i] = &v
+		}
+	}
+
+	// Strings
+	var entries []moEntry
+	for i := int64(0); i < count; i++ {
+		key, keyOK, err := sr.expand(idsOffset, i, "sysdep msgid")
+		if err != nil {
+			return nil, err
+		}
+		value, valueOK, err := sr.expand(strsOffset, i, "sysdep msgstr")
+		if err != nil {
+			return nil, err
+		}
+
+		if keyOK && valueOK {
+			entries = append(entries, moEntry{key: key, value: value})
+		}
+	}
+
+	return entries, nil
+}
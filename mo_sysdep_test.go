@@ -0,0 +1,293 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+var sysdepSegmentRe = regexp.MustCompile(`<(\w+)>`)
+
+// buildSysdepMo builds a little endian revision 1 MO file, without hash table, with the static entries
+// and the system dependent ones, which write their segments like "<PRIu64>" as xgettext extracts them.
+func buildSysdepMo(static, sysdep map[string]string) []byte {
+	sortedKeys := func(m map[string]string) []string {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	ids, sysIds := sortedKeys(static), sortedKeys(sysdep)
+
+	// Segment names
+	var names []string
+	refs := make(map[string]uint32)
+	for _, id := range sysIds {
+		for _, s := range []string{id, sysdep[id]} {
+			for _, m := range sysdepSegmentRe.FindAllStringSubmatch(s, -1) {
+				if _, ok := refs[m[1]]; !ok {
+					refs[m[1]] = uint32(len(names))
+					names = append(names, m[1])
+				}
+			}
+		}
+	}
+
+	n, m, s := uint32(len(ids)), uint32(len(sysIds)), uint32(len(names))
+	idsOffset := uint32(moSysdepHeaderSize)
+	strsOffset := idsOffset + n*8
+	segOffset := strsOffset + n*8
+	sysIdsOffset := segOffset + s*8
+	sysStrsOffset := sysIdsOffset + m*4
+	dataOffset := sysStrsOffset + m*4
+
+	var data []byte
+	table := []uint32{MoMagicLittleEndian, 1, n, idsOffset, strsOffset, 0, 0, s, segOffset, m, sysIdsOffset, sysStrsOffset}
+
+	// add appends b, NUL terminated, to the data and returns its offset
+	add := func(b []byte) uint32 {
+		off := dataOffset + uint32(len(data))
+		data = append(append(data, b...), 0)
+		return off
+	}
+
+	for _, id := range ids {
+		table = append(table, uint32(len(id)), add([]byte(id)))
+	}
+	for _, id := range ids {
+		table = append(table, uint32(len(static[id])), add([]byte(static[id])))
+	}
+	// Segment name lengths include the NUL, as msgfmt writes them
+	for _, name := range names {
+		table = append(table, uint32(len(name))+1, add([]byte(name)))
+	}
+
+	// sysdepString writes the static pieces and the segments of str, and returns the offset of its struct
+	sysdepString := func(str string) uint32 {
+		locs := sysdepSegmentRe.FindAllStringSubmatchIndex(str, -1)
+
+		var static []byte
+		var pairs []uint32
+		last := 0
+		for _, loc := range locs {
+			static = append(static, str[last:loc[0]]...)
+			pairs = append(pairs, uint32(loc[0]-last), refs[str[loc[2]:loc[3]]])
+			last = loc[1]
+		}
+		static = append(static, str[last:]...)
+		pairs = append(pairs, uint32(len(str)-last)+1, moSegmentsEnd)
+
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, append([]uint32{add(static)}, pairs...))
+		off := dataOffset + uint32(len(data))
+		data = append(data, buf.Bytes()...)
+		return off
+	}
+	for _, id := range sysIds {
+		table = append(table, sysdepString(id))
+	}
+	for _, id := range sysIds {
+		table = append(table, sysdepString(sysdep[id]))
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, table)
+	buf.Write(data)
+
+	return buf.Bytes()
+}
+
+var sysdepStatic = map[string]string{
+	"":        "Content-Type: text/plain; charset=UTF-8\nPlural-Forms: nplurals=2; plural=(n != 1);\n",
+	"%d days": "%d Tage",
+}
+
+var sysdepStrings = map[string]string{
+	"%<PRIu64> bytes":                   "%<PRIu64> Bytes",
+	"%<PRId32> file\x00%<PRId32> files": "%<PRId32> Datei\x00%<PRId32> Dateien",
+	"size\x04%<PRIxMAX> of %<PRIu16>":   "%<PRIxMAX> von %<PRIu16>",
+	"%<I>d users":                       "%<I>d Benutzer",
+	"%<PRIu128> unknown":                "%<PRIu128> unbekannt",
+	"%d days":                           "%d Tage (sysdep)",
+}
+
+func TestSysdepSegmentValue(t *testing.T) {
+	for name, expected := range map[string]string{
+		"PRId8":       "d",
+		"PRIu16":      "u",
+		"PRIx32":      "x",
+		"PRIX64":      "lX",
+		"PRIoLEAST32": "o",
+		"PRIiLEAST64": "li",
+		"PRIdFAST8":   "d",
+		"PRIuFAST16":  "lu",
+		"PRIdMAX":     "ld",
+		"PRIxPTR":     "lx",
+		"I":           "I",
+	} {
+		if v, ok := sysdepSegmentValue(name); !ok || v != expected {
+			t.Errorf("%s: expected '%s' but got '%s' (%v)", name, expected, v, ok)
+		}
+	}
+
+	for _, name := range []string{"", "PRI", "PRIu", "PRIu128", "PRIs64", "PRIuLEAST", "SCNd64", "J"} {
+		if v, ok := sysdepSegmentValue(name); ok {
+			t.Errorf("%s: expected unknown segment but got '%s'", name, v)
+		}
+	}
+}
+
+func TestMoSysdep(t *testing.T) {
+	data := buildSysdepMo(sysdepStatic, sysdepStrings)
+
+	mo := new(Mo)
+	if err := mo.ParseE(data); err != nil {
+		t.Fatal(err)
+	}
+	lazy := new(LazyMo)
+	if err := lazy.ParseE(data); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tr := range map[string]Translator{"Mo": mo, "LazyMo": lazy} {
+		get, getN, getC := tr.Get, tr.GetN, tr.GetC
+
+		// C length modifiers are left as they are
+		if s := get("%lu bytes"); s != "%lu Bytes" {
+			t.Errorf("%s: expected '%%lu Bytes' but got '%s'", name, s)
+		}
+		if s := getN("%d file", "%d files", 3, 3); s != "3 Dateien" {
+			t.Errorf("%s: expected '3 Dateien' but got '%s'", name, s)
+		}
+		if s := getC("%lx of %u", "size"); s != "%lx von %u" {
+			t.Errorf("%s: expected '%%lx von %%u' but got '%s'", name, s)
+		}
+		if s := get("%Id users"); s != "%Id Benutzer" {
+			t.Errorf("%s: expected '%%Id Benutzer' but got '%s'", name, s)
+		}
+
+		// Unknown segments leave the entry out
+		if s := get("%<PRIu128> unknown"); s != "%<PRIu128> unknown" {
+			t.Errorf("%s: expected untranslated entry but got '%s'", name, s)
+		}
+
+		// Static strings come first
+		if s := get("%d days", 2); s != "2 Tage" {
+			t.Errorf("%s: expected '2 Tage' but got '%s'", name, s)
+		}
+	}
+
+	// Expanded entries are encoded back as static ones
+	out, err := MarshalMo(lazy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back := new(Mo)
+	if err := back.ParseE(out); err != nil {
+		t.Fatal(err)
+	}
+	if s := back.GetN("%d file", "%d files", 1, 1); s != "1 Datei" {
+		t.Errorf("Expected '1 Datei' but got '%s'", s)
+	}
+	if get := back.Get; get("%d days") != "%d Tage" {
+		t.Errorf("Expected '%%d Tage' but got '%s'", get("%d days"))
+	}
+}
+
+func TestMoSysdepOverlapping(t *testing.T) {
+	data := buildSysdepMo(sysdepStatic, sysdepStrings)
+
+	// Every string is the whole file
+	sysIdsOffset := int(binary.LittleEndian.Uint32(data[40:]))
+	str := binary.LittleEndian.Uint32(data[sysIdsOffset:])
+	for i := 0; i < len(sysdepStrings)*2; i++ {
+		binary.LittleEndian.PutUint32(data[sysIdsOffset+i*4:], str)
+	}
+	for i, v := range []uint32{0, uint32(len(data)), moSegmentsEnd} {
+		binary.LittleEndian.PutUint32(data[int(str)+i*4:], v)
+	}
+
+	if err := new(Mo).ParseE(data); !errors.Is(err, ErrCorruptMo) {
+		t.Errorf("Expected ErrCorruptMo but got '%v'", err)
+	}
+	if err := new(LazyMo).ParseE(data); !errors.Is(err, ErrCorruptMo) {
+		t.Errorf("Expected ErrCorruptMo from LazyMo but got '%v'", err)
+	}
+}
+
+func TestMoSysdepCorrupt(t *testing.T) {
+	data := buildSysdepMo(sysdepStatic, sysdepStrings)
+
+	// corrupt returns a copy of data with the number at offset off replaced by v
+	corrupt := func(off int, v uint32) []byte {
+		bad := append([]byte{}, data...)
+		binary.LittleEndian.PutUint32(bad[off:], v)
+		return bad
+	}
+
+	sysIdsOffset := int(binary.LittleEndian.Uint32(data[40:]))
+	str := int(binary.LittleEndian.Uint32(data[sysIdsOffset:]))
+	for name, bad := range map[string][]byte{
+		"short header":   data[:40],
+		"segment count":  corrupt(28, 0xfffffff),
+		"strings count":  corrupt(36, 0xffffffff),
+		"msgstr table":   corrupt(44, uint32(len(data))),
+		"string offset":  corrupt(sysIdsOffset, uint32(len(data)-2)),
+		"static offset":  corrupt(str, uint32(len(data))),
+		"segment size":   corrupt(str+4, 0xffffffff),
+		"segment ref":    corrupt(str+8, 1000),
+		"truncated data": data[:len(data)-20],
+	} {
+		mo := new(Mo)
+		mo.Parse(str1Mo)
+
+		err := mo.ParseE(bad)
+		if !errors.Is(err, ErrCorruptMo) {
+			t.Errorf("%s: expected ErrCorruptMo but got '%v'", name, err)
+		}
+		if name != "segment ref" && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%s: expected io.ErrUnexpectedEOF but got '%v'", name, err)
+		}
+		if s := mo.Get("Hello"); s != "Hallo" {
+			t.Errorf("%s: expected 'Hallo' but got '%s'", name, s)
+		}
+
+		if err := new(LazyMo).ParseE(bad); !errors.Is(err, ErrCorruptMo) {
+			t.Errorf("%s: expected ErrCorruptMo from LazyMo but got '%v'", name, err)
+		}
+	}
+}
+
+func TestMoSysdepMsgfmt(t *testing.T) {
+	// Danish catalog of xz 5.2.4, built by GNU msgfmt with <PRIu32> and <PRIu64> segments
+	const path = "fixtures/da/LC_MESSAGES/xz.mo"
+
+	mo := new(Mo)
+	if err := mo.ParseFileE(path); err != nil {
+		t.Fatal(err)
+	}
+	lazy := new(LazyMo)
+	if err := lazy.ParseFileE(path); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tr := range map[string]Translator{"Mo": mo, "LazyMo": lazy} {
+		if s := tr.Get("Using up to %u threads."); s != "Bruger op til %u tråde." {
+			t.Errorf("%s: expected 'Bruger op til %%u tråde.' but got '%s'", name, s)
+		}
+		if s := tr.Get("Value of the option `%s' must be in the range [%lu, %lu]"); s != "Værdien for tilvalget »%s« skal være i intervallet [%lu, %lu]" {
+			t.Errorf("%s: expected the expanded <PRIu64> translation but got '%s'", name, s)
+		}
+	}
+}
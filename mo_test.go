@@ -6,9 +6,16 @@
 package gotext
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
 	"os"
 	"path"
+	"sort"
 	"testing"
+	"testing/iotest"
 )
 
 func TestMo_Get(t *testing.T) {
@@ -98,6 +105,9 @@ func TestMo(t *testing.T) {
 	if tr != "This one is the singular in a Ctx context: Test" {
 		t.Errorf("Expected 'This one is the singular in a Ctx context: Test' but got '%s'", tr)
 	}
+	if e := mo.contexts["Ctx"]["One with var: %s"]; e == nil || e.Context != "Ctx" {
+		t.Errorf("Expected an entry with the Ctx context but got %+v", e)
+	}
 
 	// Test plural
 	tr = mo.GetNC("One with var: %s", "Several with vars: %s", 17, "Ctx", v)
@@ -232,3 +242,195 @@ func TestMoBinaryEncoding(t *testing.T) {
 		t.Errorf("Expected 'en_US' but got '%s'", tr)
 	}
 }
+
+func TestMoParseE(t *testing.T) {
+	mo := new(Mo)
+
+	// Missing file
+	err := mo.ParseFileE("fixtures/en_US/missing.mo")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got '%v'", err)
+	}
+
+	// Not a MO file
+	err = mo.ParseFileE("fixtures/en_US/default.po")
+	if !errors.Is(err, ErrBadMagic) {
+		t.Errorf("Expected ErrBadMagic but got '%v'", err)
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.File != "fixtures/en_US/default.po" {
+		t.Errorf("Expected *ParseError for 'fixtures/en_US/default.po' but got '%v'", err)
+	}
+
+	data, err := ioutil.ReadFile("fixtures/en_US/default.mo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unsupported revision
+	bad := append([]byte{}, data...)
+	bad[6] = 7
+	err = new(Mo).ParseE(bad)
+	if !errors.Is(err, ErrUnsupportedRevision) {
+		t.Errorf("Expected ErrUnsupportedRevision but got '%v'", err)
+	}
+
+	// Truncated file
+	err = new(Mo).ParseE(data[:len(data)/2])
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected io.ErrUnexpectedEOF but got '%v'", err)
+	}
+
+	// Valid file
+	mo = new(Mo)
+	if err = mo.ParseE(data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tr := mo.Get("My text"); tr != "Translated text" {
+		t.Errorf("Expected 'Translated text' but got '%s'", tr)
+	}
+}
+
+// buildMo returns the content of a little endian MO file for the given msgid/msgstr pairs.
+func buildMo(entries map[string]string) []byte {
+	ids := make([]string, 0, len(entries))
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	n := uint32(len(ids))
+	idsOffset := uint32(28)
+	strsOffset := idsOffset + n*8
+	dataOffset := strsOffset + n*8
+
+	var data []byte
+	table := make([]uint32, 0, n*4)
+	for _, id := range ids {
+		table = append(table, uint32(len(id)), dataOffset+uint32(len(data)))
+		data = append(append(data, id...), 0)
+	}
+	for _, id := range ids {
+		table = append(table, uint32(len(entries[id])), dataOffset+uint32(len(data)))
+		data = append(append(data, entries[id]...), 0)
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, []uint32{MoMagicLittleEndian, 0, n, idsOffset, strsOffset, 0, 0})
+	binary.Write(&buf, binary.LittleEndian, table)
+	buf.Write(data)
+
+	return buf.Bytes()
+}
+
+func TestMoParseReader(t *testing.T) {
+	f, err := os.Open("fixtures/en_US/default.mo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	mo := new(Mo)
+	if err := mo.ParseReaderE(f); err != nil {
+		t.Fatal(err)
+	}
+	if tr := mo.Get("My text"); tr != "Translated text" {
+		t.Errorf("Expected 'Translated text' but got '%s'", tr)
+	}
+
+	// Read errors are returned as is
+	if err := new(Mo).ParseReaderE(iotest.ErrReader(io.ErrClosedPipe)); err != io.ErrClosedPipe {
+		t.Errorf("Expected io.ErrClosedPipe but got '%v'", err)
+	}
+}
+
+func TestMoParseCorrupt(t *testing.T) {
+	data, err := ioutil.ReadFile("fixtures/de/default.mo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// corrupt returns a copy of data with the number at offset off replaced by v
+	corrupt := func(off int, v uint32) []byte {
+		bad := append([]byte{}, data...)
+		binary.LittleEndian.PutUint32(bad[off:], v)
+		return bad
+	}
+
+	idsOffset := int(binary.LittleEndian.Uint32(data[12:]))
+	strsOffset := int(binary.LittleEndian.Uint32(data[16:]))
+	for name, bad := range map[string][]byte{
+		"short header":   data[:20],
+		"huge count":     corrupt(8, 0xffffffff),
+		"msgid table":    corrupt(12, uint32(len(data)-8)),
+		"msgstr table":   corrupt(16, 0xfffffff0),
+		"hash table":     corrupt(24, uint32(len(data))),
+		"msgid offset":   corrupt(idsOffset+12, uint32(len(data))),
+		"msgstr length":  corrupt(strsOffset+24, 0xffffffff),
+		"truncated data": data[:len(data)-10],
+	} {
+		mo := new(Mo)
+		mo.Parse([]byte(str1Mo))
+
+		err := mo.ParseE(bad)
+		if !errors.Is(err, ErrCorruptMo) || !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%s: expected ErrCorruptMo but got '%v'", name, err)
+		}
+
+		// The previous catalog is untouched
+		if tr := mo.Get("Hello"); tr != "Hallo" {
+			t.Errorf("%s: expected 'Hallo' but got '%s'", name, tr)
+		}
+		if tr := mo.Get("My text"); tr != "My text" {
+			t.Errorf("%s: expected no new translations but got '%s'", name, tr)
+		}
+
+		if err := new(LazyMo).ParseE(bad); err == nil {
+			new(LazyMo).Get("My text")
+		}
+	}
+}
+
+func TestMoParseOverlapping(t *testing.T) {
+	// Every msgid and msgstr is the same long string
+	const n, length = 1000, 4000
+	data := make([]byte, moHeaderSize+n*16+length)
+	for i, v := range []uint32{MoMagicLittleEndian, 0, n, moHeaderSize, moHeaderSize + n*8} {
+		binary.LittleEndian.PutUint32(data[i*4:], v)
+	}
+	for i := 0; i < n*2; i++ {
+		binary.LittleEndian.PutUint32(data[moHeaderSize+i*8:], length)
+		binary.LittleEndian.PutUint32(data[moHeaderSize+i*8+4:], moHeaderSize+n*16)
+	}
+
+	if err := new(Mo).ParseE(data); !errors.Is(err, ErrCorruptMo) {
+		t.Errorf("Expected ErrCorruptMo but got '%v'", err)
+	}
+}
+
+// str1Mo is a small valid MO file.
+var str1Mo = buildMo(map[string]string{"": "Language: de\n", "Hello": "Hallo"})
+
+func FuzzMoParse(f *testing.F) {
+	data, err := ioutil.ReadFile("fixtures/de/default.mo")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Add(str1Mo)
+	f.Add(data[:30])
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Must never panic
+		mo := new(Mo)
+		if err := mo.ParseE(data); err == nil {
+			mo.Get("My text")
+		}
+
+		lazy := new(LazyMo)
+		if err := lazy.ParseE(data); err == nil {
+			lazy.GetN("My text", "My texts", 2)
+			lazy.MarshalBinary()
+		}
+	})
+}
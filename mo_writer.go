@@ -0,0 +1,286 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// moHeaderSize is the size of the revision 0 MO header.
+const moHeaderSize = 28
+
+/*
+MoEncoder writes catalogs as GNU MO files, like msgfmt does.
+Any Translator can be encoded, as its entries are read through MarshalBinary.
+
+Example:
+
+	import (
+		"os"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		po := new(gotext.Po)
+		po.ParseFile("/path/to/po/file/translations.po")
+
+		f, _ := os.Create("/path/to/po/file/translations.mo")
+		defer f.Close()
+
+		gotext.NewMoEncoder(f).Encode(po)
+	}
+*/
+type MoEncoder struct {
+	// ByteOrder of the written file, binary.LittleEndian by default.
+	ByteOrder binary.ByteOrder
+
+	// HashTable adds the hash table GNU gettext uses to speed up lookups. It's enabled by default.
+	HashTable bool
+
+	w io.Writer
+}
+
+// NewMoEncoder returns a MoEncoder writing little endian MO files with a hash table to w.
+func NewMoEncoder(w io.Writer) *MoEncoder {
+	return &MoEncoder{
+		ByteOrder: binary.LittleEndian,
+		HashTable: true,
+		w:         w,
+	}
+}
+
+// MarshalMo returns the catalog of t as the content of a little endian MO file with a hash table.
+func MarshalMo(t Translator) ([]byte, error) {
+	var buff bytes.Buffer
+	err := NewMoEncoder(&buff).Encode(t)
+
+	return buff.Bytes(), err
+}
+
+// moEntry is an encoded msgid/msgstr pair.
+type moEntry struct {
+	key   string
+	value string
+}
+
+// Encode writes the catalog of t as a revision 0 MO file.
+// Untranslated and fuzzy entries are left out, except for the header entry, as msgfmt does.
+// Keys are sorted, contexts are joined to their msgid with EotSeparator,
+// and plural ids and forms are joined with NulSeparator.
+// Plural entries get the nplurals forms of the catalog, and the ones with other forms
+// return an error wrapping ErrPluralCount, without writing anything.
+func (enc *MoEncoder) Encode(t Translator) error {
+	te, err := encodeTranslator(t)
+	if err != nil {
+		return err
+	}
+	nplurals := formCount(te.Nplurals)
+
+	// Collect entries
+	var entries []moEntry
+	add := func(ctx string, tr *Translation) {
+		if tr.Trs[0] == "" || tr.IsFuzzy() && (ctx != "" || tr.ID != "") {
+			return
+		}
+
+		key := tr.ID
+		if ctx != "" {
+			key = ctx + EotSeparator + key
+		}
+
+		value := tr.Trs[0]
+		if tr.PluralID != "" {
+			key += NulSeparator + tr.PluralID
+
+			forms, extra := tr.forms(nplurals)
+			if len(extra) > 0 && err == nil {
+				err = fmt.Errorf("%w: %s has msgstr[%d], but nplurals is %d", ErrPluralCount, entryString(ctx, tr.ID), extra[0], nplurals)
+			}
+			value = strings.Join(forms, NulSeparator)
+		}
+
+		entries = append(entries, moEntry{key: key, value: value})
+	}
+	for _, tr := range te.Translations {
+		add("", tr)
+	}
+	for ctx, trs := range te.Contexts {
+		for _, tr := range trs {
+			add(ctx, tr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	return enc.write(entries)
+}
+
+// write writes the sorted entries as a MO file.
+func (enc *MoEncoder) write(entries []moEntry) error {
+	bo := enc.ByteOrder
+	if bo == nil {
+		bo = binary.LittleEndian
+	}
+
+	n := uint32(len(entries))
+	hashSize := uint32(0)
+	if enc.HashTable {
+		hashSize = moHashSize(n)
+	}
+
+	idsOffset := uint32(moHeaderSize)
+	strsOffset := idsOffset + n*8
+	hashOffset := strsOffset + n*8
+	dataOffset := hashOffset + hashSize*4
+
+	// Header
+	table := []uint32{MoMagicLittleEndian, 0, n, idsOffset, strsOffset, hashSize, hashOffset}
+
+	// Strings tables, with all msgids followed by all msgstrs
+	offset := dataOffset
+	for _, e := range entries {
+		table = append(table, uint32(len(e.key)), offset)
+		offset += uint32(len(e.key)) + 1
+	}
+	for _, e := range entries {
+		table = append(table, uint32(len(e.value)), offset)
+		offset += uint32(len(e.value)) + 1
+	}
+
+	// Hash table
+	if hashSize > 0 {
+		table = append(table, moHashTable(entries, hashSize)...)
+	}
+
+	var buff bytes.Buffer
+	buff.Grow(int(offset))
+	if err := binary.Write(&buff, bo, table); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		buff.WriteString(e.key)
+		buff.WriteByte(0)
+	}
+	for _, e := range entries {
+		buff.WriteString(e.value)
+		buff.WriteByte(0)
+	}
+
+	_, err := buff.WriteTo(enc.w)
+
+	return err
+}
+
+// moHashSize returns the size of the hash table for n entries, as msgfmt computes it.
+func moHashSize(n uint32) uint32 {
+	size := nextPrime(n * 4 / 3)
+	if size <= 2 {
+		size = 3
+	}
+
+	return size
+}
+
+// moHashTable builds the GNU hash table of the sorted entries, using open addressing with double hashing.
+// Cells hold the 1-based index of the entries, 0 being an empty cell.
+func moHashTable(entries []moEntry, size uint32) []uint32 {
+	table := make([]uint32, size)
+
+	for i, e := range entries {
+		// Only the msgid, and its context, are hashed
+		key := e.key
+		if j := strings.IndexByte(key, 0); j != -1 {
+			key = key[:j]
+		}
+
+		h := hashString(key)
+		idx := h % size
+		if table[idx] != 0 {
+			incr := 1 + h%(size-2)
+			for table[idx] != 0 {
+				if idx >= size-incr {
+					idx -= size - incr
+				} else {
+					idx += incr
+				}
+			}
+		}
+		table[idx] = uint32(i) + 1
+	}
+
+	return table
+}
+
+// hashString is the hashpjw function used by GNU gettext on MO hash tables.
+func hashString(s string) uint32 {
+	var h uint64
+	for i := 0; i < len(s); i++ {
+		h = h<<4 + uint64(s[i])
+		if g := h & ^uint64(0xfffffff); g != 0 {
+			h ^= g >> 24
+			h ^= g
+		}
+	}
+
+	return uint32(h)
+}
+
+// nextPrime returns the smallest odd prime number greater or equal to seed.
+func nextPrime(seed uint32) uint32 {
+	seed |= 1
+	for !isPrime(seed) {
+		seed += 2
+	}
+
+	return seed
+}
+
+func isPrime(n uint32) bool {
+	if n < 2 {
+		return false
+	}
+	for d := uint32(2); d*d <= n; d++ {
+		if n%d == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encodeTranslator returns the TranslatorEncoding of t, to read the entries of any Translator.
+func encodeTranslator(t Translator) (*TranslatorEncoding, error) {
+	data, err := t.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	te := new(TranslatorEncoding)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(te); err != nil {
+		return nil, err
+	}
+
+	return te, nil
+}
+
+// headerEntry returns the header entry msgstr of te, which its headers were parsed from.
+func headerEntry(te *TranslatorEncoding) string {
+	if tr, ok := te.Translations[""]; ok {
+		return tr.Trs[0]
+	}
+
+	return ""
+}
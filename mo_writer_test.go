@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+func TestMarshalMo(t *testing.T) {
+	// Same output as msgfmt
+	po := new(Po)
+	if err := po.ParseFileE("fixtures/de/default.po"); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ioutil.ReadFile("fixtures/de/default.mo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := MarshalMo(po)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, expected) {
+		t.Error("Expected the same output as msgfmt")
+	}
+
+	// Mo objects can be encoded too
+	mo := new(Mo)
+	mo.Parse(expected)
+	out, err = MarshalMo(mo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, expected) {
+		t.Error("Expected the same output from a Mo object")
+	}
+}
+
+func TestMoEncoder(t *testing.T) {
+	// Set PO content
+	str := `msgid ""
+msgstr ""
+"Language: de\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "Hello"
+msgstr "Hallo"
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "Eine Datei"
+msgstr[1] "%d Dateien"
+
+msgctxt "Menu"
+msgid "Open"
+msgstr "Öffnen"
+
+msgid "Untranslated"
+msgstr ""
+
+#, fuzzy
+msgid "Fuzzy"
+msgstr "Unscharf"
+`
+	po := new(Po)
+	if err := po.ParseE([]byte(str)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bo := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, hash := range []bool{true, false} {
+			var buff bytes.Buffer
+			enc := NewMoEncoder(&buff)
+			enc.ByteOrder = bo
+			enc.HashTable = hash
+			if err := enc.Encode(po); err != nil {
+				t.Fatal(err)
+			}
+
+			data := buff.Bytes()
+			if magic := bo.Uint32(data); magic != MoMagicLittleEndian {
+				t.Errorf("Unexpected magic number %x for %v", magic, bo)
+			}
+			if n := bo.Uint32(data[8:]); n != 4 {
+				t.Errorf("Expected 4 entries but got %d", n)
+			}
+			if size := bo.Uint32(data[20:]); hash != (size == 5) {
+				t.Errorf("Unexpected hash table size %d", size)
+			}
+
+			mo := new(Mo)
+			if err := mo.ParseE(data); err != nil {
+				t.Fatal(err)
+			}
+			if tr := mo.Get("Hello"); tr != "Hallo" {
+				t.Errorf("Expected 'Hallo' but got '%s'", tr)
+			}
+			if tr := mo.GetN("One file", "%d files", 3, 3); tr != "3 Dateien" {
+				t.Errorf("Expected '3 Dateien' but got '%s'", tr)
+			}
+			if tr := mo.GetC("Open", "Menu"); tr != "Öffnen" {
+				t.Errorf("Expected 'Öffnen' but got '%s'", tr)
+			}
+			if tr := mo.Get("Fuzzy"); tr != "Fuzzy" {
+				t.Errorf("Expected fuzzy entries to be left out but got '%s'", tr)
+			}
+			if mo.Language != "de" {
+				t.Errorf("Expected 'de' but got '%s'", mo.Language)
+			}
+		}
+	}
+}
+
+func TestHashString(t *testing.T) {
+	// Values computed by the GNU gettext hash_string function
+	for s, h := range map[string]uint32{
+		"":        0,
+		"a":       0x61,
+		"ab":      0x672,
+		"My text": 0x04b7aca4,
+	} {
+		if v := hashString(s); v != h {
+			t.Errorf("Expected %#x for %q but got %#x", h, s, v)
+		}
+	}
+}
+
+func TestMoEncoderForms(t *testing.T) {
+	po := new(Po)
+	if err := po.ParseE([]byte(`msgid ""
+msgstr ""
+"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n==2 ? 1 : 2);\n"
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "Ein Datei"
+msgstr[1] "Zwei Dateien"
+`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Missing forms are written empty
+	out, err := MarshalMo(po)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte("Ein Datei\x00Zwei Dateien\x00\x00")) {
+		t.Errorf("Expected 3 forms in %q", out)
+	}
+
+	// Forms out of nplurals aren't written
+	po.GetTranslation("One file").Trs[20000000] = "Viele Dateien"
+	var buff bytes.Buffer
+	if err := NewMoEncoder(&buff).Encode(po); !errors.Is(err, ErrPluralCount) {
+		t.Errorf("Expected ErrPluralCount but got '%v'", err)
+	}
+	if buff.Len() != 0 {
+		t.Errorf("Expected no output but got %d bytes", buff.Len())
+	}
+}
@@ -359,7 +359,7 @@ func tokenize(s string) []string {
 
 		Eg: (foo) -> true; (foo)(bar) -> false;
 	*/
-	if s[0] == '(' && s[len(s)-1] == ')' {
+	if len(s) > 0 && s[0] == '(' && s[len(s)-1] == ')' {
 		s = s[1 : len(s)-1]
 	}
 	ret := []string{}
@@ -381,6 +381,9 @@ func tokenize(s string) []string {
 
 // Compile a string containing a plural form expression to a Expression object.
 func Compile(s string) (expr Expression, err error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, errors.New("empty plural expression")
+	}
 	if s == "0" {
 		return constValue{value: 0}, nil
 	}
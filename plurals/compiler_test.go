@@ -48,3 +48,11 @@ func TestCompiler(t *testing.T) {
 		}
 	}
 }
+
+func TestCompileEmpty(t *testing.T) {
+	for _, s := range []string{"", " ", "\t\n"} {
+		if expr, err := Compile(s); err == nil || expr != nil {
+			t.Errorf("Expected an error for %q but got %v", s, expr)
+		}
+	}
+}
@@ -9,7 +9,8 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/gob"
-	"io/ioutil"
+	"fmt"
+	"io"
 	"net/textproto"
 	"os"
 	"strconv"
@@ -62,23 +63,31 @@ type Po struct {
 	translations map[string]*Translation
 	contexts     map[string]map[string]*Translation
 
+	// Obsolete entries (#~), never used on lookups
+	obsolete []*Translation
+
+	// Use entries flagged as fuzzy on lookups
+	allowFuzzy bool
+
+	// Charset forced by SetCharset, instead of the one declared on the headers
+	charset string
+
+	// Duplicate definitions found by the last parse, reported by Validate
+	duplicates []Diagnostic
+
+	// Positions of the parsed entries in their PO source, reported by Validate and kept by WriteTo
+	positions map[*Translation]poPosition
+	parsed    int
+
 	// Sync Mutex
 	sync.RWMutex
-
-	// Parsing buffers
-	trBuffer  *Translation
-	ctxBuffer string
 }
 
-type parseState int
-
-const (
-	head parseState = iota
-	msgCtxt
-	msgID
-	msgIDPlural
-	msgStr
-)
+// poPosition is the position of an entry in its PO source, and the order it was parsed in.
+type poPosition struct {
+	line, column int
+	order        int
+}
 
 // NewPoTranslator creates a new Po object with the Translator interface
 func NewPoTranslator() Translator {
@@ -86,29 +95,64 @@ func NewPoTranslator() Translator {
 }
 
 // ParseFile tries to read the file by its provided path (f) and parse its content as a .po file.
+// Gzip compressed files, like translations.po.gz, are decompressed.
 func (po *Po) ParseFile(f string) {
+	po.ParseFileE(f)
+}
+
+// ParseFileE works like ParseFile, but returns an error when the file can't be read or parsed.
+// A missing file or a directory path returns an error wrapping ErrNotFound.
+func (po *Po) ParseFileE(f string) error {
 	// Check if file exists
 	info, err := os.Stat(f)
 	if err != nil {
-		return
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrNotFound, f)
+		}
+		return err
 	}
 
 	// Check that isn't a directory
 	if info.IsDir() {
-		return
+		return fmt.Errorf("%w: %s is a directory", ErrNotFound, f)
 	}
 
-	// Parse file content
-	data, err := ioutil.ReadFile(f)
+	// Parse file content, decompressed when needed
+	fd, err := openFile(nil, f)
 	if err != nil {
-		return
+		return err
 	}
+	defer fd.Close()
 
-	po.Parse(data)
+	return withFile(po.ParseReaderE(fd), f)
 }
 
 // Parse loads the translations specified in the provided string (str)
 func (po *Po) Parse(buf []byte) {
+	po.ParseE(buf)
+}
+
+// ParseE works like Parse, but returns a *ParseError describing the first syntax error found.
+// Parsing doesn't stop on errors: every entry that can be recovered is still loaded.
+//
+// Entries are converted to UTF-8 from the charset declared on the Content-Type header,
+// or the one set with SetCharset, and the header is updated to declare UTF-8.
+// An error wrapping ErrUnsupportedCharset is returned when the charset isn't supported,
+// and the entries are then loaded unconverted.
+func (po *Po) ParseE(buf []byte) error {
+	return po.ParseReaderE(bytes.NewReader(buf))
+}
+
+// ParseReader loads the translations read from r, line by line,
+// so big catalogs can be parsed from pipes, HTTP bodies or decompressors without buffering them whole.
+// It produces the same catalog as Parse.
+func (po *Po) ParseReader(r io.Reader) {
+	po.ParseReaderE(r)
+}
+
+// ParseReaderE works like ParseReader, but returns the error found reading r,
+// or a *ParseError describing the first syntax error found, as ParseE does.
+func (po *Po) ParseReaderE(r io.Reader) error {
 	// Lock while parsing
 	po.Lock()
 
@@ -117,190 +161,42 @@ func (po *Po) Parse(buf []byte) {
 		po.translations = make(map[string]*Translation)
 		po.contexts = make(map[string]map[string]*Translation)
 	}
+	if po.positions == nil {
+		po.positions = make(map[*Translation]poPosition)
+	}
+	po.duplicates = nil
 
-	// Get lines
-	lines := strings.Split(string(buf), "\n")
-
-	// Init buffer
-	po.trBuffer = NewTranslation()
-	po.ctxBuffer = ""
-
-	state := head
-	for _, l := range lines {
-		// Trim spaces
-		l = strings.TrimSpace(l)
-
-		// Skip invalid lines
-		if !po.isValidLine(l) {
-			continue
-		}
-
-		// Buffer context and continue
-		if strings.HasPrefix(l, "msgctxt") {
-			po.parseContext(l)
-			state = msgCtxt
-			continue
-		}
+	p := newPoParser(po)
 
-		// Buffer msgid and continue
-		if strings.HasPrefix(l, "msgid") && !strings.HasPrefix(l, "msgid_plural") {
-			po.parseID(l)
-			state = msgID
-			continue
-		}
-
-		// Check for plural form
-		if strings.HasPrefix(l, "msgid_plural") {
-			po.parsePluralID(l)
-			state = msgIDPlural
-			continue
-		}
+	// Parse line by line
+	var rerr error
+	br := bufio.NewReader(r)
+	for n := 1; rerr == nil; n++ {
+		var l string
+		l, rerr = br.ReadString('\n')
+		p.parseLine(l, n)
+	}
 
-		// Save Translation
-		if strings.HasPrefix(l, "msgstr") {
-			po.parseMessage(l)
-			state = msgStr
-			continue
-		}
+	// Save last entry
+	p.flush()
 
-		// Multi line strings and headers
-		if strings.HasPrefix(l, "\"") && strings.HasSuffix(l, "\"") {
-			po.parseString(l, state)
-			continue
-		}
+	perr := p.err
+	if rerr != io.EOF {
+		perr = rerr
 	}
 
-	// Save last Translation buffer.
-	po.saveBuffer()
+	// Report unsupported charsets
+	if perr == nil {
+		perr = p.csErr
+	}
 
 	// Unlock to parse headers
 	po.Unlock()
 
 	// Parse headers
 	po.parseHeaders()
-}
 
-// saveBuffer takes the context and Translation buffers
-// and saves it on the translations collection
-func (po *Po) saveBuffer() {
-	// With no context...
-	if po.ctxBuffer == "" {
-		po.translations[po.trBuffer.ID] = po.trBuffer
-	} else {
-		// With context...
-		if _, ok := po.contexts[po.ctxBuffer]; !ok {
-			po.contexts[po.ctxBuffer] = make(map[string]*Translation)
-		}
-		po.contexts[po.ctxBuffer][po.trBuffer.ID] = po.trBuffer
-
-		// Cleanup current context buffer if needed
-		if po.trBuffer.ID != "" {
-			po.ctxBuffer = ""
-		}
-	}
-
-	// Flush Translation buffer
-	po.trBuffer = NewTranslation()
-}
-
-// parseContext takes a line starting with "msgctxt",
-// saves the current Translation buffer and creates a new context.
-func (po *Po) parseContext(l string) {
-	// Save current Translation buffer.
-	po.saveBuffer()
-
-	// Buffer context
-	po.ctxBuffer, _ = strconv.Unquote(strings.TrimSpace(strings.TrimPrefix(l, "msgctxt")))
-}
-
-// parseID takes a line starting with "msgid",
-// saves the current Translation and creates a new msgid buffer.
-func (po *Po) parseID(l string) {
-	// Save current Translation buffer.
-	po.saveBuffer()
-
-	// Set id
-	po.trBuffer.ID, _ = strconv.Unquote(strings.TrimSpace(strings.TrimPrefix(l, "msgid")))
-}
-
-// parsePluralID saves the plural id buffer from a line starting with "msgid_plural"
-func (po *Po) parsePluralID(l string) {
-	po.trBuffer.PluralID, _ = strconv.Unquote(strings.TrimSpace(strings.TrimPrefix(l, "msgid_plural")))
-}
-
-// parseMessage takes a line starting with "msgstr" and saves it into the current buffer.
-func (po *Po) parseMessage(l string) {
-	l = strings.TrimSpace(strings.TrimPrefix(l, "msgstr"))
-
-	// Check for indexed Translation forms
-	if strings.HasPrefix(l, "[") {
-		idx := strings.Index(l, "]")
-		if idx == -1 {
-			// Skip wrong index formatting
-			return
-		}
-
-		// Parse index
-		i, err := strconv.Atoi(l[1:idx])
-		if err != nil {
-			// Skip wrong index formatting
-			return
-		}
-
-		// Parse Translation string
-		po.trBuffer.Trs[i], _ = strconv.Unquote(strings.TrimSpace(l[idx+1:]))
-
-		// Loop
-		return
-	}
-
-	// Save single Translation form under 0 index
-	po.trBuffer.Trs[0], _ = strconv.Unquote(l)
-}
-
-// parseString takes a well formatted string without prefix
-// and creates headers or attach multi-line strings when corresponding
-func (po *Po) parseString(l string, state parseState) {
-	clean, _ := strconv.Unquote(l)
-
-	switch state {
-	case msgStr:
-		// Append to last Translation found
-		po.trBuffer.Trs[len(po.trBuffer.Trs)-1] += clean
-
-	case msgID:
-		// Multiline msgid - Append to current id
-		po.trBuffer.ID += clean
-
-	case msgIDPlural:
-		// Multiline msgid - Append to current id
-		po.trBuffer.PluralID += clean
-
-	case msgCtxt:
-		// Multiline context - Append to current context
-		po.ctxBuffer += clean
-
-	}
-}
-
-// isValidLine checks for line prefixes to detect valid syntax.
-func (po *Po) isValidLine(l string) bool {
-	// Check prefix
-	valid := []string{
-		"\"",
-		"msgctxt",
-		"msgid",
-		"msgid_plural",
-		"msgstr",
-	}
-
-	for _, v := range valid {
-		if strings.HasPrefix(l, v) {
-			return true
-		}
-	}
-
-	return false
+	return perr
 }
 
 // parseHeaders retrieves data from previously parsed headers
@@ -374,6 +270,45 @@ func (po *Po) pluralForm(n int) int {
 	return po.pluralforms.Eval(uint32(n))
 }
 
+// SetCharset forces the charset used to convert the parsed content to UTF-8,
+// ignoring the one declared on the Content-Type header. Use "" to restore the default.
+// It applies to the content parsed after the call.
+func (po *Po) SetCharset(cs string) {
+	po.Lock()
+	po.charset = cs
+	po.Unlock()
+}
+
+// GetCharset returns the charset forced with SetCharset.
+func (po *Po) GetCharset() string {
+	po.RLock()
+	defer po.RUnlock()
+
+	return po.charset
+}
+
+// SetAllowFuzzy sets whether entries flagged as fuzzy are used on lookups.
+// Fuzzy entries are skipped by default, falling back to the msgid as GNU msgfmt does.
+func (po *Po) SetAllowFuzzy(allow bool) {
+	po.Lock()
+	po.allowFuzzy = allow
+	po.Unlock()
+}
+
+// GetAllowFuzzy returns whether entries flagged as fuzzy are used on lookups.
+func (po *Po) GetAllowFuzzy() bool {
+	po.RLock()
+	defer po.RUnlock()
+
+	return po.allowFuzzy
+}
+
+// isUsable reports whether tr can be used on lookups.
+// Fuzzy entries are skipped unless allowed, except for the header entry.
+func (po *Po) isUsable(tr *Translation) bool {
+	return po.allowFuzzy || tr.ID == "" || !tr.IsFuzzy()
+}
+
 // Get retrieves the corresponding Translation for the given string.
 // Supports optional parameters (vars... interface{}) to be inserted on the formatted string using the fmt.Printf syntax.
 func (po *Po) Get(str string, vars ...interface{}) string {
@@ -382,8 +317,8 @@ func (po *Po) Get(str string, vars ...interface{}) string {
 	defer po.RUnlock()
 
 	if po.translations != nil {
-		if _, ok := po.translations[str]; ok {
-			return Printf(po.translations[str].Get(), vars...)
+		if tr, ok := po.translations[str]; ok && po.isUsable(tr) {
+			return Printf(tr.Get(), vars...)
 		}
 	}
 
@@ -399,8 +334,8 @@ func (po *Po) GetN(str, plural string, n int, vars ...interface{}) string {
 	defer po.RUnlock()
 
 	if po.translations != nil {
-		if _, ok := po.translations[str]; ok {
-			return Printf(po.translations[str].GetN(po.pluralForm(n)), vars...)
+		if tr, ok := po.translations[str]; ok && po.isUsable(tr) {
+			return Printf(tr.GetN(po.pluralForm(n)), vars...)
 		}
 	}
 
@@ -421,8 +356,8 @@ func (po *Po) GetC(str, ctx string, vars ...interface{}) string {
 	if po.contexts != nil {
 		if _, ok := po.contexts[ctx]; ok {
 			if po.contexts[ctx] != nil {
-				if _, ok := po.contexts[ctx][str]; ok {
-					return Printf(po.contexts[ctx][str].Get(), vars...)
+				if tr, ok := po.contexts[ctx][str]; ok && po.isUsable(tr) {
+					return Printf(tr.Get(), vars...)
 				}
 			}
 		}
@@ -442,8 +377,8 @@ func (po *Po) GetNC(str, plural string, n int, ctx string, vars ...interface{})
 	if po.contexts != nil {
 		if _, ok := po.contexts[ctx]; ok {
 			if po.contexts[ctx] != nil {
-				if _, ok := po.contexts[ctx][str]; ok {
-					return Printf(po.contexts[ctx][str].GetN(po.pluralForm(n)), vars...)
+				if tr, ok := po.contexts[ctx][str]; ok && po.isUsable(tr) {
+					return Printf(tr.GetN(po.pluralForm(n)), vars...)
 				}
 			}
 		}
@@ -456,6 +391,82 @@ func (po *Po) GetNC(str, plural string, n int, ctx string, vars ...interface{})
 	return Printf(plural, vars...)
 }
 
+// GetTranslation returns the Translation object for the given string, or nil if there isn't one.
+// It gives access to the comments, references and flags of the entry.
+// The returned object is shared with the Po object and must not be modified.
+func (po *Po) GetTranslation(str string) *Translation {
+	// Sync read
+	po.RLock()
+	defer po.RUnlock()
+
+	if po.translations == nil {
+		return nil
+	}
+
+	return po.translations[str]
+}
+
+// GetTranslationC returns the Translation object for the given string in the given context, or nil if there isn't one.
+// The returned object is shared with the Po object and must not be modified.
+func (po *Po) GetTranslationC(str, ctx string) *Translation {
+	// Sync read
+	po.RLock()
+	defer po.RUnlock()
+
+	if po.contexts == nil || po.contexts[ctx] == nil {
+		return nil
+	}
+
+	return po.contexts[ctx][str]
+}
+
+// GetObsolete returns the obsolete (#~) entries found while parsing, in file order.
+// Obsolete entries are never used on lookups, but they're kept so tools can revive or purge them.
+func (po *Po) GetObsolete() []*Translation {
+	// Sync read
+	po.RLock()
+	defer po.RUnlock()
+
+	return append([]*Translation(nil), po.obsolete...)
+}
+
+// SetObsolete replaces the obsolete entries. Use nil to purge them.
+func (po *Po) SetObsolete(trs []*Translation) {
+	po.Lock()
+	po.obsolete = append([]*Translation(nil), trs...)
+	po.Unlock()
+}
+
+// AddTranslation adds or replaces an entry, using its Context field to store it.
+// It can be used to revive an obsolete entry.
+func (po *Po) AddTranslation(tr *Translation) {
+	po.Lock()
+	defer po.Unlock()
+
+	// Init storage
+	if po.translations == nil {
+		po.translations = make(map[string]*Translation)
+		po.contexts = make(map[string]map[string]*Translation)
+	}
+
+	// The replaced entry has no position anymore
+	old := po.translations[tr.ID]
+	if tr.Context != "" {
+		old = po.contexts[tr.Context][tr.ID]
+	}
+	delete(po.positions, old)
+
+	if tr.Context == "" {
+		po.translations[tr.ID] = tr
+		return
+	}
+
+	if _, ok := po.contexts[tr.Context]; !ok {
+		po.contexts[tr.Context] = make(map[string]*Translation)
+	}
+	po.contexts[tr.Context][tr.ID] = tr
+}
+
 // MarshalBinary implements encoding.BinaryMarshaler interface
 func (po *Po) MarshalBinary() ([]byte, error) {
 	obj := new(TranslatorEncoding)
@@ -466,6 +477,7 @@ func (po *Po) MarshalBinary() ([]byte, error) {
 	obj.Plural = po.plural
 	obj.Translations = po.translations
 	obj.Contexts = po.contexts
+	obj.Obsolete = po.obsolete
 
 	var buff bytes.Buffer
 	encoder := gob.NewEncoder(&buff)
@@ -492,6 +504,7 @@ func (po *Po) UnmarshalBinary(data []byte) error {
 	po.plural = obj.Plural
 	po.translations = obj.Translations
 	po.contexts = obj.Contexts
+	po.obsolete = obj.Obsolete
 
 	if expr, err := plurals.Compile(po.plural); err == nil {
 		po.pluralforms = expr
@@ -0,0 +1,626 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	errUnexpectedLine    = errors.New("unexpected line")
+	errUnknownKeyword    = errors.New("unknown keyword")
+	errUnexpectedKeyword = errors.New("unexpected keyword")
+	errUnexpectedString  = errors.New("string without keyword")
+	errMissingString     = errors.New("keyword without string")
+	errBadString         = errors.New("invalid quoted string")
+	errBadEscape         = errors.New("invalid escape sequence")
+	errBadIndex          = errors.New("invalid msgstr index")
+	errMissingMsgid      = errors.New("missing msgid")
+	errMissingMsgstr     = errors.New("missing msgstr")
+	errHeaderPlural      = errors.New("header entry must not have msgid_plural")
+	errObsoleteMix       = errors.New("inconsistent use of #~")
+)
+
+// poMaxForms is the number of msgstr[N] forms an entry can have, far more than any language uses.
+const poMaxForms = 100
+
+type parseState int
+
+const (
+	head parseState = iota
+	msgCtxt
+	msgID
+	msgIDPlural
+	msgStr
+)
+
+// poToken is a keyword or a string found on a PO line.
+type poToken struct {
+	// Keyword name, or empty for strings
+	keyword string
+
+	// msgstr index, or -1 when the keyword has none
+	index int
+
+	// Unescaped string value
+	value string
+
+	// 1-based column
+	col int
+}
+
+// lexPoLine splits a line, from the byte offset start, in keyword and string tokens.
+// Anything after a '#' outside of a string is a comment and is ignored.
+// On errors, the tokens found before the problem are returned along with its column.
+func lexPoLine(l string, start int) ([]poToken, int, error) {
+	var toks []poToken
+
+	i := start
+	for i < len(l) {
+		c := l[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == '\v':
+			i++
+
+		case c == '#':
+			return toks, 0, nil
+
+		case c == '"':
+			s, n, err := lexPoString(l[i:])
+			if err != nil {
+				return toks, i + 1, err
+			}
+			toks = append(toks, poToken{index: -1, value: s, col: i + 1})
+			i += n
+
+		case isKeywordChar(c):
+			j := i
+			for j < len(l) && (isKeywordChar(l[j]) || l[j] >= '0' && l[j] <= '9') {
+				j++
+			}
+
+			tok := poToken{keyword: l[i:j], index: -1, col: i + 1}
+			switch tok.keyword {
+			case "msgctxt", "msgid", "msgid_plural":
+			case "msgstr":
+				// Optional plural index, like "msgstr[1]" or "msgstr [ 1 ]"
+				k := skipPoSpaces(l, j)
+				if k < len(l) && l[k] == '[' {
+					k = skipPoSpaces(l, k+1)
+					d := k
+					for k < len(l) && l[k] >= '0' && l[k] <= '9' {
+						k++
+					}
+					idx, err := strconv.Atoi(l[d:k])
+					k = skipPoSpaces(l, k)
+					if err != nil || k >= len(l) || l[k] != ']' {
+						return toks, i + 1, errBadIndex
+					}
+					tok.index = idx
+					j = k + 1
+				}
+			default:
+				return toks, i + 1, fmt.Errorf("%w %q", errUnknownKeyword, tok.keyword)
+			}
+
+			// Keywords must be followed by a space or a string
+			if j < len(l) && l[j] != '"' && skipPoSpaces(l, j) == j {
+				return toks, j + 1, errUnexpectedLine
+			}
+
+			toks = append(toks, tok)
+			i = j
+
+		default:
+			return toks, i + 1, errUnexpectedLine
+		}
+	}
+
+	return toks, 0, nil
+}
+
+func isKeywordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func skipPoSpaces(l string, i int) int {
+	for i < len(l) && (l[i] == ' ' || l[i] == '\t' || l[i] == '\r' || l[i] == '\n' || l[i] == '\f' || l[i] == '\v') {
+		i++
+	}
+	return i
+}
+
+// lexPoString reads the C string at the start of s and returns its unescaped value
+// and the number of bytes read.
+// Bytes are kept as is, so strings can be converted from the catalog charset later.
+func lexPoString(s string) (string, int, error) {
+	var sb strings.Builder
+
+	for i := 1; i < len(s); {
+		c := s[i]
+		switch c {
+		case '"':
+			return sb.String(), i + 1, nil
+
+		case '\\':
+			if i+1 >= len(s) {
+				return "", 0, errBadString
+			}
+
+			e := s[i+1]
+			i += 2
+			switch e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'a':
+				sb.WriteByte('\a')
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'v':
+				sb.WriteByte('\v')
+			case '\\', '"', '\'', '?':
+				sb.WriteByte(e)
+
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				// Up to 3 octal digits
+				v := int(e - '0')
+				for n := 1; n < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7'; n++ {
+					v = v*8 + int(s[i]-'0')
+					i++
+				}
+				if v > 0xff {
+					return "", 0, errBadEscape
+				}
+				sb.WriteByte(byte(v))
+
+			case 'x':
+				// Any number of hex digits, as C does
+				v, n := 0, 0
+				for ; i < len(s); i++ {
+					d := strings.IndexByte("0123456789abcdef", s[i]|0x20)
+					if d == -1 || s[i] < '0' {
+						break
+					}
+					v = v*16 + d
+					n++
+					if v > 0xff {
+						return "", 0, errBadEscape
+					}
+				}
+				if n == 0 {
+					return "", 0, errBadEscape
+				}
+				sb.WriteByte(byte(v))
+
+			default:
+				return "", 0, errBadEscape
+			}
+
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+
+	// Unterminated string
+	return "", 0, errBadString
+}
+
+// poParser assembles the tokens of a PO source in entries, following the GNU PO grammar:
+//
+//	[msgctxt string...] msgid string... (msgstr string... | msgid_plural string... msgstr[N] string...)
+//
+// Errors are recorded and parsing goes on, so every entry that can be recovered is loaded.
+type poParser struct {
+	po *Po
+
+	// Current entry
+	entry     *Translation
+	ctx       string
+	started   bool
+	obsolete  bool
+	hasID     bool
+	hasPlural bool
+	hasStr    bool
+	nextIndex int
+	line, col int
+
+	// Keyword receiving the following strings
+	state     parseState
+	index     int
+	hasString bool
+	skip      bool
+	kwLine    int
+	kwCol     int
+
+	// Comments and previous strings (#|) for the next entry
+	comments  *Translation
+	prevState parseState
+
+	// Entries found on this source, to detect duplicates
+	seen map[string]bool
+
+	// Charset conversion
+	cs    *charmap
+	csErr error
+
+	// First error found
+	err error
+}
+
+func newPoParser(po *Po) *poParser {
+	p := &poParser{
+		po:       po,
+		entry:    NewTranslation(),
+		comments: NewTranslation(),
+		seen:     make(map[string]bool),
+	}
+
+	if po.charset != "" {
+		p.cs, p.csErr = getCharmap(po.charset)
+	}
+
+	return p
+}
+
+// fail records err at the given position, if it's the first one.
+func (p *poParser) fail(line, col int, err error) {
+	if p.err == nil {
+		p.err = &ParseError{Line: line, Column: col, Err: err}
+	}
+}
+
+// parseLine parses the line number n.
+func (p *poParser) parseLine(l string, n int) {
+	start := skipPoSpaces(l, 0)
+	if start == len(l) {
+		return
+	}
+
+	obsolete := strings.HasPrefix(l[start:], "#~")
+	if obsolete {
+		start = skipPoSpaces(l, start+2)
+		if start == len(l) {
+			return
+		}
+	}
+
+	// Comments for the next entry
+	if l[start] == '#' || obsolete && l[start] == '|' {
+		if obsolete && l[start] == '|' {
+			// Previous strings of obsolete entries (#~|)
+			p.parsePrevious(l, start+1, n)
+			return
+		}
+		p.parseComment(l, start, n)
+		return
+	}
+
+	toks, col, err := lexPoLine(l, start)
+	for _, tok := range toks {
+		if tok.keyword == "" {
+			p.parseString(tok, n, obsolete)
+		} else {
+			p.parseKeyword(tok, n, obsolete)
+		}
+	}
+	if err != nil {
+		p.fail(n, col, err)
+
+		// Strings following a broken keyword are dropped
+		if len(toks) == 0 || toks[len(toks)-1].keyword == "" {
+			p.skip = true
+		}
+	}
+}
+
+// parseKeyword handles a keyword token found on the line n.
+func (p *poParser) parseKeyword(tok poToken, n int, obsolete bool) {
+	// Keywords starting a new entry
+	switch {
+	case tok.keyword == "msgctxt" || tok.keyword == "msgid" && (p.hasID || !p.started):
+		p.flush()
+		p.startEntry(n, tok.col, obsolete)
+
+	case obsolete != p.obsolete:
+		p.fail(n, tok.col, errObsoleteMix)
+	}
+
+	p.endKeyword()
+	p.skip = false
+
+	var state parseState
+	switch tok.keyword {
+	case "msgctxt":
+		state = msgCtxt
+
+	case "msgid":
+		state = msgID
+		p.hasID = true
+
+	case "msgid_plural":
+		if !p.hasID || p.hasPlural || p.hasStr {
+			p.unexpected(tok, n)
+			return
+		}
+		state = msgIDPlural
+		p.hasPlural = true
+
+	case "msgstr":
+		if !p.hasID || p.hasPlural != (tok.index >= 0) || !p.hasPlural && p.hasStr {
+			p.unexpected(tok, n)
+			return
+		}
+		state = msgStr
+
+		// Plural forms must be in order, the other ones are dropped with their strings
+		switch {
+		case tok.index < 0:
+			tok.index = 0
+		case tok.index >= poMaxForms:
+			p.fail(n, tok.col, fmt.Errorf("%w: more than %d plural forms", errBadIndex, poMaxForms))
+			p.state = head
+			p.skip = true
+			return
+		case tok.index != p.nextIndex:
+			p.fail(n, tok.col, fmt.Errorf("%w: expected msgstr[%d]", errBadIndex, p.nextIndex))
+			p.state = head
+			p.skip = true
+			return
+		}
+		p.hasStr = true
+		p.nextIndex = tok.index + 1
+		p.entry.Trs[tok.index] = ""
+	}
+
+	p.state = state
+	p.index = tok.index
+	p.hasString = false
+	p.kwLine, p.kwCol = n, tok.col
+}
+
+// unexpected reports a keyword out of place, and drops its strings.
+func (p *poParser) unexpected(tok poToken, n int) {
+	p.fail(n, tok.col, fmt.Errorf("%w %q", errUnexpectedKeyword, tok.keyword))
+	p.state = head
+	p.skip = true
+}
+
+// parseString appends a string token to the value of the last keyword.
+func (p *poParser) parseString(tok poToken, n int, obsolete bool) {
+	if p.skip {
+		return
+	}
+	if p.state == head {
+		p.fail(n, tok.col, errUnexpectedString)
+		p.skip = true
+		return
+	}
+	if obsolete != p.obsolete {
+		p.fail(n, tok.col, errObsoleteMix)
+	}
+
+	switch p.state {
+	case msgCtxt:
+		p.ctx += tok.value
+	case msgID:
+		p.entry.ID += tok.value
+	case msgIDPlural:
+		p.entry.PluralID += tok.value
+	case msgStr:
+		p.entry.Trs[p.index] += tok.value
+	}
+	p.hasString = true
+}
+
+// endKeyword checks the last keyword got a value.
+func (p *poParser) endKeyword() {
+	if p.state != head && !p.hasString {
+		p.fail(p.kwLine, p.kwCol, errMissingString)
+	}
+	p.state = head
+}
+
+// startEntry starts a new entry at the given position, attaching the buffered comments.
+func (p *poParser) startEntry(n, col int, obsolete bool) {
+	p.started = true
+	p.obsolete = obsolete
+	p.line, p.col = n, col
+
+	p.entry.TranslatorComments = p.comments.TranslatorComments
+	p.entry.ExtractedComments = p.comments.ExtractedComments
+	p.entry.References = p.comments.References
+	p.entry.Flags = p.comments.Flags
+	p.entry.PreviousContext = p.comments.PreviousContext
+	p.entry.PreviousID = p.comments.PreviousID
+	p.entry.PreviousPluralID = p.comments.PreviousPluralID
+	p.comments = NewTranslation()
+	p.prevState = head
+}
+
+// flush checks the current entry and saves it on the Po storage.
+func (p *poParser) flush() {
+	if !p.started {
+		return
+	}
+	p.endKeyword()
+
+	switch {
+	case !p.hasID:
+		p.fail(p.line, p.col, errMissingMsgid)
+	case !p.hasStr:
+		p.fail(p.line, p.col, errMissingMsgstr)
+	default:
+		p.save()
+	}
+
+	// Reset entry
+	p.entry = NewTranslation()
+	p.ctx = ""
+	p.started, p.obsolete, p.hasID, p.hasPlural, p.hasStr = false, false, false, false, false
+	p.nextIndex = 0
+	p.skip = false
+}
+
+// save stores the current entry, converted to UTF-8.
+func (p *poParser) save() {
+	tr := p.entry
+	tr.Context = p.ctx
+	p.po.positions[tr] = poPosition{line: p.line, column: p.col, order: p.po.parsed}
+	p.po.parsed++
+
+	// The header entry declares the charset of the following entries
+	header := !p.obsolete && tr.Context == "" && tr.ID == ""
+	if header {
+		if p.hasPlural {
+			p.fail(p.line, p.col, errHeaderPlural)
+		}
+		p.setCharset(tr.Trs[0])
+	}
+
+	// Convert to UTF-8
+	p.cs.decodeTranslation(tr)
+	if header && p.cs != nil {
+		tr.Trs[0] = setHeaderCharset(tr.Trs[0], "UTF-8")
+	}
+
+	// Obsolete entries go to their own collection
+	if p.obsolete {
+		p.po.obsolete = append(p.po.obsolete, tr)
+		return
+	}
+
+	// Duplicates replace the previous definition
+	key := tr.Context + EotSeparator + tr.ID
+	if p.seen[key] {
+		err := fmt.Errorf("%w: msgid %q", ErrDuplicate, tr.ID)
+		p.fail(p.line, p.col, err)
+		p.po.duplicates = append(p.po.duplicates, Diagnostic{
+			Line:    p.line,
+			Column:  p.col,
+			Context: tr.Context,
+			ID:      tr.ID,
+			Err:     err,
+		})
+	}
+	p.seen[key] = true
+
+	// The replaced entry has no position anymore
+	old := p.po.translations[tr.ID]
+	if tr.Context != "" {
+		old = p.po.contexts[tr.Context][tr.ID]
+	}
+	delete(p.po.positions, old)
+
+	if tr.Context == "" {
+		p.po.translations[tr.ID] = tr
+		return
+	}
+	if _, ok := p.po.contexts[tr.Context]; !ok {
+		p.po.contexts[tr.Context] = make(map[string]*Translation)
+	}
+	p.po.contexts[tr.Context][tr.ID] = tr
+}
+
+// setCharset sets the charset used to convert the entries from the raw header entry,
+// unless a charset has been forced with Po.SetCharset.
+func (p *poParser) setCharset(header string) {
+	if p.po.charset != "" {
+		return
+	}
+
+	var err error
+	p.cs, err = getCharmap(headerCharset(header))
+	if err != nil && p.csErr == nil {
+		p.csErr = err
+	}
+}
+
+// parseComment buffers the comment starting at l[start] as metadata for the next entry.
+func (p *poParser) parseComment(l string, start, n int) {
+	l = strings.TrimRight(l[start:], " \t\r\n")
+	if len(l) == 1 {
+		// Empty translator comment
+		p.comments.TranslatorComments = append(p.comments.TranslatorComments, "")
+		return
+	}
+
+	switch l[1] {
+	case '.':
+		p.comments.ExtractedComments = append(p.comments.ExtractedComments, strings.TrimSpace(l[2:]))
+
+	case ':':
+		p.comments.References = append(p.comments.References, strings.Fields(l[2:])...)
+
+	case ',':
+		for _, f := range strings.Split(l[2:], ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				p.comments.Flags = append(p.comments.Flags, f)
+			}
+		}
+
+	case '|':
+		p.parsePrevious(l, 2, n)
+
+	case '~':
+		// Comments inside obsolete entries
+
+	default:
+		p.comments.TranslatorComments = append(p.comments.TranslatorComments, strings.TrimPrefix(l[1:], " "))
+	}
+}
+
+// parsePrevious buffers the previous msgctxt, msgid or msgid_plural recorded by msgmerge
+// on a "#|" line, starting at l[start].
+func (p *poParser) parsePrevious(l string, start, n int) {
+	toks, col, err := lexPoLine(l, start)
+	if err != nil {
+		p.fail(n, col, err)
+	}
+
+	for _, tok := range toks {
+		switch tok.keyword {
+		case "msgctxt":
+			p.prevState = msgCtxt
+			p.comments.PreviousContext = ""
+			continue
+		case "msgid":
+			p.prevState = msgID
+			p.comments.PreviousID = ""
+			continue
+		case "msgid_plural":
+			p.prevState = msgIDPlural
+			p.comments.PreviousPluralID = ""
+			continue
+		case "":
+		default:
+			p.fail(n, tok.col, fmt.Errorf("%w %q", errUnexpectedKeyword, tok.keyword))
+			p.prevState = head
+			continue
+		}
+
+		// Strings are appended to the last keyword
+		switch p.prevState {
+		case msgCtxt:
+			p.comments.PreviousContext += tok.value
+		case msgID:
+			p.comments.PreviousID += tok.value
+		case msgIDPlural:
+			p.comments.PreviousPluralID += tok.value
+		default:
+			p.fail(n, tok.col, errUnexpectedString)
+		}
+	}
+}
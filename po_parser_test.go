@@ -0,0 +1,239 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLexPoString(t *testing.T) {
+	for in, out := range map[string]string{
+		`"plain"`:                 "plain",
+		`"tab\there"`:             "tab\there",
+		`"\a\b\f\n\r\t\v"`:        "\a\b\f\n\r\t\v",
+		`"quote \" \' \? \\"`:     `quote " ' ? \`,
+		`"octal \101\0\12"`:       "octal A\x00\n",
+		`"octal \1012"`:           "octal A2",
+		`"hex \x41\x7e\xff"`:      "hex A~\xff",
+		`"raw \xe9 bytes"`:        "raw \xe9 bytes",
+		`"latin1 ` + "\xe9" + `"`: "latin1 \xe9",
+		`"trailing" "ignored"`:    "trailing",
+		`""`:                      "",
+		`"utf-8 ünïcödé"`:         "utf-8 ünïcödé",
+	} {
+		s, _, err := lexPoString(in)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", in, err)
+			continue
+		}
+		if s != out {
+			t.Errorf("Expected %q but got %q", out, s)
+		}
+	}
+
+	for in, expected := range map[string]error{
+		`"unterminated`:    errBadString,
+		`"ends with \"`:    errBadString,
+		`"go only \u00e9"`: errBadEscape,
+		`"go only \U0001"`: errBadEscape,
+		`"unknown \e"`:     errBadEscape,
+		`"empty hex \x"`:   errBadEscape,
+		`"big hex \x100"`:  errBadEscape,
+		`"big octal \777"`: errBadEscape,
+	} {
+		if _, _, err := lexPoString(in); err != expected {
+			t.Errorf("Expected '%v' for %q but got '%v'", expected, in, err)
+		}
+	}
+}
+
+func TestLexPoLine(t *testing.T) {
+	toks, _, err := lexPoLine(`msgstr [ 2 ] "a" "b" # comment`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(toks) != 3 || toks[0].keyword != "msgstr" || toks[0].index != 2 || toks[1].value != "a" || toks[2].value != "b" || toks[2].col != 18 {
+		t.Errorf("Unexpected tokens: %+v", toks)
+	}
+
+	toks, _, err = lexPoLine(`msgid"joined"`, 0)
+	if err != nil || len(toks) != 2 || toks[0].keyword != "msgid" || toks[1].value != "joined" {
+		t.Errorf("Unexpected tokens %+v or error '%v'", toks, err)
+	}
+
+	for in, expected := range map[string]error{
+		`msgidfoo "x"`:   errUnknownKeyword,
+		`msgstr_x "x"`:   errUnknownKeyword,
+		`msgstr[abc] ""`: errBadIndex,
+		`msgstr[1 ""`:    errBadIndex,
+		`msgid[0] ""`:    errUnexpectedLine,
+		`msgid "x" 'y'`:  errUnexpectedLine,
+	} {
+		if _, _, err := lexPoLine(in, 0); !errors.Is(err, expected) {
+			t.Errorf("Expected '%v' for %q but got '%v'", expected, in, err)
+		}
+	}
+}
+
+func TestPoParserGrammar(t *testing.T) {
+	// Set PO content
+	str := `msgid ""
+msgstr ""
+"Language: en\n"
+"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+msgid
+"Keyword alone"
+msgstr "Keyword " "alone"
+
+msgid "Continued forms"
+msgid_plural "Continued forms plural"
+msgstr[0] "zero"
+" continued"
+msgstr[1] "one"
+
+# Interposed comment
+" continued"
+msgstr[2] "two"
+" continued"
+
+msgid "Escapes"
+msgstr "\101\x42\tC"
+`
+	po := new(Po)
+	if err := po.ParseE([]byte(str)); err != nil {
+		t.Fatal(err)
+	}
+
+	if tr := po.Get("Keyword alone"); tr != "Keyword alone" {
+		t.Errorf("Expected 'Keyword alone' but got '%s'", tr)
+	}
+
+	tr := po.GetTranslation("Continued forms")
+	for i, expected := range []string{"zero continued", "one continued", "two continued"} {
+		if tr.Trs[i] != expected {
+			t.Errorf("Expected '%s' but got '%s'", expected, tr.Trs[i])
+		}
+	}
+
+	if tr := po.GetTranslation("Escapes").Get(); tr != "AB\tC" {
+		t.Errorf("Expected 'AB\\tC' but got '%s'", tr)
+	}
+}
+
+func TestPoParserErrors(t *testing.T) {
+	for _, c := range []struct {
+		str       string
+		line, col int
+		err       error
+	}{
+		{"msgid \"a\"\nmsgstr \"b\"\nmsgidfoo \"c\"\n", 3, 1, errUnknownKeyword},
+		{"msgid \"a\"\nmsgstr \"\\u00e9\"\n", 2, 8, errBadEscape},
+		{"msgid \"a\"\nmsgstr \"b\"\n\"c\" \"d\"\n\"e\nf\"", 4, 1, errBadString},
+		{"\"orphan\"\nmsgid \"a\"\nmsgstr \"b\"\n", 1, 1, errUnexpectedString},
+		{"msgstr \"b\"\n", 1, 1, errUnexpectedKeyword},
+		{"msgid \"a\"\nmsgstr \"b\"\nmsgid_plural \"c\"\n", 3, 1, errUnexpectedKeyword},
+		{"msgid \"a\"\nmsgstr[0] \"b\"\n", 2, 1, errUnexpectedKeyword},
+		{"msgid \"a\"\nmsgid_plural \"b\"\nmsgstr \"c\"\n", 3, 1, errUnexpectedKeyword},
+		{"msgid \"a\"\nmsgid_plural \"b\"\nmsgstr[1] \"c\"\n", 3, 1, errBadIndex},
+		{"msgid \"a\"\nmsgid_plural \"b\"\nmsgstr[0] \"c\"\nmsgstr[20000000] \"d\"\n", 4, 1, errBadIndex},
+		{"msgid \"a\"\nmsgstr\n\nmsgid \"b\"\nmsgstr \"c\"\n", 2, 1, errMissingString},
+		{"msgid \"a\"\n\nmsgid \"b\"\nmsgstr \"c\"\n", 1, 1, errMissingMsgstr},
+		{"msgctxt \"a\"\nmsgctxt \"b\"\nmsgid \"b\"\nmsgstr \"c\"\n", 1, 1, errMissingMsgid},
+		{"msgid \"\"\nmsgid_plural \"\"\nmsgstr[0] \"\"\nmsgstr[1] \"\"\n", 1, 1, errHeaderPlural},
+		{"msgid \"a\"\nmsgstr \"b\"\n\nmsgid \"a\"\nmsgstr \"c\"\n", 4, 1, ErrDuplicate},
+		{"msgid \"a\"\n#~ msgstr \"b\"\n", 2, 4, errObsoleteMix},
+	} {
+		err := new(Po).ParseE([]byte(c.str))
+
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("Expected *ParseError for %q but got '%v'", c.str, err)
+			continue
+		}
+		if !errors.Is(err, c.err) || pe.Line != c.line || pe.Column != c.col {
+			t.Errorf("Expected '%v' at %d:%d for %q but got '%v'", c.err, c.line, c.col, c.str, err)
+		}
+	}
+}
+
+func TestPoParserRecovery(t *testing.T) {
+	// Set PO content
+	str := `msgid "Dup"
+msgstr "First"
+
+msgid "Broken"
+msgstr[abc] "Wrong"
+"dropped"
+
+msgid "Dup"
+msgstr "Last"
+
+msgctxt "Ctx"
+msgid "Dup"
+msgstr "Context"
+`
+	po := new(Po)
+	if err := po.ParseE([]byte(str)); err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	// Duplicates keep the last definition
+	if tr := po.Get("Dup"); tr != "Last" {
+		t.Errorf("Expected 'Last' but got '%s'", tr)
+	}
+	if tr := po.GetC("Dup", "Ctx"); tr != "Context" {
+		t.Errorf("Expected 'Context' but got '%s'", tr)
+	}
+
+	// Entries without msgstr are dropped
+	if tr := po.GetTranslation("Broken"); tr != nil {
+		t.Errorf("Expected no translation but got '%v'", tr)
+	}
+}
+
+func TestPoParserBadIndex(t *testing.T) {
+	// Set PO content
+	str := `msgid "Gap"
+msgid_plural "Gaps"
+msgstr[0] "Zero"
+msgstr[2] "Two"
+msgstr[1] "One"
+
+msgid "Huge"
+msgid_plural "Huges"
+msgstr[0] "Zero"
+msgstr[1] "One"
+msgstr[20000000] "Huge"
+msgstr[2] "Two"
+
+msgid "Late"
+msgid_plural "Lates"
+msgstr[1] "One"
+`
+	po := new(Po)
+	if err := po.ParseE([]byte(str)); !errors.Is(err, errBadIndex) {
+		t.Errorf("Expected errBadIndex but got '%v'", err)
+	}
+
+	// Forms out of order are dropped, not the following ones
+	for id, expected := range map[string]map[int]string{
+		"Gap":  {0: "Zero", 1: "One"},
+		"Huge": {0: "Zero", 1: "One", 2: "Two"},
+	} {
+		tr := po.GetTranslation(id)
+		if tr == nil || !reflect.DeepEqual(tr.Trs, expected) {
+			t.Errorf("Expected the forms %v for %s but got %v", expected, id, tr)
+		}
+	}
+
+	// Entries without valid form are dropped
+	if tr := po.GetTranslation("Late"); tr != nil {
+		t.Errorf("Expected no translation but got '%v'", tr)
+	}
+}
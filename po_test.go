@@ -6,9 +6,16 @@
 package gotext
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestPo_Get(t *testing.T) {
@@ -644,3 +651,419 @@ func TestPoBinaryEncoding(t *testing.T) {
 		t.Errorf("Expected 'en_US' but got '%s'", tr)
 	}
 }
+
+func TestPoParseFileE(t *testing.T) {
+	po := new(Po)
+
+	// Missing file
+	err := po.ParseFileE("fixtures/en_US/missing.po")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got '%v'", err)
+	}
+
+	// Directory
+	err = po.ParseFileE(path.Clean(os.TempDir()))
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got '%v'", err)
+	}
+
+	// Valid file
+	err = po.ParseFileE("fixtures/en_US/default.po")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tr := po.Get("My text"); tr != "Translated text" {
+		t.Errorf("Expected 'Translated text' but got '%s'", tr)
+	}
+}
+
+func TestPoParseE(t *testing.T) {
+	// Set PO content
+	str := `
+msgid "My text"
+msgstr "Translated text"
+
+msgid "Broken"
+msgid_plural "Brokens"
+msgstr[abc] "Wrong index"
+
+msgid "More"
+msgstr "More Translation"
+`
+	// Create po object
+	po := new(Po)
+	err := po.ParseE([]byte(str))
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected *ParseError but got '%v'", err)
+	}
+	if pe.Line != 7 || pe.Column != 1 {
+		t.Errorf("Expected error at 7:1 but got %d:%d", pe.Line, pe.Column)
+	}
+
+	// Recovered entries are still loaded
+	if tr := po.Get("More"); tr != "More Translation" {
+		t.Errorf("Expected 'More Translation' but got '%s'", tr)
+	}
+
+	// Valid content
+	po = new(Po)
+	err = po.ParseE([]byte(`msgid "One"
+msgstr "Uno"`))
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestPoTranslationMetadata(t *testing.T) {
+	// Set PO content
+	str := `
+msgid ""
+msgstr ""
+"Language: en\n"
+
+# Translator comment
+#
+#. Extracted comment
+#: src/main.go:12 src/util.go:7
+#: src/other.go:3
+#, c-format, no-wrap
+msgid "Hello %s"
+msgstr "Hi %s"
+
+#, fuzzy
+msgctxt "Ctx"
+msgid "Context string"
+msgstr "Context translation"
+
+msgid "No comments"
+msgstr "None"
+`
+	// Create po object
+	po := new(Po)
+	po.Parse([]byte(str))
+
+	tr := po.GetTranslation("Hello %s")
+	if tr == nil {
+		t.Fatal("Expected translation for greeting")
+	}
+	if !reflect.DeepEqual(tr.TranslatorComments, []string{"Translator comment", ""}) {
+		t.Errorf("Unexpected translator comments: %q", tr.TranslatorComments)
+	}
+	if !reflect.DeepEqual(tr.ExtractedComments, []string{"Extracted comment"}) {
+		t.Errorf("Unexpected extracted comments: %q", tr.ExtractedComments)
+	}
+	if !reflect.DeepEqual(tr.References, []string{"src/main.go:12", "src/util.go:7", "src/other.go:3"}) {
+		t.Errorf("Unexpected references: %q", tr.References)
+	}
+	if !tr.HasFlag("c-format") || !tr.HasFlag("no-wrap") || tr.HasFlag("fuzzy") {
+		t.Errorf("Unexpected flags: %q", tr.Flags)
+	}
+
+	tr = po.GetTranslationC("Context string", "Ctx")
+	if tr == nil {
+		t.Fatal("Expected translation for 'Context string' in 'Ctx'")
+	}
+	if !reflect.DeepEqual(tr.Flags, []string{"fuzzy"}) {
+		t.Errorf("Unexpected flags: %q", tr.Flags)
+	}
+
+	tr = po.GetTranslation("No comments")
+	if tr == nil {
+		t.Fatal("Expected translation for 'No comments'")
+	}
+	if len(tr.TranslatorComments)+len(tr.ExtractedComments)+len(tr.References)+len(tr.Flags) != 0 {
+		t.Errorf("Expected no metadata but got %+v", tr)
+	}
+
+	if po.GetTranslation("Missing") != nil {
+		t.Error("Expected nil for missing translation")
+	}
+	if po.GetTranslationC("Context string", "Other") != nil {
+		t.Error("Expected nil for missing context")
+	}
+}
+
+func TestPoFuzzy(t *testing.T) {
+	// Set PO content
+	str := `
+#, fuzzy
+msgid ""
+msgstr ""
+"Language: ru\n"
+"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+msgid "Reviewed"
+msgstr "Проверено"
+
+#, fuzzy
+msgid "Unreviewed"
+msgstr "Не проверено"
+
+#, fuzzy, c-format
+msgid "One file"
+msgid_plural "Many files"
+msgstr[0] "Один файл"
+msgstr[1] "Несколько файлов"
+msgstr[2] "Много файлов"
+
+#, fuzzy
+msgctxt "Ctx"
+msgid "Unreviewed"
+msgstr "Не проверено в контексте"
+`
+	// Create po object
+	po := new(Po)
+	po.Parse([]byte(str))
+
+	// Fuzzy header still provides Plural-Forms
+	if po.Language != "ru" {
+		t.Errorf("Expected 'ru' but got '%s'", po.Language)
+	}
+	if n := po.pluralForm(5); n != 2 {
+		t.Errorf("Expected 2 for pluralForm(5), got %d", n)
+	}
+
+	// Fuzzy entries fall back to msgid
+	if tr := po.Get("Reviewed"); tr != "Проверено" {
+		t.Errorf("Expected 'Проверено' but got '%s'", tr)
+	}
+	if tr := po.Get("Unreviewed"); tr != "Unreviewed" {
+		t.Errorf("Expected 'Unreviewed' but got '%s'", tr)
+	}
+	if tr := po.GetN("One file", "Many files", 5); tr != "Many files" {
+		t.Errorf("Expected 'Many files' but got '%s'", tr)
+	}
+	if tr := po.GetC("Unreviewed", "Ctx"); tr != "Unreviewed" {
+		t.Errorf("Expected 'Unreviewed' but got '%s'", tr)
+	}
+	if tr := po.GetNC("Unreviewed", "Unreviewed plural", 5, "Ctx"); tr != "Unreviewed plural" {
+		t.Errorf("Expected 'Unreviewed plural' but got '%s'", tr)
+	}
+
+	// Opt in
+	po.SetAllowFuzzy(true)
+	if !po.GetAllowFuzzy() {
+		t.Error("Expected fuzzy entries to be allowed")
+	}
+	if tr := po.Get("Unreviewed"); tr != "Не проверено" {
+		t.Errorf("Expected 'Не проверено' but got '%s'", tr)
+	}
+	if tr := po.GetN("One file", "Many files", 5); tr != "Много файлов" {
+		t.Errorf("Expected 'Много файлов' but got '%s'", tr)
+	}
+	if tr := po.GetC("Unreviewed", "Ctx"); tr != "Не проверено в контексте" {
+		t.Errorf("Expected 'Не проверено в контексте' but got '%s'", tr)
+	}
+}
+
+func TestPoObsolete(t *testing.T) {
+	// Set PO content
+	str := `
+msgid "Live"
+msgstr "Lebendig"
+
+# Old translator comment
+#, fuzzy
+#~ msgid "Gone"
+#~ msgstr ""
+#~ "Weg "
+#~ "damit"
+
+#~ msgctxt "Ctx"
+#~ msgid "Old"
+#~ msgid_plural "Olds"
+#~ msgstr[0] "Alt"
+#~ msgstr[1] "Alte"
+`
+	// Create po object
+	po := new(Po)
+	if err := po.ParseE([]byte(str)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lookups never use obsolete entries
+	if tr := po.Get("Gone"); tr != "Gone" {
+		t.Errorf("Expected 'Gone' but got '%s'", tr)
+	}
+	if tr := po.GetNC("Old", "Olds", 2, "Ctx"); tr != "Olds" {
+		t.Errorf("Expected 'Olds' but got '%s'", tr)
+	}
+	if tr := po.Get("Live"); tr != "Lebendig" {
+		t.Errorf("Expected 'Lebendig' but got '%s'", tr)
+	}
+
+	obs := po.GetObsolete()
+	if len(obs) != 2 {
+		t.Fatalf("Expected 2 obsolete entries but got %d", len(obs))
+	}
+	if obs[0].ID != "Gone" || obs[0].Get() != "Weg damit" || !obs[0].IsFuzzy() {
+		t.Errorf("Unexpected obsolete entry: %+v", obs[0])
+	}
+	if !reflect.DeepEqual(obs[0].TranslatorComments, []string{"Old translator comment"}) {
+		t.Errorf("Unexpected comments: %q", obs[0].TranslatorComments)
+	}
+	if obs[1].Context != "Ctx" || obs[1].PluralID != "Olds" || obs[1].GetN(1) != "Alte" {
+		t.Errorf("Unexpected obsolete entry: %+v", obs[1])
+	}
+
+	// Obsolete entries are written back
+	out, err := po.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "#~ msgctxt \"Ctx\"\n#~ msgid \"Old\"\n") {
+		t.Errorf("Expected obsolete entry in output:\n%s", out)
+	}
+	po2 := new(Po)
+	if err := po2.ParseE(out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(po2.GetObsolete(), obs) {
+		t.Errorf("Obsolete entries differ after writing:\n%s", out)
+	}
+
+	// Obsolete entries survive encoding
+	buff, err := po.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	po3 := new(Po)
+	if err := po3.UnmarshalBinary(buff); err != nil {
+		t.Fatal(err)
+	}
+	if len(po3.GetObsolete()) != 2 {
+		t.Errorf("Expected 2 obsolete entries but got %d", len(po3.GetObsolete()))
+	}
+
+	// Revive and purge
+	po.AddTranslation(obs[1])
+	po.SetObsolete(nil)
+	if tr := po.GetNC("Old", "Olds", 2, "Ctx"); tr != "Alte" {
+		t.Errorf("Expected 'Alte' but got '%s'", tr)
+	}
+	if len(po.GetObsolete()) != 0 {
+		t.Errorf("Expected no obsolete entries but got %d", len(po.GetObsolete()))
+	}
+}
+
+func TestPoPreviousStrings(t *testing.T) {
+	// Set PO content
+	str := `
+#, fuzzy
+#| msgctxt "Old context"
+#| msgid "Delete the file"
+#| msgid_plural ""
+#| "Delete the "
+#| "files"
+msgctxt "Menu"
+msgid "Remove the file"
+msgid_plural "Remove the files"
+msgstr[0] "Datei löschen"
+msgstr[1] "Dateien löschen"
+
+msgid "Unchanged"
+msgstr "Unverändert"
+
+#~| msgid "Old obsolete"
+#~ msgid "Obsolete"
+#~ msgstr "Veraltet"
+`
+	// Create po object
+	po := new(Po)
+	if err := po.ParseE([]byte(str)); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := po.GetTranslationC("Remove the file", "Menu")
+	if tr == nil {
+		t.Fatal("Expected translation for 'Remove the file'")
+	}
+	if tr.PreviousContext != "Old context" {
+		t.Errorf("Expected 'Old context' but got '%s'", tr.PreviousContext)
+	}
+	if tr.PreviousID != "Delete the file" {
+		t.Errorf("Expected 'Delete the file' but got '%s'", tr.PreviousID)
+	}
+	if tr.PreviousPluralID != "Delete the files" {
+		t.Errorf("Expected 'Delete the files' but got '%s'", tr.PreviousPluralID)
+	}
+
+	tr = po.GetTranslation("Unchanged")
+	if tr.PreviousContext != "" || tr.PreviousID != "" || tr.PreviousPluralID != "" {
+		t.Errorf("Expected no previous strings but got %+v", tr)
+	}
+
+	obs := po.GetObsolete()
+	if len(obs) != 1 || obs[0].PreviousID != "Old obsolete" {
+		t.Fatalf("Unexpected obsolete entries: %+v", obs)
+	}
+
+	// Previous strings are written back
+	out, err := po.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range []string{
+		"#| msgctxt \"Old context\"\n#| msgid \"Delete the file\"\n#| msgid_plural \"Delete the files\"\nmsgctxt \"Menu\"\n",
+		"#~| msgid \"Old obsolete\"\n#~ msgid \"Obsolete\"\n",
+	} {
+		if !strings.Contains(string(out), l) {
+			t.Errorf("Expected '%s' in output:\n%s", l, out)
+		}
+	}
+
+	po2 := new(Po)
+	if err := po2.ParseE(out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(po2.GetTranslationC("Remove the file", "Menu"), po.GetTranslationC("Remove the file", "Menu")) {
+		t.Errorf("Previous strings differ after writing:\n%s", out)
+	}
+}
+
+func TestPoParseReader(t *testing.T) {
+	data, err := ioutil.ReadFile("fixtures/en_US/default.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	po := new(Po)
+	po.Parse(data)
+
+	// One byte at a time, with Windows line endings and no final newline
+	crlf := bytes.TrimSpace(bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n")))
+	rpo := new(Po)
+	if err := rpo.ParseReaderE(iotest.OneByteReader(bytes.NewReader(crlf))); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(po.translations, rpo.translations) {
+		t.Error("Expected the same translations from ParseReader and Parse")
+	}
+	if !reflect.DeepEqual(po.contexts, rpo.contexts) {
+		t.Error("Expected the same contexts from ParseReader and Parse")
+	}
+	if !reflect.DeepEqual(po.Headers, rpo.Headers) {
+		t.Errorf("Expected headers '%v' but got '%v'", po.Headers, rpo.Headers)
+	}
+
+	// Syntax errors keep their position
+	rpo = new(Po)
+	err = rpo.ParseReaderE(strings.NewReader("msgid \"One\"\nmsgstr \"Uno\"\n\nbroken line\n"))
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 4 || pe.Column != 1 {
+		t.Errorf("Expected a *ParseError at line 4 but got '%v'", err)
+	}
+
+	// Read errors are returned as is, keeping what was read
+	rpo = new(Po)
+	r := io.MultiReader(strings.NewReader("msgid \"One\"\nmsgstr \"Uno\"\n"), iotest.ErrReader(io.ErrClosedPipe))
+	if err := rpo.ParseReaderE(r); err != io.ErrClosedPipe {
+		t.Errorf("Expected io.ErrClosedPipe but got '%v'", err)
+	}
+	if tr := rpo.Get("One"); tr != "Uno" {
+		t.Errorf("Expected 'Uno' but got '%s'", tr)
+	}
+}
@@ -0,0 +1,376 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"io"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// poLineWidth is the maximum line width used to wrap strings, as GNU gettext does.
+const poLineWidth = 79
+
+// poHeaderKeys lists the GNU header fields in their usual order and spelling.
+// textproto canonicalizes some of them (e.g. "Pot-Creation-Date"), so they're restored on write.
+var poHeaderKeys = []string{
+	"Project-Id-Version",
+	"Report-Msgid-Bugs-To",
+	"POT-Creation-Date",
+	"PO-Revision-Date",
+	"Last-Translator",
+	"Language-Team",
+	"Language",
+	"MIME-Version",
+	"Content-Type",
+	"Content-Transfer-Encoding",
+	"Plural-Forms",
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+// It returns the catalog as the content of a .po file.
+func (po *Po) MarshalText() ([]byte, error) {
+	var buff bytes.Buffer
+	_, err := po.WriteTo(&buff)
+
+	return buff.Bytes(), err
+}
+
+// WriteTo writes the catalog to w as a .po file and implements the io.WriterTo interface.
+// The header entry is rebuilt from the Headers field, keeping the spelling and order of the parsed fields.
+// Parsed entries are written in the order they were parsed, so a parsed file is written back with its own order,
+// and the other ones, like those added with AddTranslation, follow them sorted by context and msgid.
+func (po *Po) WriteTo(w io.Writer) (int64, error) {
+	// Sync read
+	po.RLock()
+	defer po.RUnlock()
+
+	pw := &poWriter{w: w}
+
+	// Header entry
+	header := NewTranslation()
+	if tr, ok := po.translations[""]; ok {
+		*header = *tr
+	}
+	header.ID = ""
+	header.PluralID = ""
+	if len(po.Headers) > 0 {
+		header.Trs = map[int]string{0: headerString(po.Headers, header.Trs[0])}
+	}
+	if header.Get() != "" || len(header.Flags) > 0 || len(header.TranslatorComments) > 0 {
+		pw.writeEntry(header, "", po.nplurals)
+	}
+
+	// Entries, skipping the empty placeholders left by the parser
+	type entry struct {
+		ctx string
+		tr  *Translation
+	}
+	var entries []entry
+	for id, tr := range po.translations {
+		if id != "" {
+			entries = append(entries, entry{"", tr})
+		}
+	}
+	for ctx, trs := range po.contexts {
+		for id, tr := range trs {
+			if id != "" {
+				entries = append(entries, entry{ctx, tr})
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		pa, oka := po.positions[a.tr]
+		pb, okb := po.positions[b.tr]
+		switch {
+		case oka && okb:
+			return pa.order < pb.order
+		case oka != okb:
+			return oka
+		case a.ctx != b.ctx:
+			return a.ctx < b.ctx
+		}
+		return a.tr.ID < b.tr.ID
+	})
+	for _, e := range entries {
+		pw.writeEntry(e.tr, e.ctx, po.nplurals)
+	}
+
+	// Obsolete entries go last, as msgmerge does
+	pw.prefix = "#~ "
+	for _, tr := range po.obsolete {
+		pw.writeEntry(tr, tr.Context, po.nplurals)
+	}
+
+	return pw.n, pw.err
+}
+
+// headerString builds the header entry msgstr from the given headers.
+// The keys of raw, the header entry msgstr they were parsed from, keep their spelling and order,
+// and the other ones follow, the GNU fields first.
+func headerString(h textproto.MIMEHeader, raw string) string {
+	var sb strings.Builder
+
+	written := make(map[string]bool)
+	write := func(name, key string) {
+		if written[key] {
+			return
+		}
+		written[key] = true
+		for _, v := range h[key] {
+			sb.WriteString(name + ": " + v + "\n")
+		}
+	}
+
+	for _, line := range strings.Split(raw, "\n") {
+		// Continuation lines start with a space
+		if i := strings.Index(line, ":"); i > 0 && line[0] != ' ' && line[0] != '\t' {
+			name := strings.TrimSpace(line[:i])
+			write(name, textproto.CanonicalMIMEHeaderKey(name))
+		}
+	}
+	for _, k := range poHeaderKeys {
+		write(k, textproto.CanonicalMIMEHeaderKey(k))
+	}
+
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		write(k, k)
+	}
+
+	return sb.String()
+}
+
+// sortedKeys returns the keys of a translations map in order.
+func sortedKeys(m map[string]*Translation) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// poWriter writes PO entries and keeps track of the written bytes and the first error.
+type poWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+
+	// Prefix for keyword and string lines, "#~ " for obsolete entries
+	prefix string
+
+	// Whether an entry was written, to separate the next one by a blank line
+	entries bool
+}
+
+func (pw *poWriter) write(s string) {
+	if pw.err != nil {
+		return
+	}
+
+	n, err := io.WriteString(pw.w, s)
+	pw.n += int64(n)
+	pw.err = err
+}
+
+// writeEntry writes a single Translation with its comments.
+func (pw *poWriter) writeEntry(tr *Translation, ctx string, nplurals int) {
+	if pw.entries {
+		pw.write("\n")
+	}
+	pw.entries = true
+
+	for _, c := range tr.TranslatorComments {
+		if c == "" {
+			pw.write("#\n")
+		} else {
+			pw.write("# " + c + "\n")
+		}
+	}
+	for _, c := range tr.ExtractedComments {
+		pw.write("#. " + c + "\n")
+	}
+	pw.writeReferences(tr.References)
+	if len(tr.Flags) > 0 {
+		pw.write("#, " + strings.Join(tr.Flags, ", ") + "\n")
+	}
+
+	wrap := !tr.HasFlag("no-wrap")
+
+	// Previous strings
+	prefix := pw.prefix
+	pw.prefix = strings.TrimSuffix(prefix, " ") + "| "
+	if prefix == "" {
+		pw.prefix = "#| "
+	}
+	if tr.PreviousContext != "" {
+		pw.writeString("msgctxt", tr.PreviousContext, wrap)
+	}
+	if tr.PreviousID != "" {
+		pw.writeString("msgid", tr.PreviousID, wrap)
+	}
+	if tr.PreviousPluralID != "" {
+		pw.writeString("msgid_plural", tr.PreviousPluralID, wrap)
+	}
+	pw.prefix = prefix
+
+	if ctx != "" {
+		pw.writeString("msgctxt", ctx, wrap)
+	}
+	pw.writeString("msgid", tr.ID, wrap)
+
+	if tr.PluralID == "" {
+		pw.writeString("msgstr", tr.Trs[0], wrap)
+	} else {
+		pw.writeString("msgid_plural", tr.PluralID, wrap)
+
+		// Write every form up to nplurals, even if empty, and the other forms the entry has
+		forms, extra := tr.forms(formCount(nplurals))
+		for i, s := range forms {
+			pw.writeString("msgstr["+strconv.Itoa(i)+"]", s, wrap)
+		}
+		for _, i := range extra {
+			pw.writeString("msgstr["+strconv.Itoa(i)+"]", tr.Trs[i], wrap)
+		}
+	}
+}
+
+// writeReferences writes "#:" lines, packing as many references per line as the width allows.
+func (pw *poWriter) writeReferences(refs []string) {
+	line := "#:"
+	for _, ref := range refs {
+		if len(line) > 2 && len(line)+1+len(ref) > poLineWidth {
+			pw.write(line + "\n")
+			line = "#:"
+		}
+		line += " " + ref
+	}
+	if len(line) > 2 {
+		pw.write(line + "\n")
+	}
+}
+
+// writeString writes a keyword and its quoted string value.
+// Strings with embedded newlines or exceeding the line width are split GNU-style,
+// starting with an empty string on the keyword line.
+func (pw *poWriter) writeString(keyword, s string, wrap bool) {
+	// Split after each newline
+	var parts []string
+	for s != "" {
+		i := strings.Index(s, "\n")
+		if i == -1 || i == len(s)-1 {
+			parts = append(parts, s)
+			break
+		}
+		parts = append(parts, s[:i+1])
+		s = s[i+1:]
+	}
+
+	if len(parts) <= 1 {
+		esc := ""
+		if len(parts) == 1 {
+			esc = escapePoString(parts[0])
+		}
+		if !wrap || len(pw.prefix)+len(keyword)+len(esc)+3 <= poLineWidth {
+			pw.write(pw.prefix + keyword + " \"" + esc + "\"\n")
+			return
+		}
+	}
+
+	pw.write(pw.prefix + keyword + " \"\"\n")
+	for _, part := range parts {
+		for _, l := range wrapPoString(escapePoString(part), poLineWidth-len(pw.prefix), wrap) {
+			pw.write(pw.prefix + "\"" + l + "\"\n")
+		}
+	}
+}
+
+// wrapPoString splits an escaped string in lines that fit lineWidth once quoted.
+// Lines are broken after spaces when possible, and never inside an escape sequence.
+func wrapPoString(esc string, lineWidth int, wrap bool) []string {
+	width := lineWidth - 2
+	if !wrap || len(esc) <= width {
+		return []string{esc}
+	}
+
+	var lines []string
+	for len(esc) > width {
+		// Find the last space before the limit
+		cut := strings.LastIndex(esc[:width], " ") + 1
+		if cut <= 0 {
+			// No space available, cut at the limit avoiding escape sequences
+			cut = width
+			for i := 0; i < width; {
+				n := 1
+				if esc[i] == '\\' {
+					n = 2
+					if esc[i+1] >= '0' && esc[i+1] <= '7' {
+						n = 4
+					}
+				}
+				if i+n > width {
+					cut = i
+					break
+				}
+				i += n
+			}
+		}
+		lines = append(lines, esc[:cut])
+		esc = esc[cut:]
+	}
+	if esc != "" {
+		lines = append(lines, esc)
+	}
+
+	return lines
+}
+
+// escapePoString escapes a string using the C syntax of PO files.
+func escapePoString(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\a':
+			sb.WriteString(`\a`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\v':
+			sb.WriteString(`\v`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				// Octal escape for any other control character
+				sb.WriteString(`\` + strconv.FormatInt(int64(c)>>6, 8) + strconv.FormatInt(int64(c)>>3&7, 8) + strconv.FormatInt(int64(c)&7, 8))
+			} else {
+				sb.WriteByte(c)
+			}
+		}
+	}
+
+	return sb.String()
+}
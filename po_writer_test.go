@@ -0,0 +1,261 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPoWriteTo(t *testing.T) {
+	// Set PO content
+	str := `# Header comment
+msgid ""
+msgstr ""
+"Project-Id-Version: gotext\n"
+"Language: de\n"
+"MIME-Version: 1.0\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+"X-Generator: test\n"
+
+# Translator comment
+#. Extracted comment
+#: main.go:1 main.go:2
+#, c-format
+msgid "Hello %s"
+msgstr "Hallo %s"
+
+msgid "Escapes \"quoted\"\ttab\\"
+msgstr "Maskiert \"zitiert\"\ttab\\\a"
+
+msgid "Multi\nline\n"
+msgstr "Mehrere\nZeilen\n"
+
+msgid "A very long string that certainly doesn't fit on a single line of a PO file when written"
+msgstr "Eine sehr lange Zeichenkette, die bestimmt nicht in eine einzige Zeile einer PO-Datei passt"
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "Eine Datei"
+msgstr[1] "%d Dateien"
+
+msgctxt "Menu"
+msgid "Open"
+msgstr "Öffnen"
+`
+	po := new(Po)
+	if err := po.ParseE([]byte(str)); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := po.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check header rebuilt with GNU spelling
+	if !bytes.HasPrefix(out, []byte("# Header comment\nmsgid \"\"\nmsgstr \"\"\n\"Project-Id-Version: gotext\\n\"\n")) {
+		t.Errorf("Unexpected header:\n%s", out)
+	}
+	if !bytes.Contains(out, []byte("\"MIME-Version: 1.0\\n\"\n")) {
+		t.Errorf("Expected MIME-Version header:\n%s", out)
+	}
+
+	// Check wrapping
+	for _, l := range strings.Split(string(out), "\n") {
+		if len(l) > poLineWidth {
+			t.Errorf("Line exceeds %d columns: %q", poLineWidth, l)
+		}
+	}
+	if !bytes.Contains(out, []byte("msgid \"\"\n\"Multi\\n\"\n\"line\\n\"\n")) {
+		t.Errorf("Expected multi-line msgid split on newlines:\n%s", out)
+	}
+
+	// Parse again and compare
+	po2 := new(Po)
+	if err := po2.ParseE(out); err != nil {
+		t.Fatalf("Can't parse written content: %v\n%s", err, out)
+	}
+
+	for _, id := range []string{"Hello %s", "Escapes \"quoted\"\ttab\\", "Multi\nline\n", "A very long string that certainly doesn't fit on a single line of a PO file when written"} {
+		tr := po2.GetTranslation(id)
+		if tr == nil {
+			t.Errorf("Missing translation for '%s'", id)
+		} else if tr.Get() != po.GetTranslation(id).Get() {
+			t.Errorf("Expected '%s' but got '%s'", po.GetTranslation(id).Get(), tr.Get())
+		}
+	}
+	if tr := po2.GetN("One file", "%d files", 5); tr != "%d Dateien" {
+		t.Errorf("Expected '%%d Dateien' but got '%s'", tr)
+	}
+	if tr := po2.GetC("Open", "Menu"); tr != "Öffnen" {
+		t.Errorf("Expected 'Öffnen' but got '%s'", tr)
+	}
+	if !reflect.DeepEqual(po.Headers, po2.Headers) {
+		t.Errorf("Headers differ: %v vs %v", po.Headers, po2.Headers)
+	}
+	if !reflect.DeepEqual(po.GetTranslation("Hello %s"), po2.GetTranslation("Hello %s")) {
+		t.Errorf("Metadata differs: %+v vs %+v", po.GetTranslation("Hello %s"), po2.GetTranslation("Hello %s"))
+	}
+
+	// Output is stable
+	out2, err := po2.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, out2) {
+		t.Errorf("Output isn't stable:\n%s\n---\n%s", out, out2)
+	}
+}
+
+func TestPoWriteToOrder(t *testing.T) {
+	src := `msgid ""
+msgstr "Language: de\n"
+
+msgid "Zebra"
+msgstr "Zebra"
+
+msgctxt "menu"
+msgid "Open"
+msgstr "Öffnen"
+
+msgid "Apple"
+msgstr "Apfel"
+
+#~ msgid "Old"
+#~ msgstr "Alt"
+`
+
+	po := new(Po)
+	if err := po.ParseE([]byte(src)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Added entries follow the parsed ones
+	tr := NewTranslation()
+	tr.ID = "Banana"
+	tr.Trs[0] = "Banane"
+	po.AddTranslation(tr)
+	tr = NewTranslation()
+	tr.ID = "Added"
+	tr.Trs[0] = "Neu"
+	po.AddTranslation(tr)
+
+	out, err := po.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Replace(src, "#~ msgid", "msgid \"Added\"\nmsgstr \"Neu\"\n\nmsgid \"Banana\"\nmsgstr \"Banane\"\n\n#~ msgid", 1)
+	if string(out) != expected {
+		t.Errorf("Expected\n%s\nbut got\n%s", expected, out)
+	}
+}
+
+func TestPoWriteToPoeditHeader(t *testing.T) {
+	// Header written by Poedit, in its own order and spelling
+	src := `msgid ""
+msgstr ""
+"Project-Id-Version: gotext\n"
+"POT-Creation-Date: 2018-01-15 10:00+0100\n"
+"PO-Revision-Date: 2018-01-16 12:30+0100\n"
+"Last-Translator: \n"
+"Language-Team: \n"
+"Language: de\n"
+"MIME-Version: 1.0\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Content-Transfer-Encoding: 8bit\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+"X-Generator: Poedit 2.0.5\n"
+"X-Poedit-SourceCharset: UTF-8\n"
+"X-Poedit-Basepath: ..\n"
+"X-Poedit-KeywordsList: Get;GetN:1,2\n"
+"X-Poedit-SearchPath-0: .\n"
+
+msgid "Hello"
+msgstr "Hallo"
+`
+
+	po := new(Po)
+	if err := po.ParseE([]byte(src)); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := po.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != src {
+		t.Errorf("Expected\n%s\nbut got\n%s", src, out)
+	}
+
+	// Added headers follow the parsed ones
+	po.Headers.Set("X-Added", "yes")
+	out, err = po.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Replace(src, `"X-Poedit-SearchPath-0: .\n"`, `"X-Poedit-SearchPath-0: .\n"`+"\n"+`"X-Added: yes\n"`, 1)
+	if string(out) != expected {
+		t.Errorf("Expected\n%s\nbut got\n%s", expected, out)
+	}
+}
+
+func TestPoWriteToForms(t *testing.T) {
+	po := new(Po)
+	if err := po.ParseE([]byte(`msgid ""
+msgstr "Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "Eine Datei"
+`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Missing forms are written empty, and the forms out of nplurals without gaps
+	po.GetTranslation("One file").Trs[20000000] = "Viele Dateien"
+	out, err := po.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `msgstr[0] "Eine Datei"
+msgstr[1] ""
+msgstr[20000000] "Viele Dateien"
+`
+	if !strings.Contains(string(out), expected) {
+		t.Errorf("Expected\n%s\nin\n%s", expected, out)
+	}
+}
+
+func TestEscapePoString(t *testing.T) {
+	for in, out := range map[string]string{
+		"plain":       "plain",
+		"a\"b":        `a\"b`,
+		"back\\slash": `back\\slash`,
+		"\n\t\r":      `\n\t\r`,
+		"\x01":        `\001`,
+	} {
+		if got := escapePoString(in); got != out {
+			t.Errorf("Expected '%s' but got '%s'", out, got)
+		}
+	}
+}
+
+func TestWrapPoString(t *testing.T) {
+	esc := strings.Repeat("x", 76) + `\n\n`
+	lines := wrapPoString(esc, poLineWidth, true)
+	if len(lines) != 2 || lines[0] != strings.Repeat("x", 76) || lines[1] != `\n\n` {
+		t.Errorf("Unexpected wrapping: %q", lines)
+	}
+
+	lines = wrapPoString(esc, poLineWidth, false)
+	if len(lines) != 1 {
+		t.Errorf("Unexpected wrapping: %q", lines)
+	}
+}
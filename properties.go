@@ -0,0 +1,258 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+Properties parses the content of Java .properties files, like messages_de.properties,
+and provides all the Translation functions needed.
+And it's safe for concurrent use by multiple goroutines by using the sync package for locking.
+
+Files are read as UTF-8, as Java 9 resource bundles do, or as ISO-8859-1 when they aren't valid UTF-8.
+Escapes like "\u00e4", continuation lines, and the "=", ":" and whitespace separators are handled as by java.util.Properties.
+Keys are the msgids, and keys like "open[menu]" are the msgid "open" in the msgctxt "menu".
+Comment lines right before an entry are read as its extracted comments.
+
+Example:
+
+	import (
+		"fmt"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		// Create properties object
+		p := gotext.NewPropertiesTranslator()
+
+		// Parse .properties file
+		p.ParseFile("/path/to/bundle/messages_de.properties")
+
+		// Get Translation
+		fmt.Println(p.Get("greeting"))
+	}
+*/
+type Properties struct {
+	catalog
+}
+
+// NewPropertiesTranslator creates a new Properties object with the Translator interface
+func NewPropertiesTranslator() Translator {
+	return new(Properties)
+}
+
+// ParseFile tries to read the file by its provided path (f) and parse its content as a .properties file.
+// Gzip compressed files, like messages_de.properties.gz, are decompressed.
+func (p *Properties) ParseFile(f string) {
+	p.ParseFileE(f)
+}
+
+// ParseFileE works like ParseFile, but returns an error when the file can't be read or parsed.
+// A missing file or a directory path returns an error wrapping ErrNotFound.
+func (p *Properties) ParseFileE(f string) error {
+	return p.parseFile(f, p.ParseE)
+}
+
+// ParseBundle loads the files of the Java resource bundle name in dir for the language lang,
+// like ResourceBundle.getBundle does: with lang "de_DE", the entries of messages.properties
+// are replaced by the ones of messages_de.properties, and those by the ones of messages_de_DE.properties.
+func (p *Properties) ParseBundle(dir, name, lang string) {
+	p.ParseBundleE(dir, name, lang)
+}
+
+// ParseBundleE works like ParseBundle, but returns an error when a file can't be read or parsed,
+// or an error wrapping ErrNotFound when none of the files exist.
+func (p *Properties) ParseBundleE(dir, name, lang string) error {
+	files := []string{name}
+	suffix := ""
+	for _, part := range strings.FieldsFunc(lang, func(r rune) bool { return r == '_' || r == '-' }) {
+		suffix += "_" + part
+		files = append(files, name+suffix)
+	}
+
+	found := false
+	for _, f := range files {
+		err := p.ParseFileE(filepath.Join(dir, f+".properties"))
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("%w: %s bundle for %s in %s", ErrNotFound, name, lang, dir)
+	}
+
+	return nil
+}
+
+// Parse loads the translations specified in the provided .properties content (buf).
+func (p *Properties) Parse(buf []byte) {
+	p.ParseE(buf)
+}
+
+// ParseE works like Parse, but returns a *ParseError wrapping ErrInvalidCatalog
+// when buf has a malformed \uXXXX escape, and nothing is loaded then.
+func (p *Properties) ParseE(buf []byte) error {
+	trs, err := parseProperties(buf)
+	if err != nil {
+		return err
+	}
+
+	// Lock while storing
+	p.Lock()
+	defer p.Unlock()
+
+	for _, tr := range trs {
+		p.add(tr)
+	}
+
+	return nil
+}
+
+// parseProperties reads the entries of the .properties file data.
+func parseProperties(data []byte) ([]*Translation, error) {
+	text := string(data)
+	if !utf8.Valid(data) {
+		// ISO-8859-1 bytes are the code points
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		text = string(runes)
+	}
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	lines := strings.Split(text, "\n")
+
+	var trs []*Translation
+	var comments []string
+	for i := 0; i < len(lines); {
+		n := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		i++
+
+		switch {
+		case line == "":
+			comments = nil
+			continue
+		case line[0] == '#' || line[0] == '!':
+			comments = append(comments, strings.TrimSpace(line[1:]))
+			continue
+		}
+
+		// Logical lines continue while they end with an odd number of backslashes
+		for strings.HasSuffix(line, `\`) && (len(line)-len(strings.TrimRight(line, `\`)))%2 == 1 {
+			line = line[:len(line)-1]
+			if i == len(lines) {
+				break
+			}
+			line += strings.TrimLeft(lines[i], " \t\f")
+			i++
+		}
+
+		key, value := splitProperty(line)
+		k, err := unescapeProperty(key)
+		if err == nil {
+			value, err = unescapeProperty(value)
+		}
+		if err != nil {
+			return nil, &ParseError{Line: n, Err: err}
+		}
+
+		tr := NewTranslation()
+		tr.ID = k
+		if j := strings.LastIndexByte(k, '['); j != -1 && strings.HasSuffix(k, "]") {
+			tr.ID, tr.Context = k[:j], k[j+1:len(k)-1]
+		}
+		tr.Trs[0] = value
+		tr.ExtractedComments = comments
+		trs = append(trs, tr)
+		comments = nil
+	}
+
+	return trs, nil
+}
+
+// splitProperty returns the escaped key and value of the logical line.
+func splitProperty(line string) (string, string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) != -1 {
+			end = i
+			break
+		}
+	}
+
+	value := strings.TrimLeft(line[end:], " \t\f")
+	if value != "" && (value[0] == '=' || value[0] == ':') {
+		value = strings.TrimLeft(value[1:], " \t\f")
+	}
+
+	return line[:end], value
+}
+
+// unescapeProperty replaces the escape sequences of s.
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("%w: malformed \\uxxxx encoding", ErrInvalidCatalog)
+			}
+			v, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("%w: malformed \\uxxxx encoding", ErrInvalidCatalog)
+			}
+			i += 4
+
+			// Surrogate pairs
+			r := rune(v)
+			if r >= 0xd800 && r < 0xdc00 && i+6 < len(s) && s[i+1:i+3] == `\u` {
+				if lo, err := strconv.ParseUint(s[i+3:i+7], 16, 16); err == nil && lo >= 0xdc00 && lo < 0xe000 {
+					r = 0x10000 + (r-0xd800)<<10 + rune(lo) - 0xdc00
+					i += 6
+				}
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const propertiesStr = `# Messages of the start page
+
+# Greeting
+greeting = Hallo Welt
+farewell:Tsch\u00fcss
+   indented	Einger\u00fcckt
+multiline = Erste Zeile, \
+            zweite Zeile, \
+    dritte Zeile
+escaped\ key\=x = \ leading space\nand \\ backslash \t tab
+empty
+open[menu] = \u00d6ffnen
+emoji = \ud83d\ude00
+! Other comment
+trailing = odd \\\
+  continued
+even = two \\
+`
+
+func TestProperties(t *testing.T) {
+	latin1 := []byte("latin1 = Gr\xfc\xdfe\n")
+
+	p := new(Properties)
+	if err := p.ParseE([]byte(propertiesStr)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ParseE(latin1); err != nil {
+		t.Fatal(err)
+	}
+
+	get, getC := p.Get, p.GetC
+	for _, c := range []struct{ got, expected string }{
+		{get("greeting"), "Hallo Welt"},
+		{get("farewell"), "Tschüss"},
+		{get("indented"), "Eingerückt"},
+		{get("multiline"), "Erste Zeile, zweite Zeile, dritte Zeile"},
+		{get("escaped key=x"), " leading space\nand \\ backslash \t tab"},
+		{get("empty"), "empty"},
+		{getC("open", "menu"), "Öffnen"},
+		{get("emoji"), "😀"},
+		{get("trailing"), "odd \\continued"},
+		{get("even"), "two \\"},
+		{get("latin1"), "Grüße"},
+	} {
+		if c.got != c.expected {
+			t.Errorf("Expected '%s' but got '%s'", c.expected, c.got)
+		}
+	}
+
+	if tr := p.GetTranslation("empty"); tr == nil || tr.Trs[0] != "" {
+		t.Errorf("Expected an empty value but got %+v", tr)
+	}
+	if tr := p.GetTranslation("greeting"); len(tr.ExtractedComments) != 1 || tr.ExtractedComments[0] != "Greeting" {
+		t.Errorf("Unexpected comments %q", tr.ExtractedComments)
+	}
+
+	// Malformed escapes load nothing
+	p = new(Properties)
+	err := p.ParseE([]byte("a = b\nc = \\u00e\n"))
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrInvalidCatalog) || pe.Line != 2 {
+		t.Errorf("Expected ErrInvalidCatalog on line 2 but got '%v'", err)
+	}
+	if get := p.Get; get("a") != "a" {
+		t.Errorf("Expected nothing loaded but got '%s'", get("a"))
+	}
+}
+
+func TestPropertiesBundle(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gotext-properties")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"messages.properties":       "greeting = Hello\ncolor = Color\nbye = Bye\n",
+		"messages_de.properties":    "greeting = Hallo\ncolor = Farbe\n",
+		"messages_de_AT.properties": "greeting = Servus\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := new(Properties)
+	if err := p.ParseBundleE(dir, "messages", "de_AT"); err != nil {
+		t.Fatal(err)
+	}
+	get := p.Get
+	for id, expected := range map[string]string{"greeting": "Servus", "color": "Farbe", "bye": "Bye"} {
+		if s := get(id); s != expected {
+			t.Errorf("Expected '%s' but got '%s'", expected, s)
+		}
+	}
+
+	if err := new(Properties).ParseBundleE(dir, "missing", "de"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got '%v'", err)
+	}
+
+	// Locale domains
+	if err := os.MkdirAll(filepath.Join(dir, "de", "LC_MESSAGES"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "de", "LC_MESSAGES", "default.properties"), []byte("greeting = Hallo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l := NewLocale(dir, "de_DE")
+	if err := l.AddDomainE("default"); err != nil {
+		t.Fatal(err)
+	}
+	if s := l.Get("greeting"); s != "Hallo" {
+		t.Errorf("Expected 'Hallo' but got '%s'", s)
+	}
+}
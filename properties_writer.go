@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+/*
+PropertiesEncoder writes catalogs as Java .properties files, like messages_de.properties.
+Any Translator can be encoded, as its entries are read through MarshalBinary.
+
+Keys and values are written in ASCII, with "\uXXXX" escapes for the other characters, so they can be read
+as ISO-8859-1 or UTF-8 by all Java versions. Entries get the keys Properties reads, sorted,
+with their comments. Plural entries are left out, as .properties files have no plural forms.
+Untranslated and fuzzy entries are left out too, so ResourceBundle looks their keys up
+in the parent bundle, like messages.properties, instead of returning an empty string.
+
+Example:
+
+	import (
+		"os"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		po := new(gotext.Po)
+		po.ParseFile("/path/to/po/file/translations.po")
+
+		f, _ := os.Create("/path/to/bundle/messages_de.properties")
+		defer f.Close()
+
+		gotext.NewPropertiesEncoder(f).Encode(po)
+	}
+*/
+type PropertiesEncoder struct {
+	w io.Writer
+}
+
+// NewPropertiesEncoder returns a PropertiesEncoder writing to w.
+func NewPropertiesEncoder(w io.Writer) *PropertiesEncoder {
+	return &PropertiesEncoder{w: w}
+}
+
+// MarshalProperties returns the catalog of t as the content of a .properties file.
+func MarshalProperties(t Translator) ([]byte, error) {
+	var buff bytes.Buffer
+	err := NewPropertiesEncoder(&buff).Encode(t)
+
+	return buff.Bytes(), err
+}
+
+// Encode writes the singular entries of the catalog of t as a .properties file.
+func (enc *PropertiesEncoder) Encode(t Translator) error {
+	te, err := encodeTranslator(t)
+	if err != nil {
+		return err
+	}
+
+	type entry struct {
+		key string
+		tr  *Translation
+	}
+	var entries []entry
+	add := func(ctx string, tr *Translation) {
+		if tr.ID == "" || tr.PluralID != "" || !tr.IsTranslated() || tr.IsFuzzy() {
+			return
+		}
+
+		// Keys without context, but looking like they have one, get an empty one
+		key := tr.ID
+		if ctx != "" || (strings.HasSuffix(key, "]") && strings.Contains(key, "[")) {
+			key += "[" + ctx + "]"
+		}
+		entries = append(entries, entry{key: key, tr: tr})
+	}
+	for _, tr := range te.Translations {
+		add("", tr)
+	}
+	for ctx, trs := range te.Contexts {
+		for _, tr := range trs {
+			add(ctx, tr)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	w := bufio.NewWriter(enc.w)
+	for _, e := range entries {
+		for _, c := range append(append([]string{}, e.tr.ExtractedComments...), e.tr.TranslatorComments...) {
+			for _, line := range strings.Split(c, "\n") {
+				w.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+			}
+		}
+		w.WriteString(escapeProperty(e.key, true) + "=" + escapeProperty(e.tr.Trs[0], false) + "\n")
+	}
+
+	return w.Flush()
+}
+
+// escapeProperty escapes s as a key or a value of a .properties file.
+// Keys escape the separators and comment characters, and values their leading whitespace.
+func escapeProperty(s string, key bool) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\f':
+			sb.WriteString(`\f`)
+		case r == ' ' && (key || i == 0):
+			sb.WriteString(`\ `)
+		case key && strings.ContainsRune("=:#!", r):
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			for _, u := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&sb, `\u%04x`, u)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"testing"
+)
+
+const propertiesPo = `msgid ""
+msgstr ""
+"Language: de\n"
+
+# Greeting on the start page
+msgid "greeting"
+msgstr "Grüße"
+
+msgctxt "menu"
+msgid "open"
+msgstr "Öffnen"
+
+msgid "key with spaces=and:separators#!"
+msgstr " Führendes Leerzeichen\nZweite Zeile\t\\ 😀"
+
+msgid "list[x]"
+msgstr "Liste"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d Datei"
+msgstr[1] "%d Dateien"
+
+#, fuzzy
+msgid "fuzzy"
+msgstr "Unsicher"
+`
+
+const propertiesExpected = `# Greeting on the start page
+greeting=Gr\u00fc\u00dfe
+key\ with\ spaces\=and\:separators\#\!=\ F\u00fchrendes Leerzeichen\nZweite Zeile\t\\ \ud83d\ude00
+list[x][]=Liste
+open[menu]=\u00d6ffnen
+`
+
+func TestMarshalProperties(t *testing.T) {
+	po := new(Po)
+	if err := po.ParseE([]byte(propertiesPo)); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := MarshalProperties(po)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != propertiesExpected {
+		t.Errorf("Expected\n%s\nbut got\n%s", propertiesExpected, out)
+	}
+
+	// Back to a catalog
+	p := new(Properties)
+	if err := p.ParseE(out); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"greeting", "key with spaces=and:separators#!", "list[x]"} {
+		if s, expected := p.GetTranslation(id), po.GetTranslation(id); s == nil || s.Get() != expected.Get() {
+			t.Errorf("Expected '%s' but got %+v", expected.Get(), s)
+		}
+	}
+	if s := p.GetC("open", "menu"); s != "Öffnen" {
+		t.Errorf("Expected 'Öffnen' but got '%s'", s)
+	}
+	if tr := p.GetTranslation("greeting"); len(tr.ExtractedComments) != 1 {
+		t.Errorf("Unexpected comments %q", tr.ExtractedComments)
+	}
+}
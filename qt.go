@@ -0,0 +1,261 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"encoding/xml"
+	"net/textproto"
+	"strings"
+)
+
+// Names of the extra elements keeping the gettext specific data on Qt files, as lconvert writes them.
+const (
+	qtHeaderPrefix = "extra-po-header-"
+	qtPluralIDName = "extra-po-msgid_plural"
+	qtFlagsName    = "extra-po-flags"
+)
+
+// qtCommentSep separates the context name from the disambiguation comment in the msgctxt of Qt messages.
+const qtCommentSep = "|"
+
+/*
+Qt parses the content of Qt Linguist .ts files and provides all the Translation functions needed.
+And it's safe for concurrent use by multiple goroutines by using the sync package for locking.
+
+Messages are read with their source text as msgid and the name of their context as msgctxt,
+messages of the unnamed context having none. The disambiguation comment of a message is added
+to its msgctxt after a "|", as lconvert does, so messages with the same source stay apart. The numerus forms of plural messages are the plural forms,
+in the same order, and unfinished translations are fuzzy, or untranslated when they're empty.
+Extra and translator comments are read as the extracted and translator comments,
+and vanished and obsolete messages are ignored.
+
+The PO headers and msgid_plural are read from the "extra-po-header-*" and "extra-po-msgid_plural" elements
+written by QtEncoder and lconvert. Files without Plural-Forms header evaluate the Western plural rule:
+use SetHeaders before parsing them for other languages.
+
+Example:
+
+	import (
+		"fmt"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		// Create qt object
+		q := gotext.NewQtTranslator()
+
+		// Parse .ts file
+		q.ParseFile("/path/to/ts/file/app_de.ts")
+
+		// Get Translation
+		fmt.Println(q.GetC("Open", "MainWindow"))
+	}
+*/
+type Qt struct {
+	catalog
+}
+
+// qtTS is the schema of Qt Linguist .ts files.
+type qtTS struct {
+	XMLName        xml.Name    `xml:"TS"`
+	Version        string      `xml:"version,attr"`
+	Language       string      `xml:"language,attr,omitempty"`
+	SourceLanguage string      `xml:"sourcelanguage,attr,omitempty"`
+	Extras         []qtExtra   `xml:",any"`
+	Contexts       []qtContext `xml:"context"`
+}
+
+type qtExtra struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+}
+
+type qtContext struct {
+	Name     string      `xml:"name"`
+	Messages []qtMessage `xml:"message"`
+}
+
+type qtMessage struct {
+	Numerus           string        `xml:"numerus,attr,omitempty"`
+	Locations         []qtLocation  `xml:"location"`
+	Source            string        `xml:"source"`
+	Comment           string        `xml:"comment,omitempty"`
+	ExtraComment      string        `xml:"extracomment,omitempty"`
+	TranslatorComment string        `xml:"translatorcomment,omitempty"`
+	Translation       qtTranslation `xml:"translation"`
+	Extras            []qtExtra     `xml:",any"`
+}
+
+type qtLocation struct {
+	Filename string `xml:"filename,attr,omitempty"`
+	Line     string `xml:"line,attr,omitempty"`
+}
+
+type qtTranslation struct {
+	Type           string          `xml:"type,attr,omitempty"`
+	Text           string          `xml:",chardata"`
+	LengthVariants []string        `xml:"lengthvariant"`
+	NumerusForms   []qtNumerusForm `xml:"numerusform"`
+}
+
+type qtNumerusForm struct {
+	Text           string   `xml:",chardata"`
+	LengthVariants []string `xml:"lengthvariant"`
+}
+
+// NewQtTranslator creates a new Qt object with the Translator interface
+func NewQtTranslator() Translator {
+	return new(Qt)
+}
+
+// SetHeaders sets the headers of the catalog, as found in the header entry of PO files.
+// Headers of the files parsed afterwards replace them, and their language attribute only sets
+// the Language header when there aren't any.
+func (q *Qt) SetHeaders(h textproto.MIMEHeader) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.setHeaders(h)
+}
+
+// ParseFile tries to read the file by its provided path (f) and parse its content as a .ts file.
+// Gzip compressed files, like app_de.ts.gz, are decompressed.
+func (q *Qt) ParseFile(f string) {
+	q.ParseFileE(f)
+}
+
+// ParseFileE works like ParseFile, but returns an error when the file can't be read or parsed.
+// A missing file or a directory path returns an error wrapping ErrNotFound.
+func (q *Qt) ParseFileE(f string) error {
+	return q.parseFile(f, q.ParseE)
+}
+
+// Parse loads the translations specified in the provided .ts content (buf).
+func (q *Qt) Parse(buf []byte) {
+	q.ParseE(buf)
+}
+
+// ParseE works like Parse, but returns a *ParseError when buf isn't a .ts file.
+// The error wraps ErrInvalidCatalog, and nothing is loaded then.
+func (q *Qt) ParseE(buf []byte) error {
+	var doc qtTS
+	if err := xml.Unmarshal(buf, &doc); err != nil {
+		return xmlError(err)
+	}
+	headers, trs := doc.translations()
+
+	// Lock while storing
+	q.Lock()
+	defer q.Unlock()
+
+	switch {
+	case headers != nil:
+		q.setHeaders(headers)
+	case q.Headers == nil && doc.Language != "":
+		q.setHeaders(textproto.MIMEHeader{"Language": {doc.Language}})
+	}
+	for _, tr := range trs {
+		q.add(tr)
+	}
+
+	return nil
+}
+
+// translations returns the headers and entries of a .ts document.
+func (doc *qtTS) translations() (textproto.MIMEHeader, []*Translation) {
+	var headers textproto.MIMEHeader
+	for _, e := range doc.Extras {
+		if key := strings.TrimPrefix(e.XMLName.Local, qtHeaderPrefix); key != e.XMLName.Local {
+			if headers == nil {
+				headers = textproto.MIMEHeader{}
+			}
+			headers.Add(strings.ReplaceAll(key, "_", "-"), e.Text)
+		}
+	}
+	if headers != nil && headers.Get("Language") == "" && doc.Language != "" {
+		headers.Set("Language", doc.Language)
+	}
+
+	var trs []*Translation
+	for _, c := range doc.Contexts {
+		for _, m := range c.Messages {
+			if m.Translation.Type == "vanished" || m.Translation.Type == "obsolete" {
+				continue
+			}
+			trs = append(trs, m.translation(c.Name))
+		}
+	}
+
+	return headers, trs
+}
+
+// translation returns the entry of the message m in the context ctx.
+func (m *qtMessage) translation(ctx string) *Translation {
+	tr := NewTranslation()
+	tr.ID = m.Source
+	tr.Context = ctx
+
+	for _, l := range m.Locations {
+		if l.Filename == "" {
+			continue
+		}
+		ref := l.Filename
+		if l.Line != "" {
+			ref += ":" + l.Line
+		}
+		tr.References = append(tr.References, ref)
+	}
+	if m.Comment != "" {
+		tr.Context = ctx + qtCommentSep + m.Comment
+	}
+	if m.ExtraComment != "" {
+		tr.ExtractedComments = append(tr.ExtractedComments, strings.Split(m.ExtraComment, "\n")...)
+	}
+	if m.TranslatorComment != "" {
+		tr.TranslatorComments = strings.Split(m.TranslatorComment, "\n")
+	}
+
+	for _, e := range m.Extras {
+		switch e.XMLName.Local {
+		case qtPluralIDName:
+			tr.PluralID = e.Text
+		case qtFlagsName:
+			for _, f := range strings.Split(e.Text, ",") {
+				if f = strings.TrimSpace(f); f != "" && f != "fuzzy" {
+					tr.Flags = append(tr.Flags, f)
+				}
+			}
+		}
+	}
+
+	translated := false
+	if m.Numerus == "yes" {
+		if tr.PluralID == "" {
+			tr.PluralID = m.Source
+		}
+		for i, f := range m.Translation.NumerusForms {
+			tr.Trs[i] = qtText(f.Text, f.LengthVariants)
+			translated = translated || tr.Trs[i] != ""
+		}
+	} else {
+		tr.Trs[0] = qtText(m.Translation.Text, m.Translation.LengthVariants)
+		translated = tr.Trs[0] != ""
+	}
+	if m.Translation.Type == "unfinished" && translated {
+		setFuzzy(tr)
+	}
+
+	return tr
+}
+
+// qtText returns the text of a translation, which is its first length variant when it has some.
+func qtText(text string, variants []string) string {
+	if len(variants) > 0 {
+		return variants[0]
+	}
+
+	return text
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"net/textproto"
+	"testing"
+)
+
+const qtStr = `<?xml version="1.0" encoding="utf-8"?>
+<!DOCTYPE TS>
+<TS version="2.1" language="de_DE" sourcelanguage="en">
+<context>
+    <name>MainWindow</name>
+    <message>
+        <location filename="../src/mainwindow.cpp" line="42"/>
+        <source>&amp;Open</source>
+        <extracomment>File menu entry</extracomment>
+        <translatorcomment>Short please</translatorcomment>
+        <translation>Ö&amp;ffnen</translation>
+    </message>
+    <message>
+        <source>Save</source>
+        <translation type="unfinished">Sichern</translation>
+    </message>
+    <message>
+        <source>Quit</source>
+        <translation type="unfinished"></translation>
+    </message>
+    <message>
+        <source>Close</source>
+        <comment>window</comment>
+        <translation>Fenster schließen</translation>
+    </message>
+    <message>
+        <source>Close</source>
+        <comment>file</comment>
+        <translation>Datei schließen</translation>
+    </message>
+    <message>
+        <source>Old</source>
+        <translation type="vanished">Alt</translation>
+    </message>
+    <message numerus="yes">
+        <source>%n file(s)</source>
+        <translation>
+            <numerusform>%n Datei</numerusform>
+            <numerusform>%n Dateien</numerusform>
+        </translation>
+    </message>
+</context>
+<context>
+    <name></name>
+    <message>
+        <source>Ready</source>
+        <translation variants="yes">
+            <lengthvariant>Bereit</lengthvariant>
+            <lengthvariant>OK</lengthvariant>
+        </translation>
+    </message>
+</context>
+</TS>
+`
+
+func TestQt(t *testing.T) {
+	q := new(Qt)
+	if err := q.ParseE([]byte(qtStr)); err != nil {
+		t.Fatal(err)
+	}
+
+	get, getC, getNC := q.Get, q.GetC, q.GetNC
+	for _, c := range []struct{ got, expected string }{
+		{getC("&Open", "MainWindow"), "Ö&ffnen"},
+		{getC("Save", "MainWindow"), "Save"},
+		{getC("Quit", "MainWindow"), "Quit"},
+		{getC("Old", "MainWindow"), "Old"},
+		{getC("Close", "MainWindow"), "Close"},
+		{getC("Close", "MainWindow|window"), "Fenster schließen"},
+		{getC("Close", "MainWindow|file"), "Datei schließen"},
+		{getNC("%n file(s)", "%n file(s)", 1, "MainWindow"), "%n Datei"},
+		{getNC("%n file(s)", "%n file(s)", 3, "MainWindow"), "%n Dateien"},
+		{get("Ready"), "Bereit"},
+	} {
+		if c.got != c.expected {
+			t.Errorf("Expected '%s' but got '%s'", c.expected, c.got)
+		}
+	}
+
+	q.SetAllowFuzzy(true)
+	if tr := getC("Save", "MainWindow"); tr != "Sichern" {
+		t.Errorf("Expected 'Sichern' with fuzzy entries allowed but got '%s'", tr)
+	}
+
+	if q.Language != "de_DE" {
+		t.Errorf("Expected language 'de_DE' but got '%s'", q.Language)
+	}
+
+	tr := q.GetTranslationC("&Open", "MainWindow")
+	if len(tr.References) != 1 || tr.References[0] != "../src/mainwindow.cpp:42" {
+		t.Errorf("Unexpected references %q", tr.References)
+	}
+	if len(tr.ExtractedComments) != 1 || len(tr.TranslatorComments) != 1 || tr.IsFuzzy() {
+		t.Errorf("Unexpected entry %+v", tr)
+	}
+	if tr := q.GetTranslationC("Save", "MainWindow"); !tr.IsFuzzy() {
+		t.Errorf("Expected fuzzy entry but got %+v", tr)
+	}
+	if tr := q.GetTranslationC("Quit", "MainWindow"); tr.IsFuzzy() || tr.IsTranslated() {
+		t.Errorf("Expected untranslated entry but got %+v", tr)
+	}
+	if tr := q.GetTranslationC("%n file(s)", "MainWindow"); tr.PluralID != "%n file(s)" {
+		t.Errorf("Expected plural entry but got %+v", tr)
+	}
+}
+
+func TestQtPluralForms(t *testing.T) {
+	ts := `<TS version="2.1" language="pl">
+<context>
+    <name>Files</name>
+    <message numerus="yes">
+        <source>%n file(s)</source>
+        <translation>
+            <numerusform>%n plik</numerusform>
+            <numerusform>%n pliki</numerusform>
+            <numerusform>%n plików</numerusform>
+        </translation>
+    </message>
+</context>
+</TS>`
+
+	q := new(Qt)
+	q.SetHeaders(textproto.MIMEHeader{"Language": {"pl"}, "Plural-Forms": {polishPluralForms}})
+	if err := q.ParseE([]byte(ts)); err != nil {
+		t.Fatal(err)
+	}
+
+	getNC := q.GetNC
+	for n, expected := range map[int]string{1: "%n plik", 3: "%n pliki", 5: "%n plików"} {
+		if s := getNC("%n file(s)", "%n file(s)", n, "Files"); s != expected {
+			t.Errorf("Expected '%s' for %d but got '%s'", expected, n, s)
+		}
+	}
+}
+
+func TestQtErrors(t *testing.T) {
+	for _, ts := range []string{
+		"<TS version=\"2.1\">\n<context>\n</TS>",
+		`<resources></resources>`,
+		``,
+	} {
+		q := new(Qt)
+		err := q.ParseE([]byte(ts))
+		var pe *ParseError
+		if !errors.As(err, &pe) || !errors.Is(err, ErrInvalidCatalog) {
+			t.Errorf("Expected ErrInvalidCatalog for %q but got '%v'", ts, err)
+		}
+	}
+
+	q := new(Qt)
+	err := q.ParseE([]byte("<TS version=\"2.1\">\n<context>\n</TS>"))
+	var pe *ParseError
+	if errors.As(err, &pe) && pe.Line != 3 {
+		t.Errorf("Expected error on line 3 but got '%v'", err)
+	}
+}
@@ -0,0 +1,225 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+QtEncoder writes catalogs as Qt Linguist .ts files, to be translated with Qt Linguist
+like the messages of Qt applications.
+Any Translator can be encoded, as its entries are read through MarshalBinary.
+
+Every entry is written, untranslated and fuzzy ones included, in the context named after its msgctxt.
+A msgctxt like "context|comment" is split into the context name and the disambiguation comment.
+Fuzzy entries are unfinished translations, and plural entries numerus messages
+with a numerus form for every plural form. Headers, msgid_plural and flags are kept
+on extra elements, as lconvert does, so a PO catalog read back with Qt keeps all of them.
+
+Example:
+
+	import (
+		"os"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		po := new(gotext.Po)
+		po.ParseFile("/path/to/po/file/translations.po")
+
+		f, _ := os.Create("/path/to/ts/file/app_de.ts")
+		defer f.Close()
+
+		gotext.NewQtEncoder(f).Encode(po)
+	}
+*/
+type QtEncoder struct {
+	// SourceLanguage of the msgids, "en" by default.
+	SourceLanguage string
+
+	// Language of the translations, the Language header of the catalog by default.
+	Language string
+
+	// Indent of the nested elements, four spaces by default. Use "" for a compact output.
+	Indent string
+
+	w io.Writer
+}
+
+// NewQtEncoder returns a QtEncoder writing indented .ts files to w.
+func NewQtEncoder(w io.Writer) *QtEncoder {
+	return &QtEncoder{
+		SourceLanguage: "en",
+		Indent:         "    ",
+		w:              w,
+	}
+}
+
+// MarshalQt returns the catalog of t as the content of a .ts file.
+func MarshalQt(t Translator) ([]byte, error) {
+	var buff bytes.Buffer
+	err := NewQtEncoder(&buff).Encode(t)
+
+	return buff.Bytes(), err
+}
+
+// Encode writes the catalog of t as a .ts file. Contexts and their messages are sorted.
+func (enc *QtEncoder) Encode(t Translator) error {
+	te, err := encodeTranslator(t)
+	if err != nil {
+		return err
+	}
+
+	nplurals := te.Nplurals
+	if nplurals < 1 {
+		nplurals = 2
+	}
+
+	doc := qtTS{
+		Version:        "2.1",
+		Language:       enc.Language,
+		SourceLanguage: enc.SourceLanguage,
+		Extras:         qtHeaderExtras(te.Headers, headerEntry(te)),
+	}
+	if doc.Language == "" {
+		doc.Language = te.Language
+	}
+
+	contexts := make([]string, 0, len(te.Contexts)+1)
+	if len(te.Translations) > 0 {
+		contexts = append(contexts, "")
+	}
+	for ctx := range te.Contexts {
+		contexts = append(contexts, ctx)
+	}
+	sort.Strings(contexts)
+
+	// Messages of the msgctxts with the same context name go to the same context
+	messages := make(map[string][]qtMessage)
+	var names []string
+	for _, ctx := range contexts {
+		trs := te.Translations
+		if ctx != "" {
+			trs = te.Contexts[ctx]
+		}
+
+		name, comment := qtSplitContext(ctx)
+		for _, id := range sortedKeys(trs) {
+			if id == "" {
+				continue
+			}
+			if _, ok := messages[name]; !ok {
+				names = append(names, name)
+			}
+			m := qtMessageOf(trs[id], nplurals)
+			m.Comment = comment
+			messages[name] = append(messages[name], m)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		doc.Contexts = append(doc.Contexts, qtContext{Name: name, Messages: messages[name]})
+	}
+
+	if _, err := io.WriteString(enc.w, xml.Header+"<!DOCTYPE TS>\n"); err != nil {
+		return err
+	}
+	xe := xml.NewEncoder(enc.w)
+	xe.Indent("", enc.Indent)
+	if err := xe.Encode(&doc); err != nil {
+		return err
+	}
+	_, err = io.WriteString(enc.w, "\n")
+
+	return err
+}
+
+// qtHeaderExtras returns the extra elements of the headers h, but the Language one
+// written as the language attribute, in the order of the PO header entry raw.
+func qtHeaderExtras(h textproto.MIMEHeader, raw string) []qtExtra {
+	var extras []qtExtra
+	for _, line := range strings.Split(headerString(h, raw), "\n") {
+		kv := strings.SplitN(line, ": ", 2)
+		if len(kv) != 2 || textproto.CanonicalMIMEHeaderKey(kv[0]) == "Language" {
+			continue
+		}
+
+		name := qtHeaderPrefix + strings.ReplaceAll(strings.ToLower(kv[0]), "-", "_")
+		extras = append(extras, qtExtra{XMLName: xml.Name{Local: name}, Text: kv[1]})
+	}
+
+	return extras
+}
+
+// qtSplitContext returns the context name and the disambiguation comment of the msgctxt ctx.
+// A msgctxt without comment after the "|" is a context name.
+func qtSplitContext(ctx string) (string, string) {
+	i := strings.Index(ctx, qtCommentSep)
+	if i == -1 || i == len(ctx)-len(qtCommentSep) {
+		return ctx, ""
+	}
+
+	return ctx[:i], ctx[i+len(qtCommentSep):]
+}
+
+// qtMessageOf returns the message of tr, with nplurals numerus forms at least for plural entries.
+func qtMessageOf(tr *Translation, nplurals int) qtMessage {
+	m := qtMessage{
+		Source:            tr.ID,
+		ExtraComment:      strings.Join(tr.ExtractedComments, "\n"),
+		TranslatorComment: strings.Join(tr.TranslatorComments, "\n"),
+	}
+
+	for _, ref := range tr.References {
+		l := qtLocation{Filename: ref}
+		if i := strings.LastIndexByte(ref, ':'); i != -1 {
+			if _, err := strconv.Atoi(ref[i+1:]); err == nil {
+				l = qtLocation{Filename: ref[:i], Line: ref[i+1:]}
+			}
+		}
+		m.Locations = append(m.Locations, l)
+	}
+
+	var flags []string
+	for _, f := range tr.Flags {
+		if f != "fuzzy" {
+			flags = append(flags, f)
+		}
+	}
+	if len(flags) > 0 {
+		m.Extras = append(m.Extras, qtExtra{XMLName: xml.Name{Local: qtFlagsName}, Text: strings.Join(flags, ", ")})
+	}
+
+	if tr.PluralID != "" {
+		m.Numerus = "yes"
+		m.Extras = append(m.Extras, qtExtra{XMLName: xml.Name{Local: qtPluralIDName}, Text: tr.PluralID})
+
+		forms := nplurals
+		for i := range tr.Trs {
+			if i >= forms {
+				forms = i + 1
+			}
+		}
+		for i := 0; i < forms; i++ {
+			m.Translation.NumerusForms = append(m.Translation.NumerusForms, qtNumerusForm{Text: tr.Trs[i]})
+		}
+	} else {
+		m.Translation.Text = tr.Trs[0]
+	}
+
+	if !tr.IsTranslated() || tr.IsFuzzy() {
+		m.Translation.Type = "unfinished"
+	}
+
+	return m
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"testing"
+)
+
+const qtPo = `msgid ""
+msgstr ""
+"Language: de\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+"X-Generator: test\n"
+
+#. Greeting
+#: main.go:12
+#, c-format
+msgid "Hello %s"
+msgstr "Hallo %s"
+
+msgctxt "menu"
+msgid "Open"
+msgstr "Öffnen"
+
+msgctxt "menu|window"
+msgid "Close"
+msgstr "Fenster schließen"
+
+msgctxt "menu|file"
+msgid "Close"
+msgstr "Datei schließen"
+
+#, fuzzy
+msgid "Save"
+msgstr "Sichern"
+
+msgid "Quit"
+msgstr ""
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d Datei"
+msgstr[1] "%d Dateien"
+`
+
+const qtExpected = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE TS>
+<TS version="2.1" language="de" sourcelanguage="en">
+    <extra-po-header-plural_forms>nplurals=2; plural=(n != 1);</extra-po-header-plural_forms>
+    <extra-po-header-x_generator>test</extra-po-header-x_generator>
+    <context>
+        <name></name>
+        <message numerus="yes">
+            <source>%d file</source>
+            <translation>
+                <numerusform>%d Datei</numerusform>
+                <numerusform>%d Dateien</numerusform>
+            </translation>
+            <extra-po-msgid_plural>%d files</extra-po-msgid_plural>
+        </message>
+        <message>
+            <location filename="main.go" line="12"></location>
+            <source>Hello %s</source>
+            <extracomment>Greeting</extracomment>
+            <translation>Hallo %s</translation>
+            <extra-po-flags>c-format</extra-po-flags>
+        </message>
+        <message>
+            <source>Quit</source>
+            <translation type="unfinished"></translation>
+        </message>
+        <message>
+            <source>Save</source>
+            <translation type="unfinished">Sichern</translation>
+        </message>
+    </context>
+    <context>
+        <name>menu</name>
+        <message>
+            <source>Open</source>
+            <translation>Öffnen</translation>
+        </message>
+        <message>
+            <source>Close</source>
+            <comment>file</comment>
+            <translation>Datei schließen</translation>
+        </message>
+        <message>
+            <source>Close</source>
+            <comment>window</comment>
+            <translation>Fenster schließen</translation>
+        </message>
+    </context>
+</TS>
+`
+
+func TestMarshalQt(t *testing.T) {
+	po := new(Po)
+	if err := po.ParseE([]byte(qtPo)); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := MarshalQt(po)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != qtExpected {
+		t.Errorf("Expected\n%s\nbut got\n%s", qtExpected, out)
+	}
+}
+
+func TestQtRoundTrip(t *testing.T) {
+	po := new(Po)
+	if err := po.ParseE([]byte(qtPo)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Decoded catalogs have no source order, so they're written sorted
+	src, err := encodeTranslator(po)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected bytes.Buffer
+	if _, err := src.GetTranslator().(*Po).WriteTo(&expected); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := MarshalQt(po)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := new(Qt)
+	if err := q.ParseE(out); err != nil {
+		t.Fatal(err)
+	}
+
+	// Back to PO
+	te, err := encodeTranslator(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	if _, err := te.GetTranslator().(*Po).WriteTo(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != expected.String() {
+		t.Errorf("Expected\n%s\nbut got\n%s\nfrom\n%s", expected.String(), got.String(), out)
+	}
+}
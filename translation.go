@@ -5,11 +5,33 @@
 
 package gotext
 
+import "sort"
+
 // Translation is the struct for the Translations parsed via Po or Mo files and all coming parsers
 type Translation struct {
 	ID       string
 	PluralID string
 	Trs      map[int]string
+
+	// Context (msgctxt) of the entry, empty when there isn't one.
+	Context string
+
+	// Translator comments ("# ") found before the entry.
+	TranslatorComments []string
+
+	// Extracted comments ("#.") added by the extraction tools.
+	ExtractedComments []string
+
+	// Source code references ("#:"), like "src/main.go:42".
+	References []string
+
+	// Flags ("#,"), like "fuzzy", "c-format" or "no-wrap".
+	Flags []string
+
+	// Previous source strings ("#|") recorded by msgmerge on fuzzy matches.
+	PreviousContext  string
+	PreviousID       string
+	PreviousPluralID string
 }
 
 // NewTranslation returns the Translation object and initialized it.
@@ -50,3 +72,61 @@ func (t *Translation) GetN(n int) string {
 	// Return untranslated plural by default
 	return t.PluralID
 }
+
+// HasFlag reports whether the translation has the given flag, like "c-format".
+func (t *Translation) HasFlag(flag string) bool {
+	for _, f := range t.Flags {
+		if f == flag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsFuzzy reports whether the translation is flagged as fuzzy and needs review.
+func (t *Translation) IsFuzzy() bool {
+	return t.HasFlag("fuzzy")
+}
+
+// IsTranslated reports whether the entry has at least one non-empty translation.
+func (t *Translation) IsTranslated() bool {
+	for _, s := range t.Trs {
+		if s != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forms returns the translations of the first n plural forms of t, empty for the missing ones,
+// along with the sorted indexes of the other forms t has.
+func (t *Translation) forms(n int) ([]string, []int) {
+	forms := make([]string, n)
+	var extra []int
+	for i, s := range t.Trs {
+		if i >= 0 && i < n {
+			forms[i] = s
+			continue
+		}
+		extra = append(extra, i)
+	}
+	sort.Ints(extra)
+
+	return forms, extra
+}
+
+// formCount returns the number of plural forms of a catalog declaring nplurals:
+// 2 when it doesn't declare a valid number, as for the default Plural-Forms of gettext,
+// and poMaxForms at most.
+func formCount(nplurals int) int {
+	switch {
+	case nplurals < 1:
+		return 2
+	case nplurals > poMaxForms:
+		return poMaxForms
+	}
+
+	return nplurals
+}
@@ -22,6 +22,18 @@ type Translator interface {
 	UnmarshalBinary([]byte) error
 }
 
+// Loader is implemented by Translator objects that report errors while loading their sources.
+// Po and Mo implement it, and Locale.AddDomainE uses it to surface missing or broken files.
+type Loader interface {
+	ParseFileE(f string) error
+	ParseE(buf []byte) error
+}
+
+// fuzzyTranslator is implemented by Translator objects that can use fuzzy entries on lookups.
+type fuzzyTranslator interface {
+	SetAllowFuzzy(allow bool)
+}
+
 // TranslatorEncoding is used as intermediary storage to encode Translator objects to Gob.
 type TranslatorEncoding struct {
 	// Headers storage
@@ -40,6 +52,9 @@ type TranslatorEncoding struct {
 	// Storage
 	Translations map[string]*Translation
 	Contexts     map[string]map[string]*Translation
+
+	// Obsolete entries, only used by Po objects
+	Obsolete []*Translation
 }
 
 // GetTranslator is used to recover a Translator object after unmarshaling the TranslatorEncoding object.
@@ -54,6 +69,7 @@ func (te *TranslatorEncoding) GetTranslator() Translator {
 	po.plural = te.Plural
 	po.translations = te.Translations
 	po.contexts = te.Contexts
+	po.obsolete = te.Obsolete
 
 	return po
 }
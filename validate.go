@@ -0,0 +1,255 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"fmt"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DeineAgenturUG/gotext/plurals"
+)
+
+// requiredHeaders lists the header fields msgfmt --check-header expects.
+var requiredHeaders = []string{
+	"Project-Id-Version",
+	"PO-Revision-Date",
+	"Last-Translator",
+	"Language-Team",
+	"Language",
+	"MIME-Version",
+	"Content-Type",
+	"Content-Transfer-Encoding",
+}
+
+// pluralCheckMax is the highest n evaluated to check the range of plural expressions, as msgfmt does.
+const pluralCheckMax = 1000
+
+// Diagnostic is a problem found by Validate on a catalog.
+type Diagnostic struct {
+	// Position of the entry in the PO source.
+	// They're 0 for MO files and entries added with AddTranslation.
+	Line   int
+	Column int
+
+	// Context and ID of the entry, both empty for problems in the header entry.
+	Context string
+	ID      string
+
+	// Err wraps one of ErrDuplicate, ErrInvalidHeader, ErrInvalidPluralForms,
+	// ErrPluralCount, ErrPluralRange or ErrNewlineMismatch.
+	Err error
+}
+
+// Error implements the error interface.
+func (d Diagnostic) Error() string {
+	return (&ParseError{Line: d.Line, Column: d.Column, Err: d.Err}).Error()
+}
+
+// Unwrap returns the underlying error.
+func (d Diagnostic) Unwrap() error {
+	return d.Err
+}
+
+// Validate checks the catalog the way msgfmt --check does, and returns the problems found
+// sorted by position. It returns nil for valid catalogs.
+func (po *Po) Validate() []Diagnostic {
+	po.RLock()
+	defer po.RUnlock()
+
+	return validateCatalog(po.Headers, po.translations, po.contexts, po.positions, po.duplicates, po.allowFuzzy)
+}
+
+// Validate checks the catalog the way msgfmt --check does, and returns the problems found.
+// It returns nil for valid catalogs.
+func (mo *Mo) Validate() []Diagnostic {
+	mo.RLock()
+	defer mo.RUnlock()
+
+	return validateCatalog(mo.Headers, mo.translations, mo.contexts, nil, mo.duplicates, false)
+}
+
+// validateCatalog runs the msgfmt checks on the given catalog storage, reporting the entries at their positions.
+// Fuzzy entries are skipped unless allowFuzzy is set, as they aren't compiled by msgfmt.
+func validateCatalog(headers textproto.MIMEHeader, translations map[string]*Translation, contexts map[string]map[string]*Translation, positions map[*Translation]poPosition, duplicates []Diagnostic, allowFuzzy bool) []Diagnostic {
+	diags := append([]Diagnostic(nil), duplicates...)
+
+	// Collect entries with their context
+	type entry struct {
+		ctx string
+		tr  *Translation
+	}
+	var entries []entry
+	for _, tr := range translations {
+		if tr.ID != "" {
+			entries = append(entries, entry{"", tr})
+		}
+	}
+	for ctx, trs := range contexts {
+		for _, tr := range trs {
+			entries = append(entries, entry{ctx, tr})
+		}
+	}
+
+	hasPlurals := false
+	for _, e := range entries {
+		if e.tr.PluralID != "" {
+			hasPlurals = true
+		}
+	}
+
+	// Header entry
+	header := translations[""]
+	nplurals := 0
+	if header == nil {
+		diags = append(diags, Diagnostic{Err: fmt.Errorf("%w: missing header entry", ErrInvalidHeader)})
+	} else {
+		fail := func(err error) {
+			pos := positions[header]
+			diags = append(diags, Diagnostic{Line: pos.line, Column: pos.column, Err: err})
+		}
+
+		for _, l := range strings.Split(header.Get(), "\n") {
+			if l != "" && !strings.Contains(l, ":") {
+				fail(fmt.Errorf("%w: line %q isn't a field", ErrInvalidHeader, l))
+			}
+		}
+		for _, k := range requiredHeaders {
+			if headers.Get(k) == "" {
+				fail(fmt.Errorf("%w: missing field %q", ErrInvalidHeader, k))
+			}
+		}
+
+		var err error
+		nplurals, err = validatePluralForms(headers.Get("Plural-Forms"), hasPlurals)
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	// Entries
+	for _, e := range entries {
+		tr := e.tr
+		if (tr.IsFuzzy() && !allowFuzzy) || !tr.IsTranslated() {
+			continue
+		}
+		fail := func(err error) {
+			pos := positions[tr]
+			diags = append(diags, Diagnostic{Line: pos.line, Column: pos.column, Context: e.ctx, ID: tr.ID, Err: err})
+		}
+
+		// Number of plural forms
+		if tr.PluralID != "" && nplurals > 0 {
+			if _, extra := tr.forms(nplurals); len(extra) > 0 {
+				fail(fmt.Errorf("%w: msgstr[%d] is out of nplurals %d", ErrPluralCount, extra[0], nplurals))
+			} else if len(tr.Trs) != nplurals {
+				fail(fmt.Errorf("%w: %d forms, but nplurals is %d", ErrPluralCount, len(tr.Trs), nplurals))
+			}
+		}
+
+		// Leading and trailing newlines
+		indexes := make([]int, 0, len(tr.Trs))
+		for i := range tr.Trs {
+			indexes = append(indexes, i)
+		}
+		sort.Ints(indexes)
+		for _, i := range indexes {
+			str := tr.Trs[i]
+			if str == "" {
+				continue
+			}
+
+			id, keyword := tr.ID, "msgid"
+			if i > 0 && tr.PluralID != "" {
+				id, keyword = tr.PluralID, "msgid_plural"
+			}
+			msgstr := "msgstr"
+			if tr.PluralID != "" {
+				msgstr = "msgstr[" + strconv.Itoa(i) + "]"
+			}
+
+			if strings.HasPrefix(id, "\n") != strings.HasPrefix(str, "\n") {
+				fail(fmt.Errorf("%w: %s and %s don't both begin with \\n", ErrNewlineMismatch, keyword, msgstr))
+			}
+			if strings.HasSuffix(id, "\n") != strings.HasSuffix(str, "\n") {
+				fail(fmt.Errorf("%w: %s and %s don't both end with \\n", ErrNewlineMismatch, keyword, msgstr))
+			}
+		}
+	}
+
+	if len(diags) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i], diags[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		if a.Context != b.Context {
+			return a.Context < b.Context
+		}
+		return a.ID < b.ID
+	})
+
+	return diags
+}
+
+// validatePluralForms checks a Plural-Forms header value and returns its nplurals.
+// An empty value is only valid when the catalog doesn't have plural entries.
+func validatePluralForms(pf string, hasPlurals bool) (int, error) {
+	if pf == "" {
+		if hasPlurals {
+			return 0, fmt.Errorf("%w: missing, but the catalog has plural entries", ErrInvalidPluralForms)
+		}
+		return 0, nil
+	}
+
+	nplurals, plural := -1, ""
+	for _, f := range strings.Split(pf, ";") {
+		vs := strings.SplitN(f, "=", 2)
+		if len(vs) != 2 {
+			continue
+		}
+
+		switch strings.TrimSpace(vs[0]) {
+		case "nplurals":
+			n, err := strconv.Atoi(strings.TrimSpace(vs[1]))
+			if err != nil || n < 1 || n > poMaxForms {
+				return 0, fmt.Errorf("%w: invalid nplurals %q", ErrInvalidPluralForms, strings.TrimSpace(vs[1]))
+			}
+			nplurals = n
+
+		case "plural":
+			plural = strings.TrimSpace(vs[1])
+		}
+	}
+
+	if nplurals == -1 {
+		return 0, fmt.Errorf("%w: missing nplurals", ErrInvalidPluralForms)
+	}
+	if plural == "" {
+		return 0, fmt.Errorf("%w: missing plural expression", ErrInvalidPluralForms)
+	}
+
+	expr, err := plurals.Compile(plural)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidPluralForms, err)
+	}
+
+	for n := uint32(0); n <= pluralCheckMax; n++ {
+		if i := expr.Eval(n); i < 0 || i >= nplurals {
+			return nplurals, fmt.Errorf("%w: index %d for n = %d, but nplurals is %d", ErrPluralRange, i, n, nplurals)
+		}
+	}
+
+	return nplurals, nil
+}
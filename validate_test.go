@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const validateHeader = `msgid ""
+msgstr ""
+"Project-Id-Version: test 1.0\n"
+"PO-Revision-Date: 2020-01-01 00:00+0000\n"
+"Last-Translator: Jane Doe <jane@example.com>\n"
+"Language-Team: German <de@example.com>\n"
+"Language: de\n"
+"MIME-Version: 1.0\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Content-Transfer-Encoding: 8bit\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+`
+
+func TestPoValidate(t *testing.T) {
+	// Valid catalog
+	valid := []byte(validateHeader + `
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "Eine Datei"
+msgstr[1] "%d Dateien"
+
+msgid "Untranslated\n"
+msgstr ""
+
+#, fuzzy
+msgid "Fuzzy\n"
+msgstr "Unscharf"
+`)
+	po := new(Po)
+	po.Parse(valid)
+	if diags := po.Validate(); diags != nil {
+		t.Errorf("Unexpected diagnostics: %v", diags)
+	}
+
+	// Parsing the source again replaces its entries without duplicates
+	po.Parse(valid)
+	if diags := po.Validate(); diags != nil {
+		t.Errorf("Unexpected diagnostics after parsing again: %v", diags)
+	}
+
+	// Fuzzy entries are checked when allowed
+	po.SetAllowFuzzy(true)
+	if diags := po.Validate(); len(diags) != 1 || !errors.Is(diags[0], ErrNewlineMismatch) || diags[0].Line != 22 {
+		t.Errorf("Expected a newline mismatch at line 22 but got %v", diags)
+	}
+
+	// Broken catalog
+	po = new(Po)
+	po.Parse([]byte(validateHeader + `
+msgid "Dup"
+msgstr "Erste"
+
+msgid "Dup"
+msgstr "Zweite"
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "Eine Datei"
+
+msgctxt "Ctx"
+msgid "\nLeading"
+msgstr "Führend"
+
+msgid "Trailing\n"
+msgid_plural "Trailings\n"
+msgstr[0] "Folgend\n"
+msgstr[1] "Folgende"
+`))
+
+	expected := []struct {
+		line int
+		ctx  string
+		err  error
+	}{
+		{16, "", ErrDuplicate},
+		{19, "", ErrPluralCount},
+		{23, "Ctx", ErrNewlineMismatch},
+		{27, "", ErrNewlineMismatch},
+	}
+	diags := po.Validate()
+	if len(diags) != len(expected) {
+		t.Fatalf("Expected %d diagnostics but got %v", len(expected), diags)
+	}
+	for i, e := range expected {
+		if d := diags[i]; d.Line != e.line || d.Column != 1 || d.Context != e.ctx || !errors.Is(d, e.err) {
+			t.Errorf("Expected '%v' at line %d but got '%v'", e.err, e.line, d)
+		}
+	}
+	if !strings.Contains(diags[3].Error(), "msgid_plural and msgstr[1] don't both end with \\n") {
+		t.Errorf("Unexpected message: %v", diags[3])
+	}
+}
+
+func TestPoValidateForms(t *testing.T) {
+	po := new(Po)
+	po.Parse([]byte(validateHeader + `
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "Eine Datei"
+msgstr[1] "%d Dateien"
+`))
+
+	// Forms out of nplurals are reported, without checking the ones in between
+	po.GetTranslation("One file").Trs[20000000] = "Viele Dateien"
+	diags := po.Validate()
+	if len(diags) != 1 || !errors.Is(diags[0], ErrPluralCount) || !strings.Contains(diags[0].Error(), "msgstr[20000000] is out of nplurals 2") {
+		t.Errorf("Expected an out of nplurals form but got %v", diags)
+	}
+}
+
+func TestPoValidateHeader(t *testing.T) {
+	for _, c := range []struct {
+		str string
+		err error
+		msg string
+	}{
+		{`msgid "a"` + "\n" + `msgstr "b"`, ErrInvalidHeader, "missing header entry"},
+		{strings.Replace(validateHeader, `"Language-Team: German <de@example.com>\n"`, "", 1), ErrInvalidHeader, `missing field "Language-Team"`},
+		{strings.Replace(validateHeader, `"Language: de\n"`, `"Language de\n"`, 1), ErrInvalidHeader, `line "Language de" isn't a field`},
+		{strings.Replace(validateHeader, `nplurals=2;`, `nplurals=two;`, 1), ErrInvalidPluralForms, `invalid nplurals "two"`},
+		{strings.Replace(validateHeader, `nplurals=2;`, `nplurals=20000000;`, 1), ErrInvalidPluralForms, `invalid nplurals "20000000"`},
+		{strings.Replace(validateHeader, `plural=(n != 1);`, `plural=(n !! 1);`, 1), ErrInvalidPluralForms, ""},
+		{strings.Replace(validateHeader, `plural=(n != 1);`, `;`, 1), ErrInvalidPluralForms, "missing plural expression"},
+		{strings.Replace(validateHeader, `plural=(n != 1);`, `plural=(n==1 ? 0 : 2);`, 1), ErrPluralRange, "index 2 for n = 0, but nplurals is 2"},
+		{strings.Replace(validateHeader, `"Plural-Forms: nplurals=2; plural=(n != 1);\n"`, "", 1) + "msgid \"a\"\nmsgid_plural \"b\"\nmsgstr[0] \"c\"\nmsgstr[1] \"d\"\n", ErrInvalidPluralForms, "catalog has plural entries"},
+	} {
+		po := new(Po)
+		po.Parse([]byte(c.str))
+
+		diags := po.Validate()
+		if len(diags) == 0 || !errors.Is(diags[0], c.err) || !strings.Contains(diags[0].Error(), c.msg) {
+			t.Errorf("Expected '%v' containing %q but got %v", c.err, c.msg, diags)
+		}
+	}
+}
+
+func TestMoValidate(t *testing.T) {
+	mo := new(Mo)
+	mo.Parse(buildMo(map[string]string{
+		"":                  "Language: de\nContent-Type: text/plain; charset=UTF-8\nPlural-Forms: nplurals=3; plural=(n==1 ? 0 : 2);\n",
+		"One file\x00Files": "Eine Datei\x00Dateien",
+		"Line\n":            "Zeile",
+	}))
+
+	diags := mo.Validate()
+	for _, err := range []error{ErrInvalidHeader, ErrPluralCount, ErrNewlineMismatch} {
+		found := false
+		for _, d := range diags {
+			found = found || errors.Is(d, err)
+		}
+		if !found {
+			t.Errorf("Expected '%v' in %v", err, diags)
+		}
+	}
+}
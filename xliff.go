@@ -0,0 +1,519 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strings"
+)
+
+// XLIFF namespaces
+const (
+	xliff12Namespace = "urn:oasis:names:tc:xliff:document:1.2"
+	xliff20Namespace = "urn:oasis:names:tc:xliff:document:2.0"
+)
+
+// Names used to keep the gettext specific data of the entries on XLIFF files.
+const (
+	xliffHeaderNote     = "po-header"
+	xliffContextNote    = "msgctxt"
+	xliffTranslatorNote = "translator"
+	xliffDeveloperNote  = "developer"
+
+	xliff12PluralType  = "x-gettext-plurals"
+	xliff12ContextType = "x-gettext-msgctxt"
+	xliff20PluralType  = "gettext:plurals"
+	xliff20FuzzyState  = "gettext:fuzzy"
+)
+
+/*
+Xliff parses the content of XLIFF 1.2 and 2.0 files and provides all the Translation functions needed.
+And it's safe for concurrent use by multiple goroutines by using the sync package for locking.
+
+Units are read with their source text as msgid and their target text as translation.
+The gettext data is mapped as XliffEncoder writes it:
+
+  - The PO header entry is the file note from "po-header" (1.2), or with the "po-header" category (2.0).
+  - Contexts are a context of the "x-gettext-msgctxt" type (1.2), or a note with the "msgctxt" category (2.0).
+  - Plural entries are groups of the "x-gettext-plurals" (1.2) or "gettext:plurals" (2.0) type,
+    with a unit for every plural form.
+  - Translator and extracted comments are notes from "translator" and "developer" (1.2),
+    or with those categories (2.0). Other notes are read as translator comments.
+  - Fuzzy entries have a target state needing review or a unit with approved="no" (1.2),
+    or the "gettext:fuzzy" subState (2.0).
+    Targets of new units are fuzzy too, and units without target are untranslated.
+
+Example:
+
+	import (
+		"fmt"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		// Create xliff object
+		x := gotext.NewXliffTranslator()
+
+		// Parse .xliff file
+		x.ParseFile("/path/to/xliff/file/translations.xliff")
+
+		// Get Translation
+		fmt.Println(x.Get("Translate this"))
+	}
+*/
+type Xliff struct {
+	catalog
+}
+
+// xliffNote is a note of XLIFF files, using the from attribute on 1.2 and category on 2.0.
+type xliffNote struct {
+	From     string `xml:"from,attr,omitempty"`
+	Category string `xml:"category,attr,omitempty"`
+	Text     string `xml:",chardata"`
+}
+
+// xliff12 is the schema of XLIFF 1.2 files.
+type xliff12 struct {
+	XMLName xml.Name      `xml:"xliff"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Version string        `xml:"version,attr"`
+	Files   []xliff12File `xml:"file"`
+}
+
+type xliff12File struct {
+	Original       string         `xml:"original,attr"`
+	SourceLanguage string         `xml:"source-language,attr"`
+	TargetLanguage string         `xml:"target-language,attr,omitempty"`
+	Datatype       string         `xml:"datatype,attr"`
+	Header         *xliff12Header `xml:"header"`
+	Body           xliff12Group   `xml:"body"`
+}
+
+type xliff12Header struct {
+	Notes []xliffNote `xml:"note"`
+}
+
+type xliff12Group struct {
+	ID       string                `xml:"id,attr,omitempty"`
+	Restype  string                `xml:"restype,attr,omitempty"`
+	Contexts []xliff12ContextGroup `xml:"context-group"`
+	Notes    []xliffNote           `xml:"note"`
+	Units    []xliff12Unit         `xml:"trans-unit"`
+	Groups   []xliff12Group        `xml:"group"`
+
+	// Units and groups written by XliffEncoder in the order of the messages
+	Elements []xliffElement `xml:",any"`
+}
+
+type xliff12ContextGroup struct {
+	Purpose  string           `xml:"purpose,attr,omitempty"`
+	Contexts []xliff12Context `xml:"context"`
+}
+
+type xliff12Context struct {
+	Type string `xml:"context-type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type xliff12Unit struct {
+	ID       string                `xml:"id,attr"`
+	Approved string                `xml:"approved,attr,omitempty"`
+	Source   string                `xml:"source"`
+	Target   *xliff12Target        `xml:"target"`
+	Contexts []xliff12ContextGroup `xml:"context-group"`
+	Notes    []xliffNote           `xml:"note"`
+}
+
+type xliff12Target struct {
+	State string `xml:"state,attr,omitempty"`
+	Text  string `xml:",chardata"`
+}
+
+// xliff20 is the schema of XLIFF 2.0 files.
+type xliff20 struct {
+	XMLName xml.Name      `xml:"xliff"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Version string        `xml:"version,attr"`
+	SrcLang string        `xml:"srcLang,attr"`
+	TrgLang string        `xml:"trgLang,attr,omitempty"`
+	Files   []xliff20File `xml:"file"`
+}
+
+type xliff20File struct {
+	ID       string         `xml:"id,attr"`
+	Original string         `xml:"original,attr,omitempty"`
+	Notes    *xliff20Notes  `xml:"notes"`
+	Units    []xliff20Unit  `xml:"unit"`
+	Groups   []xliff20Group `xml:"group"`
+
+	// Units and groups written by XliffEncoder in the order of the messages
+	Elements []xliffElement `xml:",any"`
+}
+
+type xliff20Notes struct {
+	Notes []xliffNote `xml:"note"`
+}
+
+type xliff20Group struct {
+	ID     string         `xml:"id,attr"`
+	Type   string         `xml:"type,attr,omitempty"`
+	Notes  *xliff20Notes  `xml:"notes"`
+	Units  []xliff20Unit  `xml:"unit"`
+	Groups []xliff20Group `xml:"group"`
+}
+
+type xliff20Unit struct {
+	ID       string           `xml:"id,attr"`
+	Notes    *xliff20Notes    `xml:"notes"`
+	Segments []xliff20Segment `xml:"segment"`
+}
+
+type xliff20Segment struct {
+	State    string  `xml:"state,attr,omitempty"`
+	SubState string  `xml:"subState,attr,omitempty"`
+	Source   string  `xml:"source"`
+	Target   *string `xml:"target"`
+}
+
+// NewXliffTranslator creates a new Xliff object with the Translator interface
+func NewXliffTranslator() Translator {
+	return new(Xliff)
+}
+
+// ParseFile tries to read the file by its provided path (f) and parse its content as a XLIFF file.
+// Gzip compressed files, like translations.xliff.gz, are decompressed.
+func (x *Xliff) ParseFile(f string) {
+	x.ParseFileE(f)
+}
+
+// ParseFileE works like ParseFile, but returns an error when the file can't be read or parsed.
+// A missing file or a directory path returns an error wrapping ErrNotFound.
+func (x *Xliff) ParseFileE(f string) error {
+	return x.parseFile(f, x.ParseE)
+}
+
+// Parse loads the translations specified in the provided XLIFF content (buf).
+func (x *Xliff) Parse(buf []byte) {
+	x.ParseE(buf)
+}
+
+// ParseE works like Parse, but returns a *ParseError when buf isn't a XLIFF 1.2 or 2.0 file.
+// The error wraps ErrInvalidCatalog, and nothing is loaded then.
+func (x *Xliff) ParseE(buf []byte) error {
+	version, err := xliffVersion(buf)
+	if err != nil {
+		return err
+	}
+
+	var headers textproto.MIMEHeader
+	var trs []*Translation
+	if strings.HasPrefix(version, "1.") {
+		var doc xliff12
+		if err := xml.Unmarshal(buf, &doc); err != nil {
+			return xmlError(err)
+		}
+		headers, trs = doc.translations()
+	} else {
+		var doc xliff20
+		if err := xml.Unmarshal(buf, &doc); err != nil {
+			return xmlError(err)
+		}
+		headers, trs = doc.translations()
+	}
+
+	// Lock while storing
+	x.Lock()
+	defer x.Unlock()
+
+	if headers != nil {
+		x.setHeaders(headers)
+	}
+	for _, tr := range trs {
+		x.add(tr)
+	}
+
+	return nil
+}
+
+// xliffVersion returns the version of the XLIFF root element of data.
+func xliffVersion(data []byte) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return "", &ParseError{Err: fmt.Errorf("%w: missing xliff element", ErrInvalidCatalog)}
+			}
+			return "", xmlError(err)
+		}
+
+		root, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root.Name.Local != "xliff" {
+			line, col := dec.InputPos()
+			return "", &ParseError{Line: line, Column: col, Err: fmt.Errorf("%w: root element %q isn't xliff", ErrInvalidCatalog, root.Name.Local)}
+		}
+
+		for _, attr := range root.Attr {
+			if attr.Name.Local == "version" && (strings.HasPrefix(attr.Value, "1.") || strings.HasPrefix(attr.Value, "2.")) {
+				return attr.Value, nil
+			}
+		}
+		line, col := dec.InputPos()
+		return "", &ParseError{Line: line, Column: col, Err: fmt.Errorf("%w: unsupported XLIFF version", ErrInvalidCatalog)}
+	}
+}
+
+// xmlError returns the *ParseError of an encoding/xml error.
+func xmlError(err error) error {
+	var se *xml.SyntaxError
+	if errors.As(err, &se) {
+		return &ParseError{Line: se.Line, Err: fmt.Errorf("%w: %s", ErrInvalidCatalog, se.Msg)}
+	}
+
+	return &ParseError{Err: fmt.Errorf("%w: %v", ErrInvalidCatalog, err)}
+}
+
+// parseHeaderNote parses the PO header entry kept on a note.
+func parseHeaderNote(s string) textproto.MIMEHeader {
+	h, err := textproto.NewReader(bufio.NewReader(strings.NewReader(s + "\n\n"))).ReadMIMEHeader()
+	if err != nil && len(h) == 0 {
+		return nil
+	}
+
+	return h
+}
+
+// addNotes adds the text of the notes to the comments of tr.
+func addNotes(tr *Translation, notes []xliffNote) {
+	for _, n := range notes {
+		kind := n.From + n.Category
+		lines := strings.Split(n.Text, "\n")
+
+		switch kind {
+		case xliffHeaderNote:
+		case xliffContextNote:
+			tr.Context = n.Text
+		case xliffDeveloperNote:
+			tr.ExtractedComments = append(tr.ExtractedComments, lines...)
+		default:
+			tr.TranslatorComments = append(tr.TranslatorComments, lines...)
+		}
+	}
+}
+
+// setFuzzy adds the fuzzy flag to tr once.
+func setFuzzy(tr *Translation) {
+	if !tr.IsFuzzy() {
+		tr.Flags = append(tr.Flags, "fuzzy")
+	}
+}
+
+// translations returns the headers and entries of a XLIFF 1.2 document.
+func (doc *xliff12) translations() (textproto.MIMEHeader, []*Translation) {
+	var headers textproto.MIMEHeader
+	var trs []*Translation
+
+	for _, f := range doc.Files {
+		if f.Header != nil {
+			for _, n := range f.Header.Notes {
+				if n.From == xliffHeaderNote {
+					headers = parseHeaderNote(n.Text)
+				}
+			}
+		}
+		if headers == nil && f.TargetLanguage != "" {
+			headers = textproto.MIMEHeader{"Language": {f.TargetLanguage}}
+		}
+
+		trs = f.Body.translations(trs)
+	}
+
+	return headers, trs
+}
+
+// xliff12MsgCtxt returns the msgctxt kept on the context groups.
+func xliff12MsgCtxt(groups []xliff12ContextGroup) string {
+	for _, g := range groups {
+		for _, c := range g.Contexts {
+			if c.Type == xliff12ContextType {
+				return c.Text
+			}
+		}
+	}
+
+	return ""
+}
+
+// translations appends the entries of the units of g, and of its groups, to trs.
+func (g *xliff12Group) translations(trs []*Translation) []*Translation {
+	// Plural entries
+	if g.Restype == xliff12PluralType && len(g.Units) > 0 {
+		tr := NewTranslation()
+		tr.Context = xliff12MsgCtxt(g.Contexts)
+		addNotes(tr, g.Notes)
+		for i, u := range g.Units {
+			switch i {
+			case 0:
+				tr.ID = u.Source
+			case 1:
+				tr.PluralID = u.Source
+			}
+			if u.Target != nil {
+				tr.Trs[i] = u.Target.Text
+				if u.isFuzzy() {
+					setFuzzy(tr)
+				}
+			}
+		}
+		return append(trs, tr)
+	}
+
+	for _, u := range g.Units {
+		tr := NewTranslation()
+		tr.ID = u.Source
+		tr.Context = xliff12MsgCtxt(u.Contexts)
+		addNotes(tr, u.Notes)
+		if u.Target != nil {
+			tr.Trs[0] = u.Target.Text
+			if u.isFuzzy() {
+				setFuzzy(tr)
+			}
+		}
+		trs = append(trs, tr)
+	}
+	for i := range g.Groups {
+		trs = g.Groups[i].translations(trs)
+	}
+
+	return trs
+}
+
+// isFuzzy reports whether the target of u isn't approved or its state needs review.
+func (u *xliff12Unit) isFuzzy() bool {
+	if u.Target == nil {
+		return false
+	}
+	if u.Approved == "no" {
+		return true
+	}
+
+	switch u.Target.State {
+	case "new", "needs-adaptation", "needs-l10n", "needs-review-adaptation", "needs-review-l10n", "needs-review-translation":
+		return true
+	}
+
+	return false
+}
+
+// list returns the notes, or nil when there aren't any.
+func (n *xliff20Notes) list() []xliffNote {
+	if n == nil {
+		return nil
+	}
+
+	return n.Notes
+}
+
+// translations returns the headers and entries of a XLIFF 2.0 document.
+func (doc *xliff20) translations() (textproto.MIMEHeader, []*Translation) {
+	var headers textproto.MIMEHeader
+	var trs []*Translation
+
+	for _, f := range doc.Files {
+		for _, n := range f.Notes.list() {
+			if n.Category == xliffHeaderNote {
+				headers = parseHeaderNote(n.Text)
+			}
+		}
+		if headers == nil && doc.TrgLang != "" {
+			headers = textproto.MIMEHeader{"Language": {doc.TrgLang}}
+		}
+
+		g := xliff20Group{Units: f.Units, Groups: f.Groups}
+		trs = g.translations(trs)
+	}
+
+	return headers, trs
+}
+
+// translations appends the entries of the units of g, and of its groups, to trs.
+func (g *xliff20Group) translations(trs []*Translation) []*Translation {
+	// Plural entries
+	if g.Type == xliff20PluralType && len(g.Units) > 0 {
+		tr := NewTranslation()
+		addNotes(tr, g.Notes.list())
+		for i, u := range g.Units {
+			source, target, fuzzy := u.text()
+			switch i {
+			case 0:
+				tr.ID = source
+			case 1:
+				tr.PluralID = source
+			}
+			if target != nil {
+				tr.Trs[i] = *target
+			}
+			if fuzzy {
+				setFuzzy(tr)
+			}
+		}
+		return append(trs, tr)
+	}
+
+	for _, u := range g.Units {
+		tr := NewTranslation()
+		addNotes(tr, u.Notes.list())
+
+		source, target, fuzzy := u.text()
+		tr.ID = source
+		if target != nil {
+			tr.Trs[0] = *target
+		}
+		if fuzzy {
+			setFuzzy(tr)
+		}
+		trs = append(trs, tr)
+	}
+	for i := range g.Groups {
+		trs = g.Groups[i].translations(trs)
+	}
+
+	return trs
+}
+
+// text returns the source and target text of the segments of u, and whether they're fuzzy.
+// The target is nil when the unit isn't translated.
+func (u *xliff20Unit) text() (string, *string, bool) {
+	var source, target strings.Builder
+	translated, fuzzy := false, false
+
+	for _, s := range u.Segments {
+		source.WriteString(s.Source)
+		if s.Target != nil {
+			target.WriteString(*s.Target)
+			translated = true
+			if s.SubState == xliff20FuzzyState || s.State == "initial" || s.State == "" {
+				fuzzy = fuzzy || *s.Target != ""
+			}
+		}
+	}
+
+	if !translated {
+		return source.String(), nil, false
+	}
+	t := target.String()
+
+	return source.String(), &t, fuzzy
+}
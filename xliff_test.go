@@ -0,0 +1,248 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+const xliff12Str = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file original="default" source-language="en" target-language="de" datatype="po">
+    <body>
+      <trans-unit id="hello" approved="yes">
+        <source>Hello</source>
+        <target state="translated">Hallo</target>
+        <note>Greeting</note>
+      </trans-unit>
+      <trans-unit id="open">
+        <source>Open</source>
+        <target state="needs-review-translation">Öffnen</target>
+        <context-group purpose="information">
+          <context context-type="x-gettext-msgctxt">menu</context>
+        </context-group>
+        <note from="developer">File menu entry</note>
+      </trans-unit>
+      <trans-unit id="missing">
+        <source>Missing</source>
+      </trans-unit>
+      <group id="files" restype="x-gettext-plurals">
+        <trans-unit id="files-0">
+          <source>One file</source>
+          <target>Eine Datei</target>
+        </trans-unit>
+        <trans-unit id="files-1">
+          <source>%d files</source>
+          <target>%d Dateien</target>
+        </trans-unit>
+      </group>
+      <group id="dialogs">
+        <trans-unit id="cancel">
+          <source>Cancel</source>
+          <target>Abbrechen</target>
+        </trans-unit>
+        <trans-unit id="close" approved="no">
+          <source>Close</source>
+          <target state="translated">Schließen</target>
+        </trans-unit>
+      </group>
+    </body>
+  </file>
+</xliff>
+`
+
+const xliff20Str = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en" trgLang="de">
+  <file id="f1">
+    <notes>
+      <note category="po-header">Language: de
+Plural-Forms: nplurals=2; plural=(n != 1);
+</note>
+    </notes>
+    <unit id="hello">
+      <notes>
+        <note category="translator">Greeting</note>
+      </notes>
+      <segment state="final">
+        <source>Hello, </source>
+        <target>Hallo, </target>
+      </segment>
+      <segment state="final">
+        <source>world</source>
+        <target>Welt</target>
+      </segment>
+    </unit>
+    <unit id="open">
+      <notes>
+        <note category="msgctxt">menu</note>
+      </notes>
+      <segment state="translated" subState="gettext:fuzzy">
+        <source>Open</source>
+        <target>Öffnen</target>
+      </segment>
+    </unit>
+    <group id="files" type="gettext:plurals">
+      <unit id="files-0">
+        <segment state="translated">
+          <source>One file</source>
+          <target>Eine Datei</target>
+        </segment>
+      </unit>
+      <unit id="files-1">
+        <segment state="translated">
+          <source>%d files</source>
+          <target>%d Dateien</target>
+        </segment>
+      </unit>
+    </group>
+  </file>
+</xliff>
+`
+
+// xliffTranslation returns the entry of x stored with the context ctx
+func xliffTranslation(x *Xliff, id, ctx string) *Translation {
+	if ctx == "" {
+		return x.translations[id]
+	}
+	return x.contexts[ctx][id]
+}
+
+func TestXliff12(t *testing.T) {
+	x := new(Xliff)
+	if err := x.ParseE([]byte(xliff12Str)); err != nil {
+		t.Fatal(err)
+	}
+
+	if x.Language != "de" {
+		t.Errorf("Expected language 'de' but got '%s'", x.Language)
+	}
+
+	get, getN, getC := x.Get, x.GetN, x.GetC
+	for _, c := range []struct{ got, expected string }{
+		{get("Hello"), "Hallo"},
+		{getC("Open", "menu"), "Open"},
+		{get("Missing"), "Missing"},
+		{getN("One file", "%d files", 1), "Eine Datei"},
+		{getN("One file", "%d files", 3, 3), "3 Dateien"},
+		{get("Cancel"), "Abbrechen"},
+		{get("Close"), "Close"},
+	} {
+		if c.got != c.expected {
+			t.Errorf("Expected '%s' but got '%s'", c.expected, c.got)
+		}
+	}
+
+	x.SetAllowFuzzy(true)
+	if tr := getC("Open", "menu"); tr != "Öffnen" {
+		t.Errorf("Expected 'Öffnen' with fuzzy entries allowed but got '%s'", tr)
+	}
+
+	if tr := xliffTranslation(x, "Hello", ""); tr.IsFuzzy() || len(tr.TranslatorComments) != 1 || tr.TranslatorComments[0] != "Greeting" {
+		t.Errorf("Unexpected entry %+v", tr)
+	}
+	if tr := xliffTranslation(x, "Open", "menu"); !tr.IsFuzzy() || len(tr.ExtractedComments) != 1 || tr.ExtractedComments[0] != "File menu entry" {
+		t.Errorf("Unexpected entry %+v", tr)
+	}
+	if tr := xliffTranslation(x, "Close", ""); !tr.IsFuzzy() || tr.Trs[0] != "Schließen" {
+		t.Errorf("Expected fuzzy entry but got %+v", tr)
+	}
+	if tr := xliffTranslation(x, "Missing", ""); tr.IsTranslated() {
+		t.Errorf("Expected untranslated entry but got %+v", tr)
+	}
+}
+
+func TestXliff20(t *testing.T) {
+	x := new(Xliff)
+	if err := x.ParseE([]byte(xliff20Str)); err != nil {
+		t.Fatal(err)
+	}
+
+	if x.Language != "de" || x.PluralForms != "nplurals=2; plural=(n != 1);" {
+		t.Errorf("Unexpected headers: '%s', '%s'", x.Language, x.PluralForms)
+	}
+
+	get, getN, getC := x.Get, x.GetN, x.GetC
+	for _, c := range []struct{ got, expected string }{
+		{get("Hello, world"), "Hallo, Welt"},
+		{getC("Open", "menu"), "Open"},
+		{getN("One file", "%d files", 1), "Eine Datei"},
+		{getN("One file", "%d files", 3, 3), "3 Dateien"},
+	} {
+		if c.got != c.expected {
+			t.Errorf("Expected '%s' but got '%s'", c.expected, c.got)
+		}
+	}
+
+	x.SetAllowFuzzy(true)
+	if tr := getC("Open", "menu"); tr != "Öffnen" {
+		t.Errorf("Expected 'Öffnen' with fuzzy entries allowed but got '%s'", tr)
+	}
+
+	if tr := xliffTranslation(x, "Hello, world", ""); tr.IsFuzzy() || len(tr.TranslatorComments) != 1 {
+		t.Errorf("Unexpected entry %+v", tr)
+	}
+	if tr := xliffTranslation(x, "Open", "menu"); !tr.IsFuzzy() {
+		t.Errorf("Expected fuzzy entry but got %+v", tr)
+	}
+	if tr := xliffTranslation(x, "One file", ""); tr.IsFuzzy() || tr.PluralID != "%d files" {
+		t.Errorf("Unexpected entry %+v", tr)
+	}
+}
+
+func TestXliffErrors(t *testing.T) {
+	for name, c := range map[string]struct {
+		xliff string
+		line  int
+	}{
+		"syntax":   {"<xliff version=\"1.2\">\n  <file>\n</xliff>", 3},
+		"root":     {"<?xml version=\"1.0\"?>\n<resources/>", 2},
+		"version":  {"<xliff version=\"3.0\"/>", 1},
+		"empty":    {"", 0},
+		"mismatch": {"<xliff version=\"2.0\">\n<file id=\"f1\"><unit id=\"1\"></file>\n</xliff>", 2},
+	} {
+		x := new(Xliff)
+		x.Parse([]byte(xliff12Str))
+
+		err := x.ParseE([]byte(c.xliff))
+
+		var pe *ParseError
+		if !errors.As(err, &pe) || !errors.Is(err, ErrInvalidCatalog) {
+			t.Errorf("%s: expected ErrInvalidCatalog but got '%v'", name, err)
+			continue
+		}
+		if pe.Line != c.line {
+			t.Errorf("%s: expected error on line %d but got '%v'", name, c.line, err)
+		}
+
+		// Nothing is loaded
+		if s := x.Get("Hello"); s != "Hallo" {
+			t.Errorf("%s: expected 'Hallo' but got '%s'", name, s)
+		}
+	}
+}
+
+func TestXliffLocale(t *testing.T) {
+	fsys := fstest.MapFS{
+		"de/LC_MESSAGES/default.xlf": {Data: []byte(xliff12Str)},
+		"de/LC_MESSAGES/other.xliff": {Data: []byte(xliff20Str)},
+	}
+
+	l := NewLocaleFS(fsys, "de_DE")
+	if err := l.AddDomainE("default"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddDomainE("other"); err != nil {
+		t.Fatal(err)
+	}
+	if s := l.GetN("One file", "%d files", 2, 2); s != "2 Dateien" {
+		t.Errorf("Expected '2 Dateien' but got '%s'", s)
+	}
+	if s := l.GetD("other", "Hello, world"); s != "Hallo, Welt" {
+		t.Errorf("Expected 'Hallo, Welt' but got '%s'", s)
+	}
+}
@@ -0,0 +1,356 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+XliffEncoder writes catalogs as XLIFF 1.2 or 2.0 files, with the msgids as source text
+and the translations as target text, to be translated by tools that only read XLIFF.
+Any Translator can be encoded, as its entries are read through MarshalBinary.
+
+Every entry is written, untranslated and fuzzy ones included, along with its context,
+plural forms, comments and header entry, so a PO catalog read back with Xliff keeps all of them
+but the references and the other flags.
+
+Example:
+
+	import (
+		"os"
+		"github.com/DeineAgenturUG/gotext"
+	)
+
+	func main() {
+		po := new(gotext.Po)
+		po.ParseFile("/path/to/po/file/translations.po")
+
+		f, _ := os.Create("/path/to/xliff/file/translations.xliff")
+		defer f.Close()
+
+		enc := gotext.NewXliffEncoder(f)
+		enc.Version = "2.0"
+		enc.Encode(po)
+	}
+*/
+type XliffEncoder struct {
+	// Version of the XLIFF files, "1.2" by default, or "2.0".
+	Version string
+
+	// SourceLanguage of the msgids, "en" by default.
+	SourceLanguage string
+
+	// TargetLanguage of the translations, the Language header of the catalog by default.
+	TargetLanguage string
+
+	// Original name of the file, "messages" by default.
+	Original string
+
+	// Indent of the nested elements, two spaces by default. Use "" for a compact output.
+	Indent string
+
+	w io.Writer
+}
+
+// NewXliffEncoder returns a XliffEncoder writing indented XLIFF 1.2 files to w.
+func NewXliffEncoder(w io.Writer) *XliffEncoder {
+	return &XliffEncoder{
+		Version:        "1.2",
+		SourceLanguage: "en",
+		Original:       "messages",
+		Indent:         "  ",
+		w:              w,
+	}
+}
+
+// MarshalXliff returns the catalog of t as the content of a XLIFF 1.2 file.
+func MarshalXliff(t Translator) ([]byte, error) {
+	var buff bytes.Buffer
+	err := NewXliffEncoder(&buff).Encode(t)
+
+	return buff.Bytes(), err
+}
+
+// xliffEntry is an entry of the encoded catalog, with the number of plural forms to write.
+type xliffEntry struct {
+	ctx   string
+	tr    *Translation
+	forms int
+}
+
+// Encode writes the catalog of t as a XLIFF file. Entries are sorted by context and id.
+func (enc *XliffEncoder) Encode(t Translator) error {
+	te, err := encodeTranslator(t)
+	if err != nil {
+		return err
+	}
+
+	nplurals := te.Nplurals
+	if nplurals < 1 {
+		nplurals = 2
+	}
+
+	var entries []xliffEntry
+	add := func(ctx string, tr *Translation) {
+		if tr.ID == "" {
+			return
+		}
+
+		// Plural entries have at least two units, to keep the msgid_plural
+		forms := 0
+		if tr.PluralID != "" {
+			forms = nplurals
+			if forms < 2 {
+				forms = 2
+			}
+			for i := range tr.Trs {
+				if i >= forms {
+					forms = i + 1
+				}
+			}
+		}
+		entries = append(entries, xliffEntry{ctx: ctx, tr: tr, forms: forms})
+	}
+	for _, tr := range te.Translations {
+		add("", tr)
+	}
+	for ctx, trs := range te.Contexts {
+		for _, tr := range trs {
+			add(ctx, tr)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.ctx != b.ctx {
+			return a.ctx < b.ctx
+		}
+		return a.tr.ID < b.tr.ID
+	})
+
+	var header string
+	if len(te.Headers) > 0 {
+		header = headerString(te.Headers, headerEntry(te))
+	}
+
+	target := enc.TargetLanguage
+	if target == "" {
+		target = te.Language
+	}
+
+	var doc interface{}
+	switch enc.Version {
+	case "", "1.2":
+		doc = enc.xliff12(entries, header, target)
+	case "2.0":
+		doc = enc.xliff20(entries, header, target)
+	default:
+		return fmt.Errorf("gotext: unsupported XLIFF version %q", enc.Version)
+	}
+
+	if _, err := io.WriteString(enc.w, xml.Header); err != nil {
+		return err
+	}
+	xe := xml.NewEncoder(enc.w)
+	xe.Indent("", enc.Indent)
+	if err := xe.Encode(doc); err != nil {
+		return err
+	}
+	_, err = io.WriteString(enc.w, "\n")
+
+	return err
+}
+
+// original returns the original file name of the encoded catalog.
+func (enc *XliffEncoder) original() string {
+	if enc.Original == "" {
+		return "messages"
+	}
+
+	return enc.Original
+}
+
+// sourceLanguage returns the source language of the encoded catalog.
+func (enc *XliffEncoder) sourceLanguage() string {
+	if enc.SourceLanguage == "" {
+		return "en"
+	}
+
+	return enc.SourceLanguage
+}
+
+// xliffElement is a unit or group written as the element name, which keeps the order of the mixed units and groups.
+type xliffElement struct {
+	name  string
+	value interface{}
+}
+
+// MarshalXML writes the value of el as its element.
+func (el xliffElement) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return e.EncodeElement(el.value, xml.StartElement{Name: xml.Name{Local: el.name}})
+}
+
+// UnmarshalXML skips the other elements of the files, which aren't kept.
+func (el *xliffElement) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
+	return d.Skip()
+}
+
+// xliffNotes returns the notes with the comments of tr, setting the from attribute or the category.
+func xliffNotes(tr *Translation, category bool) []xliffNote {
+	var notes []xliffNote
+	note := func(kind string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		n := xliffNote{Text: strings.Join(lines, "\n")}
+		if category {
+			n.Category = kind
+		} else {
+			n.From = kind
+		}
+		notes = append(notes, n)
+	}
+	note(xliffTranslatorNote, tr.TranslatorComments)
+	note(xliffDeveloperNote, tr.ExtractedComments)
+
+	return notes
+}
+
+// newXliff20Notes returns the notes element of the notes, or nil when there aren't any.
+func newXliff20Notes(notes []xliffNote) *xliff20Notes {
+	if len(notes) == 0 {
+		return nil
+	}
+
+	return &xliff20Notes{Notes: notes}
+}
+
+// pluralSource returns the source text of the plural form i of tr.
+func pluralSource(tr *Translation, i int) string {
+	if i == 0 {
+		return tr.ID
+	}
+
+	return tr.PluralID
+}
+
+// xliff12 builds the XLIFF 1.2 document of the entries.
+func (enc *XliffEncoder) xliff12(entries []xliffEntry, header, target string) *xliff12 {
+	f := xliff12File{
+		Original:       enc.original(),
+		SourceLanguage: enc.sourceLanguage(),
+		TargetLanguage: target,
+		Datatype:       "po",
+	}
+	if header != "" {
+		f.Header = &xliff12Header{Notes: []xliffNote{{From: xliffHeaderNote, Text: header}}}
+	}
+
+	// unit returns the unit of the source text and the translation s
+	unit := func(id, source, s string, fuzzy bool) xliff12Unit {
+		u := xliff12Unit{ID: id, Source: source}
+		switch {
+		case s == "":
+		case fuzzy:
+			u.Approved = "no"
+			u.Target = &xliff12Target{State: "needs-review-translation", Text: s}
+		default:
+			u.Approved = "yes"
+			u.Target = &xliff12Target{State: "translated", Text: s}
+		}
+		return u
+	}
+
+	for i, e := range entries {
+		id := strconv.Itoa(i + 1)
+
+		var contexts []xliff12ContextGroup
+		if e.ctx != "" {
+			contexts = []xliff12ContextGroup{{Purpose: "information", Contexts: []xliff12Context{{Type: xliff12ContextType, Text: e.ctx}}}}
+		}
+
+		if e.forms == 0 {
+			u := unit(id, e.tr.ID, e.tr.Trs[0], e.tr.IsFuzzy())
+			u.Contexts = contexts
+			u.Notes = xliffNotes(e.tr, false)
+			f.Body.Elements = append(f.Body.Elements, xliffElement{"trans-unit", u})
+			continue
+		}
+
+		g := xliff12Group{ID: id, Restype: xliff12PluralType, Contexts: contexts, Notes: xliffNotes(e.tr, false)}
+		for n := 0; n < e.forms; n++ {
+			g.Units = append(g.Units, unit(id+"-"+strconv.Itoa(n), pluralSource(e.tr, n), e.tr.Trs[n], e.tr.IsFuzzy()))
+		}
+		f.Body.Elements = append(f.Body.Elements, xliffElement{"group", g})
+	}
+
+	return &xliff12{
+		Xmlns:   xliff12Namespace,
+		Version: "1.2",
+		Files:   []xliff12File{f},
+	}
+}
+
+// xliff20 builds the XLIFF 2.0 document of the entries.
+func (enc *XliffEncoder) xliff20(entries []xliffEntry, header, target string) *xliff20 {
+	f := xliff20File{ID: "f1", Original: enc.original()}
+	if header != "" {
+		f.Notes = &xliff20Notes{Notes: []xliffNote{{Category: xliffHeaderNote, Text: header}}}
+	}
+
+	// unit returns the unit of the source text and the translation s
+	unit := func(id, source, s string, fuzzy bool) xliff20Unit {
+		seg := xliff20Segment{Source: source}
+		switch {
+		case s == "":
+			seg.State = "initial"
+		case fuzzy:
+			seg.State = "translated"
+			seg.SubState = xliff20FuzzyState
+			seg.Target = &s
+		default:
+			seg.State = "final"
+			seg.Target = &s
+		}
+		return xliff20Unit{ID: id, Segments: []xliff20Segment{seg}}
+	}
+
+	for i, e := range entries {
+		id := strconv.Itoa(i + 1)
+
+		notes := xliffNotes(e.tr, true)
+		if e.ctx != "" {
+			notes = append([]xliffNote{{Category: xliffContextNote, Text: e.ctx}}, notes...)
+		}
+
+		if e.forms == 0 {
+			u := unit(id, e.tr.ID, e.tr.Trs[0], e.tr.IsFuzzy())
+			u.Notes = newXliff20Notes(notes)
+			f.Elements = append(f.Elements, xliffElement{"unit", u})
+			continue
+		}
+
+		g := xliff20Group{ID: id, Type: xliff20PluralType, Notes: newXliff20Notes(notes)}
+		for n := 0; n < e.forms; n++ {
+			g.Units = append(g.Units, unit(id+"-"+strconv.Itoa(n), pluralSource(e.tr, n), e.tr.Trs[n], e.tr.IsFuzzy()))
+		}
+		f.Elements = append(f.Elements, xliffElement{"group", g})
+	}
+
+	return &xliff20{
+		Xmlns:   xliff20Namespace,
+		Version: "2.0",
+		SrcLang: enc.sourceLanguage(),
+		TrgLang: target,
+		Files:   []xliff20File{f},
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2018 DeineAgentur UG https://www.deineagentur.com. All rights reserved.
+ * Licensed under the MIT License. See LICENSE file in the project root for full license information.
+ */
+
+package gotext
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const xliffRoundTripPo = `msgid ""
+msgstr ""
+"Language: pl\n"
+"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+"X-Generator: test\n"
+
+# Greeting on the start page
+#. Shown once
+msgid "Hello"
+msgstr "Cześć"
+
+#, fuzzy
+msgctxt "menu"
+msgid "Open"
+msgstr "Otwórz"
+
+msgid "Untranslated <b>text</b>"
+msgstr ""
+
+msgid "Multi\nline"
+msgstr "Wiele\nlinii"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d plik"
+msgstr[1] "%d pliki"
+msgstr[2] "%d plików"
+
+msgctxt "menu"
+msgid "%d item"
+msgid_plural "%d items"
+msgstr[0] ""
+msgstr[1] ""
+msgstr[2] ""
+`
+
+const xliffSinglePluralPo = `msgid ""
+msgstr ""
+"Language: ja\n"
+"Plural-Forms: nplurals=1; plural=0;\n"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d ファイル"
+`
+
+func TestXliffRoundTrip(t *testing.T) {
+	testXliffRoundTrip(t, xliffRoundTripPo)
+}
+
+func TestXliffRoundTripSinglePlural(t *testing.T) {
+	testXliffRoundTrip(t, xliffSinglePluralPo)
+}
+
+func testXliffRoundTrip(t *testing.T, str string) {
+	po := new(Po)
+	if err := po.ParseE([]byte(str)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Decoded catalogs have no source order, so they're written sorted
+	src, err := encodeTranslator(po)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected bytes.Buffer
+	if _, err := src.GetTranslator().(*Po).WriteTo(&expected); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, version := range []string{"1.2", "2.0"} {
+		var buff bytes.Buffer
+		enc := NewXliffEncoder(&buff)
+		enc.Version = version
+		if err := enc.Encode(po); err != nil {
+			t.Fatalf("%s: %v", version, err)
+		}
+
+		x := new(Xliff)
+		if err := x.ParseE(buff.Bytes()); err != nil {
+			t.Fatalf("%s: %v\n%s", version, err, buff.String())
+		}
+
+		// Back to PO
+		te, err := encodeTranslator(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got bytes.Buffer
+		if _, err := te.GetTranslator().(*Po).WriteTo(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != expected.String() {
+			t.Errorf("%s: expected\n%s\nbut got\n%s\nfrom\n%s", version, expected.String(), got.String(), buff.String())
+		}
+	}
+}
+
+func TestXliffEncoderOrder(t *testing.T) {
+	po := new(Po)
+	po.Parse([]byte(xliffRoundTripPo))
+
+	// Plural groups come between the units, in the order of the messages
+	for _, version := range []string{"1.2", "2.0"} {
+		var buff bytes.Buffer
+		enc := NewXliffEncoder(&buff)
+		enc.Version = version
+		if err := enc.Encode(po); err != nil {
+			t.Fatalf("%s: %v", version, err)
+		}
+
+		last := -1
+		for i := 1; i <= 6; i++ {
+			id := fmt.Sprintf(`id="%d"`, i)
+			pos := strings.Index(buff.String(), id)
+			if pos <= last {
+				t.Errorf("%s: expected '%s' after the previous element in\n%s", version, id, buff.String())
+			}
+			last = pos
+		}
+	}
+}
+
+func TestMarshalXliff(t *testing.T) {
+	po := new(Po)
+	po.Parse([]byte(xliffRoundTripPo))
+
+	out, err := MarshalXliff(po)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := string(out)
+	for _, expected := range []string{
+		`<xliff xmlns="urn:oasis:names:tc:xliff:document:1.2" version="1.2">`,
+		`<file original="messages" source-language="en" target-language="pl" datatype="po">`,
+		`<trans-unit id="2" approved="yes">`,
+		`<target state="needs-review-translation">Otwórz</target>`,
+		`<context-group purpose="information">`,
+		`<context context-type="x-gettext-msgctxt">menu</context>`,
+		`<note from="translator">Greeting on the start page</note>`,
+		`<group id="1" restype="x-gettext-plurals">`,
+		`<source>Untranslated &lt;b&gt;text&lt;/b&gt;</source>`,
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("Expected '%s' in\n%s", expected, s)
+		}
+	}
+
+	enc := NewXliffEncoder(new(bytes.Buffer))
+	enc.Version = "3.0"
+	if err := enc.Encode(po); err == nil {
+		t.Error("Expected an error for an unsupported version")
+	}
+}